@@ -0,0 +1,209 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 访问日志格式
+const (
+	// AccessFormatCombined Apache/Nginx combined格式，GoAccess、awstats等工具可以直接分析
+	AccessFormatCombined = "combined"
+	// AccessFormatCommon Apache common格式，不含referer和user-agent
+	AccessFormatCommon = "common"
+	// AccessFormatJson 每行一个json对象
+	AccessFormatJson = "json"
+)
+
+// sinkAccessLog 访问日志的指标名称
+const sinkAccessLog = "access_log"
+
+// AccessLogConfig 访问日志配置，配置后HTTPMiddleware把访问日志写入单独的文件而不是普通日志，
+// 文件按Division切割，使用与普通日志相同的切割、压缩和清理配置
+type AccessLogConfig struct {
+	Filename string `toml:"filename" yaml:"filename" json:"filename"`
+	// Format 可选 "combined"(默认)、"common"、"json"
+	Format string `toml:"format" yaml:"format" json:"format"`
+}
+
+// accessLog 按格式写入访问日志
+type accessLog struct {
+	mu     sync.Mutex
+	w      io.Writer
+	file   io.Writer
+	format string
+	loc    *time.Location
+}
+
+// accessRecord 一次请求的访问日志内容
+type accessRecord struct {
+	start     time.Time
+	latency   time.Duration
+	remoteIP  string
+	user      string
+	method    string
+	uri       string
+	proto     string
+	status    int
+	bytes     int64
+	referer   string
+	userAgent string
+	requestID string
+}
+
+// accessLog 按AccessLog配置打开访问日志，未配置时返回nil
+func (c *LogOptions) accessLog() *accessLog {
+	cfg := c.AccessLog
+	if cfg.Filename == "" {
+		return nil
+	}
+	if err := c.prepareLogFile(cfg.Filename, c.Division == SizeDivision); err != nil {
+		panic(err)
+	}
+	w := c.divisionWriter(cfg.Filename)
+	if w == nil {
+		f, err := c.fallbackWriter(cfg.Filename)
+		if err != nil {
+			panic(err)
+		}
+		w = f
+	}
+	a := &accessLog{w: c.countWrites(sinkAccessLog, w), file: w, format: strings.ToLower(cfg.Format), loc: c.loc}
+	if a.format == "" {
+		a.format = AccessFormatCombined
+	}
+	return a
+}
+
+// rotator 返回访问日志文件的切割接口，供Log.Rotate使用
+func (a *accessLog) rotator() (rotator, bool) {
+	if a == nil {
+		return nil, false
+	}
+	r, ok := a.file.(rotator)
+	return r, ok
+}
+
+func newAccessRecord(r *http.Request, rw *responseWriter, start time.Time, remoteIP string) accessRecord {
+	rec := accessRecord{
+		start:     start,
+		latency:   time.Since(start),
+		remoteIP:  remoteIP,
+		method:    r.Method,
+		uri:       r.RequestURI,
+		proto:     r.Proto,
+		status:    rw.status,
+		bytes:     rw.bytes,
+		referer:   r.Referer(),
+		userAgent: r.UserAgent(),
+	}
+	if rec.uri == "" {
+		rec.uri = r.URL.RequestURI()
+	}
+	if user, _, ok := r.BasicAuth(); ok {
+		rec.user = user
+	} else if r.URL.User != nil {
+		rec.user = r.URL.User.Username()
+	}
+	rec.requestID, _ = RequestIDFrom(r.Context())
+	return rec
+}
+
+func (a *accessLog) write(rec accessRecord) error {
+	var line []byte
+	if a.format == AccessFormatJson {
+		b, err := json.Marshal(rec.jsonObject(a.loc))
+		if err != nil {
+			return err
+		}
+		line = append(b, '\n')
+	} else {
+		line = rec.appendCLF(nil, a.loc, a.format == AccessFormatCombined)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err := a.w.Write(line)
+	return err
+}
+
+// appendCLF 按common/combined格式输出一行：
+// 127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://example.com/" "Mozilla/4.08"
+func (rec accessRecord) appendCLF(b []byte, loc *time.Location, combined bool) []byte {
+	b = append(b, dash(rec.remoteIP)...)
+	b = append(b, " - "...)
+	b = append(b, dash(rec.user)...)
+	b = append(b, " ["...)
+	b = rec.start.In(loc).AppendFormat(b, "02/Jan/2006:15:04:05 -0700")
+	b = append(b, "] \""...)
+	b = append(b, rec.method...)
+	b = append(b, ' ')
+	b = appendEscaped(b, rec.uri)
+	b = append(b, ' ')
+	b = append(b, rec.proto...)
+	b = append(b, "\" "...)
+	b = strconv.AppendInt(b, int64(rec.status), 10)
+	b = append(b, ' ')
+	if rec.bytes > 0 {
+		b = strconv.AppendInt(b, rec.bytes, 10)
+	} else {
+		b = append(b, '-')
+	}
+	if combined {
+		b = append(b, " \""...)
+		b = appendEscaped(b, dash(rec.referer))
+		b = append(b, "\" \""...)
+		b = appendEscaped(b, dash(rec.userAgent))
+		b = append(b, '"')
+	}
+	return append(b, '\n')
+}
+
+func (rec accessRecord) jsonObject(loc *time.Location) map[string]interface{} {
+	obj := map[string]interface{}{
+		"time":       rec.start.In(loc).Format(time.RFC3339Nano),
+		"remote_ip":  rec.remoteIP,
+		"method":     rec.method,
+		"uri":        rec.uri,
+		"proto":      rec.proto,
+		"status":     rec.status,
+		"bytes":      rec.bytes,
+		"referer":    rec.referer,
+		"user_agent": rec.userAgent,
+		"latency":    rec.latency.Seconds(),
+	}
+	if rec.user != "" {
+		obj["user"] = rec.user
+	}
+	if rec.requestID != "" {
+		obj["request_id"] = rec.requestID
+	}
+	return obj
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// appendEscaped 转义引号、反斜杠和控制字符，避免请求内容破坏日志行的格式
+func appendEscaped(b []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c < 0x20 || c == 0x7f:
+			b = append(b, `\x`...)
+			b = append(b, "0123456789abcdef"[c>>4], "0123456789abcdef"[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
@@ -0,0 +1,292 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 告警webhook类型
+const (
+	AlertSlack    = "slack"
+	AlertDiscord  = "discord"
+	AlertDingTalk = "dingtalk"
+	AlertWebhook  = "webhook"
+)
+
+const _defaultAlertTemplate = `[{{.Level}}] {{.Time.Format "2006-01-02 15:04:05"}} {{.Message}}` +
+	`{{range $k, $v := .Fields}} {{$k}}={{$v}}{{end}}`
+
+// AlertConfig 将warn及以上级别的日志推送到Slack、Discord、钉钉或通用webhook
+type AlertConfig struct {
+	// Type 可选 "slack"、"discord"、"dingtalk"、"webhook"
+	Type string `toml:"type" yaml:"type" json:"type"`
+	URL  string `toml:"url" yaml:"url" json:"url"`
+	// Level 推送的最低级别，默认 "warn"
+	Level string `toml:"level" yaml:"level" json:"level"`
+	// Levels 只推送这些级别，如 ["error", "fatal"]，为空时推送Level及以上的所有级别，
+	// 配置多个AlertConfig即可按级别推送到不同的渠道
+	Levels []string `toml:"levels" yaml:"levels" json:"levels"`
+	// Template text/template格式的消息模板，可用 .Level .Time .Logger .Caller .Message .Fields
+	Template string `toml:"template" yaml:"template" json:"template"`
+	// RateLimit 相同消息每分钟最多推送的次数，0不限制
+	RateLimit int `toml:"rate_limit" yaml:"rate_limit" json:"rate_limit"`
+	// BatchSize 攒够多少条合并为一次推送，默认1即逐条推送
+	BatchSize int `toml:"batch_size" yaml:"batch_size" json:"batch_size"`
+	// BatchBytes 合并的消息超过多少字节时立即推送，0不限制
+	BatchBytes int `toml:"batch_bytes" yaml:"batch_bytes" json:"batch_bytes"`
+	// BatchInterval 合并推送时最长等待时间(秒)，默认5
+	BatchInterval int `toml:"batch_interval" yaml:"batch_interval" json:"batch_interval"`
+	// QueueSize 等待推送的批数，推送在后台进行，webhook响应慢时超出的批被丢弃并计入dropped指标，默认16
+	QueueSize int `toml:"queue_size" yaml:"queue_size" json:"queue_size"`
+}
+
+// alertEntry 消息模板的数据
+type alertEntry struct {
+	Level   string
+	Time    time.Time
+	Logger  string
+	Caller  string
+	Message string
+	Fields  map[string]interface{}
+}
+
+// alertSender 负责合并消息并在batcher的goroutine中推送，由同一AlertConfig派生的core共享
+type alertSender struct {
+	cfg     AlertConfig
+	client  *http.Client
+	limiter *rateLimiter
+	metrics *metrics
+	spool   *spool
+	batch   *batcher[string]
+}
+
+// depth 等待合并推送及暂存中的消息数
+func (s *alertSender) depth() int {
+	n := s.batch.len()
+	if s.spool != nil {
+		n += s.spool.len()
+	}
+	return n
+}
+
+func (s *alertSender) send(batch []string) {
+	if len(batch) == 0 {
+		return
+	}
+	text := strings.Join(batch, "\n")
+
+	var payload interface{}
+	switch s.cfg.Type {
+	case AlertDiscord:
+		payload = map[string]interface{}{"content": text}
+	case AlertDingTalk:
+		payload = map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]string{"content": text},
+		}
+	default:
+		payload = map[string]interface{}{"text": text}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.spool != nil && s.spool.pending() {
+		s.spool.push(req)
+	} else if retry, _ := s.do(req); retry && s.spool != nil {
+		s.spool.push(req)
+	}
+}
+
+func (s *alertSender) do(req *http.Request) (bool, error) {
+	start := time.Now()
+	retry, err := deliver(s.client, req)
+	s.metrics.observe(sinkAlert, time.Since(start))
+	if err != nil {
+		s.metrics.writeError(sinkAlert, err)
+		handleError(fmt.Errorf("logger: send %s alert failed: %v", s.cfg.Type, err))
+	} else {
+		s.metrics.written(sinkAlert, int(req.ContentLength))
+	}
+	return retry, err
+}
+
+// alertCore 实现zapcore.Core，将日志按模板格式化后交给alertSender推送
+type alertCore struct {
+	zapcore.LevelEnabler
+	sender *alertSender
+	tmpl   *template.Template
+	fields map[string]interface{}
+}
+
+func newAlertCore(cfg AlertConfig) (*alertCore, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("logger: alert url is empty")
+	}
+	switch cfg.Type {
+	case AlertSlack, AlertDiscord, AlertDingTalk, AlertWebhook:
+	default:
+		return nil, fmt.Errorf("logger: unknown alert type %q", cfg.Type)
+	}
+
+	text := cfg.Template
+	if text == "" {
+		text = _defaultAlertTemplate
+	}
+	tmpl, err := template.New(cfg.Type).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	enabler, err := alertLevel(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sender := &alertSender{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+	sender.batch = newBatcher(sinkAlert, BatchConfig{
+		MaxEntries: cfg.BatchSize,
+		MaxBytes:   cfg.BatchBytes,
+		MaxAge:     cfg.BatchInterval,
+		QueueSize:  cfg.QueueSize,
+	}, sender.send)
+	if cfg.RateLimit > 0 {
+		sender.limiter = newRateLimiter(cfg.RateLimit, time.Minute)
+	}
+	return &alertCore{
+		LevelEnabler: enabler,
+		sender:       sender,
+		tmpl:         tmpl,
+		fields:       make(map[string]interface{}),
+	}, nil
+}
+
+func alertLevel(cfg AlertConfig) (zapcore.LevelEnabler, error) {
+	if len(cfg.Levels) > 0 {
+		levels := make(map[zapcore.Level]bool, len(cfg.Levels))
+		for _, name := range cfg.Levels {
+			var lvl zapcore.Level
+			if err := unmarshalLevel(&lvl, name); err != nil {
+				return nil, err
+			}
+			levels[lvl] = true
+		}
+		return levelSet(levels), nil
+	}
+	min := zapcore.WarnLevel
+	if cfg.Level != "" {
+		if err := unmarshalLevel(&min, cfg.Level); err != nil {
+			return nil, err
+		}
+	}
+	return min, nil
+}
+
+// levelSet 只允许集合中的级别
+type levelSet map[zapcore.Level]bool
+
+func (s levelSet) Enabled(lvl zapcore.Level) bool {
+	return s[lvl]
+}
+
+func (c *alertCore) With(fs []zapcore.Field) zapcore.Core {
+	return &alertCore{
+		LevelEnabler: c.LevelEnabler,
+		sender:       c.sender,
+		tmpl:         c.tmpl,
+		fields:       mergeFields(c.fields, fs),
+	}
+}
+
+func (c *alertCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *alertCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	suppressed := 0
+	if c.sender.limiter != nil {
+		ok, n := c.sender.limiter.allow(ent.Message, ent.Time)
+		if !ok {
+			c.sender.metrics.drop(sinkAlert)
+			return nil
+		}
+		suppressed = n
+	}
+
+	var buf bytes.Buffer
+	err := c.tmpl.Execute(&buf, alertEntry{
+		Level:   ent.Level.CapitalString(),
+		Time:    ent.Time,
+		Logger:  ent.LoggerName,
+		Caller:  ent.Caller.TrimmedPath(),
+		Message: ent.Message,
+		Fields:  mergeFields(c.fields, fs),
+	})
+	if err != nil {
+		return err
+	}
+	if suppressed > 0 {
+		fmt.Fprintf(&buf, " (%d similar alerts suppressed)", suppressed)
+	}
+	c.sender.batch.add(buf.String(), buf.Len())
+	// fatal、panic之后进程可能立即退出，等待推送完成
+	if ent.Level > zapcore.ErrorLevel {
+		c.sender.batch.wait(_batchFlushTimeout)
+	}
+	return nil
+}
+
+func (c *alertCore) Sync() error {
+	c.sender.batch.wait(_batchFlushTimeout)
+	return nil
+}
+
+// alertCores 根据Alerts配置生成告警core
+func (c *LogOptions) alertCores() []zapcore.Core {
+	var cores []zapcore.Core
+	for i, cfg := range c.Alerts {
+		core, err := newAlertCore(cfg)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		core.sender.metrics = c.metrics
+		core.sender.batch.metrics = c.metrics
+		url := cfg.URL
+		core.sender.spool = c.newSpool(fmt.Sprintf("alert-%d", i), core.sender.do, func() (string, http.Header) { return url, nil })
+		c.metrics.queue(sinkAlert, core.sender.depth)
+		c.closeOnReload(core.sender.batch.close)
+		cores = append(cores, core)
+	}
+	return cores
+}
+
+// mergeFields 复制m并加入fs，返回新的map
+func mergeFields(m map[string]interface{}, fs []zapcore.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for k, v := range m {
+		enc.Fields[k] = v
+	}
+	for _, f := range fs {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
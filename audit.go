@@ -0,0 +1,296 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// _auditGenesis 第一条审计记录的prev_hash
+var _auditGenesis = strings.Repeat("0", 64)
+
+// AuditConfig 审计日志配置，审计日志只追加写入，不参与切割和清理
+type AuditConfig struct {
+	Filename string `toml:"filename" yaml:"filename" json:"filename"`
+	// HMACKey 配置后每条记录增加hmac，没有密钥无法伪造整条链
+	HMACKey string `toml:"hmac_key" yaml:"hmac_key" json:"hmac_key"`
+}
+
+// auditRecord 审计日志中的一行，hash为除hash、hmac外其余内容json编码后的sha256，
+// 其中包含上一条记录的hash，修改、删除、插入任意一条记录都会使之后的校验失败
+type auditRecord struct {
+	Seq      uint64                 `json:"seq"`
+	Time     string                 `json:"time"`
+	Event    string                 `json:"event"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+	PrevHash string                 `json:"prev_hash"`
+	Hash     string                 `json:"hash,omitempty"`
+	HMAC     string                 `json:"hmac,omitempty"`
+}
+
+// sign 计算记录的hash和hmac
+func (r *auditRecord) sign(key []byte) (hash, mac string, err error) {
+	body := *r
+	body.Hash, body.HMAC = "", ""
+	b, err := json.Marshal(body)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(b)
+	hash = hex.EncodeToString(sum[:])
+	if len(key) > 0 {
+		h := hmac.New(sha256.New, key)
+		h.Write(b)
+		mac = hex.EncodeToString(h.Sum(nil))
+	}
+	return hash, mac, nil
+}
+
+// normalize 按VerifyAuditLog解码的方式重新解码记录，签名的内容与校验时重新编码的内容一致，
+// 如无效的UTF-8在编码时被替换为U+FFFD，数字保留编码后的写法
+func (r auditRecord) normalize() (auditRecord, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return r, err
+	}
+	var out auditRecord
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&out); err != nil {
+		return r, err
+	}
+	return out, nil
+}
+
+type auditLog struct {
+	mu   sync.Mutex
+	f    *os.File
+	key  []byte
+	loc  *time.Location
+	seq  uint64
+	prev string
+}
+
+func newAuditLog(cfg AuditConfig, mode os.FileMode, loc *time.Location) (*auditLog, error) {
+	f, err := os.OpenFile(cfg.Filename, os.O_CREATE|os.O_APPEND|os.O_RDWR, mode)
+	if err != nil {
+		return nil, fmt.Errorf("logger: open audit log %s: %v", cfg.Filename, err)
+	}
+	a := &auditLog{f: f, key: []byte(cfg.HMACKey), loc: loc, prev: _auditGenesis}
+
+	// 从最后一条记录继续链
+	line, err := lastLine(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if len(line) > 0 {
+		var last auditRecord
+		if err := json.Unmarshal(line, &last); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("logger: audit log %s is corrupted: %v", cfg.Filename, err)
+		}
+		a.seq, a.prev = last.Seq, last.Hash
+	}
+	return a, nil
+}
+
+// lastLine 从文件末尾向前读取最后一行
+func lastLine(f *os.File) ([]byte, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	const chunk = 4096
+	end := info.Size()
+	var tail []byte
+	for end > 0 {
+		start := end - chunk
+		if start < 0 {
+			start = 0
+		}
+		buf := make([]byte, end-start)
+		if _, err := f.ReadAt(buf, start); err != nil && err != io.EOF {
+			return nil, err
+		}
+		tail = append(buf, tail...)
+		trimmed := bytes.TrimRight(tail, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+		end = start
+	}
+	return bytes.TrimRight(tail, "\n"), nil
+}
+
+func (a *auditLog) write(event string, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	rec := auditRecord{
+		Seq:      a.seq + 1,
+		Time:     time.Now().In(a.loc).Format(time.RFC3339Nano),
+		Event:    event,
+		PrevHash: a.prev,
+	}
+	if len(enc.Fields) > 0 {
+		rec.Fields = enc.Fields
+	}
+	rec, err := rec.normalize()
+	if err != nil {
+		return err
+	}
+	hash, mac, err := rec.sign(a.key)
+	if err != nil {
+		return err
+	}
+	rec.Hash, rec.HMAC = hash, mac
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := a.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	// 审计记录必须落盘后才返回
+	if err := a.f.Sync(); err != nil {
+		return err
+	}
+	a.seq, a.prev = rec.Seq, rec.Hash
+	return nil
+}
+
+// Audit 写入一条审计记录，未配置Audit时返回错误
+func (log *Log) Audit(event string, fields ...zap.Field) error {
+	if log.audit == nil {
+		return errors.New("logger: audit log is not configured")
+	}
+	return log.audit.write(event, fields)
+}
+
+// 审计事件的结果
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+)
+
+// AuditEvent 结构化的审计事件，通过Log.AuditEvent写入审计日志，
+// 字段名固定为actor、action、resource、outcome、metadata，便于合规检查统一解析
+type AuditEvent struct {
+	// Actor 执行操作的用户或服务，必填
+	Actor string
+	// Action 操作名称，如 "user.delete"，必填，同时作为审计记录的event
+	Action string
+	// Resource 操作对象，必填
+	Resource string
+	// Outcome 操作结果，必须是OutcomeSuccess、OutcomeFailure、OutcomeDenied之一
+	Outcome string
+	// Metadata 其他信息，可选
+	Metadata map[string]interface{}
+}
+
+// Validate 检查必填字段和Outcome的取值
+func (e AuditEvent) Validate() error {
+	var missing []string
+	for _, f := range []struct{ name, value string }{
+		{"actor", e.Actor},
+		{"action", e.Action},
+		{"resource", e.Resource},
+		{"outcome", e.Outcome},
+	} {
+		if strings.TrimSpace(f.value) == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("logger: audit event missing required fields: %s", strings.Join(missing, ", "))
+	}
+	switch e.Outcome {
+	case OutcomeSuccess, OutcomeFailure, OutcomeDenied:
+	default:
+		return fmt.Errorf("logger: audit event outcome must be %q, %q or %q, got %q", OutcomeSuccess, OutcomeFailure, OutcomeDenied, e.Outcome)
+	}
+	return nil
+}
+
+// AuditEvent 校验后写入一条审计事件，缺少必填字段时不写入并返回错误
+func (log *Log) AuditEvent(e AuditEvent) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	fields := []zap.Field{
+		zap.String("actor", e.Actor),
+		zap.String("action", e.Action),
+		zap.String("resource", e.Resource),
+		zap.String("outcome", e.Outcome),
+	}
+	if len(e.Metadata) > 0 {
+		fields = append(fields, zap.Any("metadata", e.Metadata))
+	}
+	return log.Audit(e.Action, fields...)
+}
+
+// VerifyAuditLog 校验审计日志的hash链，配置了HMACKey时同时校验hmac，返回第一处被篡改的位置
+func VerifyAuditLog(filename, hmacKey string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	prev, seq := _auditGenesis, uint64(0)
+	r := bufio.NewReader(f)
+	for lineno := 1; ; lineno++ {
+		line, err := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var rec auditRecord
+			dec := json.NewDecoder(bytes.NewReader(line))
+			// 保留数字的原始写法，重新编码后才能得到相同的hash
+			dec.UseNumber()
+			if err := dec.Decode(&rec); err != nil {
+				return fmt.Errorf("logger: audit log line %d: %v", lineno, err)
+			}
+			if rec.Seq != seq+1 {
+				return fmt.Errorf("logger: audit log line %d: seq %d, want %d", lineno, rec.Seq, seq+1)
+			}
+			if rec.PrevHash != prev {
+				return fmt.Errorf("logger: audit log line %d: prev_hash does not match previous record", lineno)
+			}
+			hash, mac, err := rec.sign([]byte(hmacKey))
+			if err != nil {
+				return fmt.Errorf("logger: audit log line %d: %v", lineno, err)
+			}
+			if hash != rec.Hash {
+				return fmt.Errorf("logger: audit log line %d: hash mismatch, record was modified", lineno)
+			}
+			if hmacKey != "" && !hmac.Equal([]byte(mac), []byte(rec.HMAC)) {
+				return fmt.Errorf("logger: audit log line %d: hmac mismatch", lineno)
+			}
+			prev, seq = rec.Hash, rec.Seq
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func newAuditLogger(t *testing.T, filename, key string) *Log {
+	t.Helper()
+	c := New(WithoutConsole())
+	c.Audit = AuditConfig{Filename: filename, HMACKey: key}
+	return c.InitLoggerWith(EncoderOptions{})
+}
+
+func TestAuditChain(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	log := newAuditLogger(t, filename, "secret")
+	if err := log.Audit("login", zap.String("user", "alice"), zap.Int("attempts", 3)); err != nil {
+		t.Fatal(err)
+	}
+	// 无效的UTF-8在编码时被替换，签名的内容与校验时一致
+	if err := log.Audit("upload", zap.String("name", "report\xff.pdf"), zap.Float64("size", 1.5)); err != nil {
+		t.Fatal(err)
+	}
+	err := log.AuditEvent(AuditEvent{Actor: "alice", Action: "user.delete", Resource: "user/42", Outcome: OutcomeSuccess,
+		Metadata: map[string]interface{}{"reason": "requested"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := log.AuditEvent(AuditEvent{Actor: "alice", Action: "user.delete"}); err == nil {
+		t.Fatal("AuditEvent without resource and outcome should fail")
+	}
+
+	if err := VerifyAuditLog(filename, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAuditLog(filename, "other"); err == nil || !strings.Contains(err.Error(), "hmac mismatch") {
+		t.Fatalf("verify with wrong key: %v", err)
+	}
+
+	// 重新打开后从最后一条记录继续链
+	log = newAuditLogger(t, filename, "secret")
+	if err := log.Audit("logout", zap.String("user", "alice")); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAuditLog(filename, "secret"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuditTampered(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	log := newAuditLogger(t, filename, "")
+	for _, user := range []string{"alice", "bob", "carol"} {
+		if err := log.Audit("login", zap.String("user", user)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	for name, tc := range map[string]struct {
+		data []byte
+		want string
+	}{
+		"modified": {bytes.Replace(data, []byte(`"bob"`), []byte(`"eve"`), 1), "line 2: hash mismatch"},
+		"deleted":  {append(append([]byte{}, lines[0]...), lines[2]...), "line 2: seq 3, want 2"},
+	} {
+		if err := os.WriteFile(filename, tc.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := VerifyAuditLog(filename, "")
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got %v, want %q", name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// _batchQueueSize 每个输出默认等待发送的批数
+const _batchQueueSize = 16
+
+// _batchFlushTimeout Sync及写入fatal、panic日志时等待发送完成的最长时间
+const _batchFlushTimeout = 3 * time.Second
+
+// BatchConfig 网络输出合并发送的条件，条数、大小、等待时间任一达到即发送
+type BatchConfig struct {
+	// MaxEntries 每批最多的条数，默认1即逐条发送
+	MaxEntries int `toml:"max_entries" yaml:"max_entries" json:"max_entries"`
+	// MaxBytes 每批最大的字节数，加入一条后超过时立即发送，0不限制
+	MaxBytes int `toml:"max_bytes" yaml:"max_bytes" json:"max_bytes"`
+	// MaxAge 第一条进入后最长等待的时间(秒)，默认5
+	MaxAge int `toml:"max_age" yaml:"max_age" json:"max_age"`
+	// QueueSize 等待发送的批数，发送跟不上时超出的批被丢弃并计入dropped指标，默认16
+	QueueSize int `toml:"queue_size" yaml:"queue_size" json:"queue_size"`
+}
+
+// batcher 按BatchConfig合并日志，达到条件时放入有界队列，由单独的goroutine依次交给send发送，
+// 写日志不会等待网络或磁盘。由同一输出派生的core共享
+type batcher[T any] struct {
+	sink       string
+	maxEntries int
+	maxBytes   int
+	maxAge     time.Duration
+	send       func(batch []T)
+	metrics    *metrics
+
+	mu      sync.Mutex
+	pending []T
+	size    int
+	timer   *time.Timer
+	closed  bool
+
+	queue chan batchJob[T]
+	// queued 队列中及正在发送的条数
+	queued int64
+	// enqueued、sent 放入队列和发送完成的批数，由mu保护，wait等待调用前放入队列的批发送完成
+	enqueued, sent uint64
+	// progress 每发送完一批时关闭并替换，通知wait
+	progress chan struct{}
+}
+
+// batchJob 一批等待发送的日志
+type batchJob[T any] struct {
+	items []T
+	size  int
+}
+
+func newBatcher[T any](sink string, cfg BatchConfig, send func([]T)) *batcher[T] {
+	b := &batcher[T]{
+		sink:       sink,
+		maxEntries: cfg.MaxEntries,
+		maxBytes:   cfg.MaxBytes,
+		maxAge:     time.Duration(cfg.MaxAge) * time.Second,
+		send:       send,
+		progress:   make(chan struct{}),
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = _batchQueueSize
+	}
+	b.queue = make(chan batchJob[T], queueSize)
+	if b.maxEntries <= 0 {
+		b.maxEntries = 1
+	}
+	if b.maxAge <= 0 {
+		b.maxAge = 5 * time.Second
+	}
+	go b.run()
+	return b
+}
+
+func (b *batcher[T]) run() {
+	for job := range b.queue {
+		start := time.Now()
+		b.send(job.items)
+		b.metrics.batch(b.sink, len(job.items), job.size, time.Since(start))
+		atomic.AddInt64(&b.queued, -int64(len(job.items)))
+		b.mu.Lock()
+		b.sent++
+		close(b.progress)
+		b.progress = make(chan struct{})
+		b.mu.Unlock()
+	}
+}
+
+// add 加入一条大小为size字节的日志，达到条件时放入发送队列
+func (b *batcher[T]) add(item T, size int) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		b.metrics.drop(b.sink)
+		return
+	}
+	b.pending = append(b.pending, item)
+	b.size += size
+	if len(b.pending) >= b.maxEntries || (b.maxBytes > 0 && b.size >= b.maxBytes) {
+		dropped := b.deliver(b.take())
+		b.mu.Unlock()
+		b.reportDrop(dropped)
+		return
+	}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.maxAge, b.flush)
+	}
+	b.mu.Unlock()
+}
+
+// len 等待合并及队列中等待发送的条数
+func (b *batcher[T]) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending) + int(atomic.LoadInt64(&b.queued))
+}
+
+// take 必须在持有锁时调用
+func (b *batcher[T]) take() ([]T, int) {
+	batch, size := b.pending, b.size
+	b.pending = nil
+	b.size = 0
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return batch, size
+}
+
+// flush 将等待合并的日志放入发送队列，不等待发送完成
+func (b *batcher[T]) flush() {
+	b.mu.Lock()
+	dropped := 0
+	if !b.closed {
+		dropped = b.deliver(b.take())
+	}
+	b.mu.Unlock()
+	b.reportDrop(dropped)
+}
+
+// deliver 必须在持有锁时调用，队列已满时丢弃这批日志，不阻塞日志写入，返回丢弃的条数
+func (b *batcher[T]) deliver(batch []T, size int) int {
+	if len(batch) == 0 {
+		return 0
+	}
+	atomic.AddInt64(&b.queued, int64(len(batch)))
+	select {
+	case b.queue <- batchJob[T]{items: batch, size: size}:
+		b.enqueued++
+		return 0
+	default:
+		atomic.AddInt64(&b.queued, -int64(len(batch)))
+		return len(batch)
+	}
+}
+
+// reportDrop 在释放锁之后报告丢弃的日志，错误处理函数可能再写日志
+func (b *batcher[T]) reportDrop(n int) {
+	if n == 0 {
+		return
+	}
+	for i := 0; i < n; i++ {
+		b.metrics.drop(b.sink)
+	}
+	handleError(fmt.Errorf("logger: %s queue is full, %d entries dropped", b.sink, n))
+}
+
+// wait 将等待合并的日志放入发送队列并等待此前加入队列的日志发送完成，之后写入的日志不影响等待，超时返回false
+func (b *batcher[T]) wait(timeout time.Duration) bool {
+	b.flush()
+	b.mu.Lock()
+	target := b.enqueued
+	b.mu.Unlock()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		b.mu.Lock()
+		if b.sent >= target {
+			b.mu.Unlock()
+			return true
+		}
+		progress := b.progress
+		b.mu.Unlock()
+		select {
+		case <-progress:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// close Reload后不再使用时调用，将等待合并的日志放入发送队列并等待发送完成，最多等待_batchFlushTimeout。
+// 之后加入的日志被丢弃，发送goroutine在队列发送完后退出
+func (b *batcher[T]) close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	dropped := b.deliver(b.take())
+	b.closed = true
+	close(b.queue)
+	b.mu.Unlock()
+	b.reportDrop(dropped)
+	b.wait(_batchFlushTimeout)
+}
@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// captureErrors 记录测试期间handleError收到的错误
+func captureErrors(t *testing.T) func() []error {
+	t.Helper()
+	var mu sync.Mutex
+	var errs []error
+	SetErrorHandler(func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	})
+	t.Cleanup(func() { SetErrorHandler(nil) })
+	return func() []error {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]error(nil), errs...)
+	}
+}
+
+func TestBatcherDoesNotBlock(t *testing.T) {
+	errs := captureErrors(t)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var sent []int
+	b := newBatcher("test", BatchConfig{MaxEntries: 2, QueueSize: 1}, func(batch []int) {
+		<-release
+		mu.Lock()
+		sent = append(sent, batch...)
+		mu.Unlock()
+	})
+
+	// 第一批正在发送，第二批在队列中，第三批队列已满被丢弃，写入都不等待send
+	start := time.Now()
+	for i := 1; i <= 6; i++ {
+		b.add(i, 1)
+		if i == 2 {
+			waitFor(t, func() bool { return b.len() == 2 && len(b.queue) == 0 })
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("add blocked for %v", d)
+	}
+	if got := errs(); len(got) != 1 || !strings.Contains(got[0].Error(), "test queue is full, 2 entries dropped") {
+		t.Fatalf("errors = %v", got)
+	}
+	if b.wait(50 * time.Millisecond) {
+		t.Fatal("wait should time out while send is blocked")
+	}
+
+	close(release)
+	if !b.wait(time.Second) {
+		t.Fatal("wait timed out after send was released")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 4 || sent[0] != 1 || sent[3] != 4 {
+		t.Fatalf("sent %v, want [1 2 3 4]", sent)
+	}
+}
+
+func TestBatcherFlushAndClose(t *testing.T) {
+	errs := captureErrors(t)
+	var mu sync.Mutex
+	var sent []string
+	b := newBatcher("test", BatchConfig{MaxEntries: 10, MaxAge: 60}, func(batch []string) {
+		mu.Lock()
+		sent = append(sent, batch...)
+		mu.Unlock()
+	})
+	b.add("a", 1)
+	if !b.wait(time.Second) {
+		t.Fatal("wait timed out")
+	}
+	b.add("b", 1)
+	b.close()
+	b.close()
+	// 关闭后加入的日志被丢弃
+	b.add("c", 1)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(sent, "") != "ab" {
+		t.Fatalf("sent %v, want [a b]", sent)
+	}
+	if len(errs()) != 0 {
+		t.Fatalf("errors = %v", errs())
+	}
+}
+
+func TestBatcherWaitWhileAdding(t *testing.T) {
+	var sent int64
+	b := newBatcher("test", BatchConfig{MaxEntries: 10, QueueSize: 1000}, func(batch []int) {
+		atomic.AddInt64(&sent, int64(len(batch)))
+	})
+	const writers, entries = 4, 1000
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < entries; j++ {
+				b.add(j, 1)
+			}
+		}()
+	}
+	// 写入的同时多个goroutine反复等待，等待不能影响写入，也不能在写入时失效
+	stop := make(chan struct{})
+	var waiters sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		waiters.Add(1)
+		go func() {
+			defer waiters.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					b.wait(time.Millisecond)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	waiters.Wait()
+	if !b.wait(time.Second) {
+		t.Fatal("wait timed out after writers finished")
+	}
+	if n := atomic.LoadInt64(&sent); n != writers*entries {
+		t.Fatalf("sent %d entries, want %d", n, writers*entries)
+	}
+}
+
+func TestSyncWhileWriting(t *testing.T) {
+	captureErrors(t)
+	var received int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&received, 1)
+	}))
+	defer srv.Close()
+	c := New(WithoutConsole())
+	c.Alerts = []AlertConfig{{Type: AlertWebhook, URL: srv.URL, BatchSize: 5, QueueSize: 100}}
+	log := c.InitLoggerWith(EncoderOptions{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				log.Warn("disk full", zap.Int("writer", i), zap.Int("n", j))
+				if j%10 == 0 {
+					log.L.Sync()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	log.L.Sync()
+	if atomic.LoadInt64(&received) == 0 {
+		t.Fatal("no alerts were sent")
+	}
+}
+
+// waitFor 等待cond成立，最多1秒
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,170 @@
+package benchmarks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mae-pax/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	_err     = errors.New("fail")
+	_fields  = []zap.Field{zap.Int("int", 1), zap.String("string", "value"), zap.Duration("duration", time.Second), zap.Error(_err)}
+	_message = "benchmark log message"
+)
+
+// newLogger 创建只写入临时文件的Log，configure用于开启要测量的功能
+func newLogger(b *testing.B, configure func(c *logger.LogOptions)) *logger.Log {
+	c := logger.New(logger.WithoutConsole(), logger.WithInfoFile(filepath.Join(b.TempDir(), "info.log")))
+	c.MaxSize = 1024
+	if configure != nil {
+		configure(c)
+	}
+	return c.InitLoggerWith(logger.EncoderOptions{})
+}
+
+// newZap 创建与newLogger输出相同格式到临时文件的zap.Logger
+func newZap(b *testing.B) *zap.Logger {
+	f, err := os.Create(filepath.Join(b.TempDir(), "zap.log"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { f.Close() })
+	ec := zap.NewProductionEncoderConfig()
+	ec.EncodeTime = zapcore.ISO8601TimeEncoder
+	return zap.New(zapcore.NewCore(zapcore.NewConsoleEncoder(ec), zapcore.AddSync(f), zapcore.InfoLevel))
+}
+
+func BenchmarkDisabled(b *testing.B) {
+	b.Run("zap", func(b *testing.B) {
+		l := newZap(b)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Debug(_message, _fields...)
+		}
+	})
+	b.Run("logger", func(b *testing.B) {
+		l := newLogger(b, nil)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Debug(_message, _fields...)
+		}
+	})
+	b.Run("logger.Debugf", func(b *testing.B) {
+		l := newLogger(b, nil)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Debugf("%s %d", _message, i)
+		}
+	})
+}
+
+func BenchmarkInfo(b *testing.B) {
+	b.Run("zap", func(b *testing.B) {
+		l := newZap(b)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Info(_message, _fields...)
+		}
+	})
+	b.Run("logger", func(b *testing.B) {
+		l := newLogger(b, nil)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Info(_message, _fields...)
+		}
+	})
+	b.Run("logger.Infof", func(b *testing.B) {
+		l := newLogger(b, nil)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Infof("%s %d", _message, i)
+		}
+	})
+	b.Run("logger.With", func(b *testing.B) {
+		l := newLogger(b, nil).L.With(_fields...)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Info(_message)
+		}
+	})
+}
+
+func BenchmarkInfoParallel(b *testing.B) {
+	l := newLogger(b, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info(_message, _fields...)
+		}
+	})
+}
+
+func BenchmarkEncoders(b *testing.B) {
+	for _, encoding := range []string{"console", "json", logger.EncodingECS} {
+		b.Run(encoding, func(b *testing.B) {
+			l := newLogger(b, func(c *logger.LogOptions) { c.Encoding = encoding })
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				l.Info(_message, _fields...)
+			}
+		})
+	}
+}
+
+// BenchmarkWrappers 分别开启各项处理，与BenchmarkInfo/logger比较即为该项的开销
+func BenchmarkWrappers(b *testing.B) {
+	cases := []struct {
+		name      string
+		configure func(c *logger.LogOptions)
+	}{
+		{"redact", func(c *logger.LogOptions) {
+			c.Redact = []logger.RedactRule{{Fields: []string{"string"}}}
+		}},
+		{"scrub", func(c *logger.LogOptions) { c.ScrubDefaults = true }},
+		{"truncate", func(c *logger.LogOptions) { c.MaxFieldSize = 16 }},
+		{"global_fields", func(c *logger.LogOptions) {
+			c.Fields = map[string]interface{}{"service": "bench", "env": "test"}
+		}},
+		{"field_filter", func(c *logger.LogOptions) {
+			c.FieldFilters = map[string]logger.FieldFilter{logger.OutputFile: {Deny: []string{"int"}}}
+		}},
+		{"entry_filter", func(c *logger.LogOptions) {
+			c.EntryFilters = map[string]logger.EntryFilter{logger.OutputFile: {Drop: []string{`string == "other"`}}}
+		}},
+		{"sampling", func(c *logger.LogOptions) {
+			c.Sampling = logger.SamplingConfig{Rules: []logger.SamplingRule{{Match: `msg == "other"`, Rate: 100}}}
+		}},
+		{"level_rules", func(c *logger.LogOptions) { c.LevelRules = []string{"github.com/mae-pax/*=debug"} }},
+		{"goroutine_id", func(c *logger.LogOptions) { c.GoroutineID = true }},
+		{"crash_context", func(c *logger.LogOptions) { c.CrashContextSize = 100 }},
+		{"buffered", func(c *logger.LogOptions) { c.FileBufferSize = 256 }},
+		{"runtime_stats", func(c *logger.LogOptions) {
+			c.RuntimeStats = logger.RuntimeStatsConfig{Interval: 1, Level: "info"}
+		}},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			l := newLogger(b, tc.configure)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				l.Info(_message, _fields...)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+// Package benchmarks 对比本包与直接使用zap的性能，并为各编码器和输出包装测量每条日志的开销，
+// 用于发现包装层引入的性能退化：
+//
+//	go test -run=NONE -bench=. -benchmem ./benchmarks/
+//
+// 所有用例都写入临时目录中的文件，不输出到控制台。以下为一次参考结果(go1.27, linux/amd64)，
+// 只用于比较相对开销，不同机器上的绝对值会有差异：
+//
+//	BenchmarkDisabled/zap                     7 ns/op     0 B/op   0 allocs/op
+//	BenchmarkDisabled/logger                 24 ns/op     0 B/op   0 allocs/op
+//	BenchmarkDisabled/logger.Debugf          66 ns/op    23 B/op   1 allocs/op
+//	BenchmarkInfo/zap                      1950 ns/op    56 B/op   3 allocs/op
+//	BenchmarkInfo/logger                   2970 ns/op     0 B/op   0 allocs/op
+//	BenchmarkInfo/logger.Infof             2970 ns/op    56 B/op   2 allocs/op
+//	BenchmarkInfo/logger.With              2530 ns/op     0 B/op   0 allocs/op
+//
+// 各项处理的开销见BenchmarkWrappers，其中scrub(正则替换)和goroutine_id(解析runtime.Stack)的开销最大。
+//
+// Infof等方法在级别未开启时不会格式化消息，剩下的1次分配来自调用方把参数装箱为interface{}，
+// 开启时另有格式化消息的1次分配，热路径上应优先使用Info加字段。
+package benchmarks
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// bufferedWriter 缓冲写入文件，缓冲区满、到达刷新间隔或Sync时写入下层writer，
+// zap在dpanic及以上级别的日志写入后调用Sync，fatal退出前的日志不会丢失
+type bufferedWriter struct {
+	mu   sync.Mutex
+	w    *bufio.Writer
+	ws   zapcore.WriteSyncer
+	done chan struct{}
+	once sync.Once
+}
+
+func newBufferedWriter(ws zapcore.WriteSyncer, size int, interval time.Duration) *bufferedWriter {
+	b := &bufferedWriter{w: bufio.NewWriterSize(ws, size), ws: ws, done: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				b.mu.Lock()
+				_ = b.w.Flush()
+				b.mu.Unlock()
+			case <-b.done:
+				return
+			}
+		}
+	}()
+	return b
+}
+
+// close 停止定时刷新并写出缓冲的内容，Reload替换后调用
+func (b *bufferedWriter) close() {
+	b.once.Do(func() { close(b.done) })
+	_ = b.Sync()
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// 单条日志超过剩余空间时先写出已缓冲的内容，避免一条日志被拆成两次写入
+	if len(p) > b.w.Available() && b.w.Buffered() > 0 {
+		if err := b.w.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return b.w.Write(p)
+}
+
+func (b *bufferedWriter) Sync() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.w.Flush(); err != nil {
+		return err
+	}
+	return b.ws.Sync()
+}
+
+// buffer 按FileBufferSize为文件输出加上缓冲，未配置时返回原writer
+func (c *LogOptions) buffer(w io.Writer) zapcore.WriteSyncer {
+	ws := zapcore.AddSync(w)
+	if c.FileBufferSize <= 0 {
+		return ws
+	}
+	interval := time.Duration(c.FileFlushInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Second
+	}
+	b := newBufferedWriter(ws, c.FileBufferSize*1024, interval)
+	c.closeOnReload(b.close)
+	return b
+}
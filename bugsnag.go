@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const _bugsnagEndpoint = "https://notify.bugsnag.com/"
+
+// BugsnagConfig 通过Bugsnag Error Reporting API上报错误日志
+type BugsnagConfig struct {
+	APIKey       string `toml:"api_key" yaml:"api_key" json:"api_key"`
+	ReleaseStage string `toml:"release_stage" yaml:"release_stage" json:"release_stage"`
+	// AppVersion 为空时根据编译信息自动生成，见buildRelease
+	AppVersion string `toml:"app_version" yaml:"app_version" json:"app_version"`
+	// Level 上报的最低级别，默认 "error"
+	Level string `toml:"level" yaml:"level" json:"level"`
+	// ProjectPackages 属于本项目的包前缀，对应的调用栈帧标记为inProject
+	ProjectPackages []string `toml:"project_packages" yaml:"project_packages" json:"project_packages"`
+	Endpoint        string   `toml:"endpoint" yaml:"endpoint" json:"endpoint"`
+}
+
+type bugsnagReporter struct {
+	cfg   BugsnagConfig
+	level zapcore.Level
+	host  string
+	queue *httpQueue
+}
+
+func newBugsnagReporter(c *LogOptions) (ErrorReporter, error) {
+	cfg := c.BugsnagConfig
+	if cfg.APIKey == "" {
+		return nil, nil
+	}
+	level, err := reporterLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = _bugsnagEndpoint
+	}
+	if cfg.AppVersion == "" {
+		cfg.AppVersion = buildRelease()
+	}
+	host, _ := os.Hostname()
+	auth := func() (string, http.Header) {
+		h := make(http.Header)
+		h.Set("Bugsnag-Api-Key", cfg.APIKey)
+		return cfg.Endpoint, h
+	}
+	return &bugsnagReporter{cfg: cfg, level: level, host: host, queue: newHTTPQueue(ReporterBugsnag, 100, c, auth)}, nil
+}
+
+// bugsnagSeverity 将zap的Level转换为bugsnag的severity
+func bugsnagSeverity(lvl zapcore.Level) string {
+	switch {
+	case lvl <= zapcore.InfoLevel:
+		return "info"
+	case lvl == zapcore.WarnLevel:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+func (r *bugsnagReporter) inProject(function string) bool {
+	for _, pkg := range r.cfg.ProjectPackages {
+		if strings.HasPrefix(function, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *bugsnagReporter) Enabled(lvl zapcore.Level) bool {
+	return lvl >= r.level
+}
+
+func (r *bugsnagReporter) Capture(ent zapcore.Entry, fs []zapcore.Field) {
+	fields := mergeFields(nil, fs)
+	frames := parseStack(ent.Stack)
+	stacktrace := make([]map[string]interface{}, 0, len(frames))
+	for _, f := range frames {
+		stacktrace = append(stacktrace, map[string]interface{}{
+			"file":       f.File,
+			"lineNumber": f.Line,
+			"method":     f.Function,
+			"inProject":  r.inProject(f.Function),
+		})
+	}
+	if len(stacktrace) == 0 && ent.Caller.Defined {
+		stacktrace = append(stacktrace, map[string]interface{}{
+			"file":       ent.Caller.File,
+			"lineNumber": ent.Caller.Line,
+			"inProject":  true,
+		})
+	}
+
+	event := map[string]interface{}{
+		"exceptions": []map[string]interface{}{{
+			"errorClass": ent.Level.CapitalString(),
+			"message":    ent.Message,
+			"stacktrace": stacktrace,
+		}},
+		"severity":  bugsnagSeverity(ent.Level),
+		"unhandled": ent.Level > zapcore.ErrorLevel,
+		"app": map[string]interface{}{
+			"releaseStage": r.cfg.ReleaseStage,
+			"version":      r.cfg.AppVersion,
+		},
+		"device":   map[string]interface{}{"hostname": r.host, "time": ent.Time.Format(time.RFC3339)},
+		"metaData": map[string]interface{}{"fields": fields},
+	}
+	if ent.LoggerName != "" {
+		event["context"] = ent.LoggerName
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"payloadVersion": "5",
+		"notifier": map[string]interface{}{
+			"name":    "github.com/mae-pax/logger",
+			"version": "1.0.0",
+			"url":     "https://github.com/mae-pax/logger",
+		},
+		"events": []interface{}{event},
+	})
+	if err != nil {
+		handleError(fmt.Errorf("logger: encode bugsnag event failed: %v", err))
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, r.cfg.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Bugsnag-Api-Key", r.cfg.APIKey)
+	req.Header.Set("Bugsnag-Payload-Version", "5")
+	req.Header.Set("Bugsnag-Sent-At", time.Now().UTC().Format(time.RFC3339))
+	r.queue.send(req)
+}
+
+func (r *bugsnagReporter) Flush(timeout time.Duration) bool {
+	return r.queue.wait(timeout)
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"runtime/debug"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// buildRelease 根据编译信息生成release，优先使用主模块版本，开发构建时使用vcs.revision，
+// 格式为 "module@version"，无法获取时返回空字符串
+func buildRelease() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	version := info.Main.Version
+	if version == "" || version == "(devel)" {
+		version = ""
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				version = s.Value
+				break
+			}
+		}
+	}
+	if version == "" {
+		return ""
+	}
+	if info.Main.Path == "" {
+		return version
+	}
+	return info.Main.Path + "@" + version
+}
+
+// buildFields 根据编译信息生成字段：主模块版本及vcs.revision、vcs.time、vcs.modified，无法获取的字段不输出
+func buildFields() []zapcore.Field {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	var fs []zapcore.Field
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		fs = append(fs, zap.String("version", v))
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			fs = append(fs, zap.String("vcs_revision", s.Value))
+		case "vcs.time":
+			fs = append(fs, zap.String("vcs_time", s.Value))
+		case "vcs.modified":
+			if s.Value == "true" {
+				fs = append(fs, zap.Bool("vcs_modified", true))
+			}
+		}
+	}
+	return fs
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"runtime"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithCallerSkip 返回调用位置额外跳过n层的Log，用于封装了Log的函数
+func (log *Log) WithCallerSkip(n int) *Log {
+	clone := *log
+	clone.L = log.L.WithOptions(zap.AddCallerSkip(n))
+	return &clone
+}
+
+// callerFinder 自动确定调用位置：跳过zap、本包以及配置的封装包中的帧，取第一个其他帧
+type callerFinder struct {
+	prefixes []string
+}
+
+func newCallerFinder(packages []string) *callerFinder {
+	prefixes := []string{"go.uber.org/zap", "github.com/mae-pax/logger."}
+	for _, pkg := range packages {
+		// 只匹配包本身及其子包，避免 a/b 匹配到 a/bc
+		prefixes = append(prefixes, pkg+".", pkg+"/")
+	}
+	return &callerFinder{prefixes: prefixes}
+}
+
+func (f *callerFinder) skip(function string) bool {
+	for _, prefix := range f.prefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *callerFinder) entry(ent *zapcore.Entry, fs []zapcore.Field) []zapcore.Field {
+	if !ent.Caller.Defined {
+		return fs
+	}
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !f.skip(frame.Function) {
+			ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	return fs
+}
@@ -0,0 +1,46 @@
+package logger
+
+import "time"
+
+// Clock 日志使用的时钟，测试中可以替换为固定或可控的时间
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock 总是返回同一时间的Clock
+type FixedClock time.Time
+
+// Now 返回固定的时间
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// SetClock 设置日志使用的时钟，作用于日志时间戳、按时间切割的边界和文件名、切割后的备份文件名、按MaxAge清理以及告警、sentry的限流，
+// 测试中可以用可控的时钟验证切割和限流而不需要等待
+func (c *LogOptions) SetClock(clock Clock) {
+	c.clock = clock
+}
+
+// getClock 返回设置的时钟，未设置时返回系统时钟
+func (c *LogOptions) getClock() Clock {
+	if c.clock == nil {
+		return systemClock{}
+	}
+	return c.clock
+}
+
+// locClock 返回指定时区的时间
+type locClock struct {
+	clock Clock
+	loc   *time.Location
+}
+
+func (c locClock) Now() time.Time {
+	return c.clock.Now().In(c.loc)
+}
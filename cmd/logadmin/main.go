@@ -0,0 +1,177 @@
+// logadmin 按配置文件查看和维护日志文件：
+//
+//	logadmin --conf configs/config.yaml list          列出日志文件及切割出的文件的大小和时间
+//	logadmin --conf configs/config.yaml prune         按max_backups、max_age、max_total_size立即清理
+//	logadmin --url http://127.0.0.1:8080 rotate       通过Log.RegisterRotateHandler注册的接口切割日志
+//	logadmin --pid 1234 rotate                        发送SIGHUP切割日志，需要开启rotate_on_sighup
+//	logadmin --conf configs/config.yaml verify-audit  校验审计日志的hash链，也可以在参数中指定文件
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mae-pax/logger"
+	"github.com/spf13/pflag"
+)
+
+var (
+	confPath string
+	pid      int
+	url      string
+	hmacKey  string
+)
+
+func init() {
+	pflag.StringVar(&confPath, "conf", "configs/config.yaml", "logger config, format by extension")
+	pflag.IntVar(&pid, "pid", 0, "rotate: send SIGHUP to this process")
+	pflag.StringVar(&url, "url", "", "rotate: base url of the service exposing "+logger.RotatePath)
+	pflag.StringVar(&hmacKey, "hmac-key", "", "verify-audit: hmac key, defaults to audit.hmac_key in the config")
+	pflag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: logadmin [flags] list|prune|rotate|verify-audit [file...]")
+		pflag.PrintDefaults()
+	}
+}
+
+func main() {
+	pflag.Parse()
+	if pflag.NArg() == 0 {
+		pflag.Usage()
+		os.Exit(2)
+	}
+	var err error
+	switch cmd, args := pflag.Arg(0), pflag.Args()[1:]; cmd {
+	case "list":
+		err = list()
+	case "prune":
+		err = prune()
+	case "rotate":
+		err = rotate()
+	case "verify-audit":
+		err = verifyAudit(args)
+	default:
+		pflag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func loadConfig() (*logger.LogOptions, error) {
+	b, err := os.ReadFile(confPath)
+	if err != nil {
+		return nil, err
+	}
+	return logger.NewFromBytes(b, strings.TrimPrefix(filepath.Ext(confPath), "."))
+}
+
+func list() error {
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	files, err := c.LogFiles()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tSIZE\tMODIFIED\tAGE\t")
+	var total int64
+	for _, f := range files {
+		path := f.Path
+		if f.Current {
+			path += " (current)"
+		}
+		age := time.Since(f.ModTime).Truncate(time.Second)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", path, humanSize(f.Size), f.ModTime.Format(time.RFC3339), age)
+		total += f.Size
+	}
+	fmt.Fprintf(w, "%d files\t%s\t\t\t\n", len(files), humanSize(total))
+	return w.Flush()
+}
+
+func prune() error {
+	c, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	removed, err := c.Prune()
+	for _, path := range removed {
+		fmt.Println("removed", path)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "removed %d files\n", len(removed))
+	return nil
+}
+
+func rotate() error {
+	switch {
+	case url != "":
+		resp, err := http.Post(strings.TrimSuffix(url, "/")+logger.RotatePath, "", nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("rotate: %s", resp.Status)
+		}
+		return nil
+	case pid > 0:
+		return syscall.Kill(pid, syscall.SIGHUP)
+	}
+	return fmt.Errorf("rotate: --url or --pid is required")
+}
+
+func verifyAudit(files []string) error {
+	key := hmacKey
+	c, err := loadConfig()
+	if len(files) == 0 {
+		// 没有指定文件时必须从配置中读取
+		if err != nil {
+			return err
+		}
+		if c.Audit.Filename == "" {
+			return fmt.Errorf("verify-audit: audit.filename is not configured")
+		}
+		files = []string{c.Audit.Filename}
+	}
+	if key == "" && err == nil {
+		key = c.Audit.HMACKey
+	}
+	failed := false
+	for _, file := range files {
+		if err := logger.VerifyAuditLog(file, key); err != nil {
+			fmt.Printf("%s: %v\n", file, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: ok\n", file)
+	}
+	if failed {
+		return fmt.Errorf("verify-audit: verification failed")
+	}
+	return nil
+}
+
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
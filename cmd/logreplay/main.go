@@ -0,0 +1,97 @@
+// logreplay 读取归档的json或console格式日志文件，按配置文件重新写入各个输出，
+// 用于把已有的日志导入新的日志存储：
+//
+//	logreplay --conf configs/config.yaml --since 2024-01-01T00:00:00Z logs/server.log logs/server.log.*.gz
+//
+// 文件按参数的顺序重放，.gz、.zst 文件自动解压
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mae-pax/logger"
+	"github.com/spf13/pflag"
+)
+
+var (
+	confPath   string
+	format     string
+	timeLayout string
+	since      string
+	until      string
+	rate       int
+)
+
+func init() {
+	pflag.StringVar(&confPath, "conf", "configs/config.yaml", "config of the outputs to replay into, format by extension")
+	pflag.StringVar(&format, "format", "", `format of the files, "json" or "console", detected per line when empty`)
+	pflag.StringVar(&timeLayout, "time-layout", "", "time layout the files were written with, see EncoderOptions.TimeLayout")
+	pflag.StringVar(&since, "since", "", "replay entries at or after this RFC3339 time")
+	pflag.StringVar(&until, "until", "", "replay entries at or before this RFC3339 time")
+	pflag.IntVar(&rate, "rate", 0, "max entries replayed per second, 0 for unlimited")
+}
+
+func main() {
+	pflag.Parse()
+	if pflag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: logreplay [flags] file...")
+		pflag.PrintDefaults()
+		os.Exit(2)
+	}
+	if err := run(pflag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(files []string) error {
+	b, err := os.ReadFile(confPath)
+	if err != nil {
+		return err
+	}
+	c, err := logger.NewFromBytes(b, strings.TrimPrefix(filepath.Ext(confPath), "."))
+	if err != nil {
+		return err
+	}
+	log := c.InitLoggerWith(logger.EncoderOptions{})
+	defer log.L.Sync()
+
+	opts := logger.ReplayOptions{
+		Format:     format,
+		Encoder:    c.Encoder,
+		TimeLayout: timeLayout,
+		Rate:       rate,
+	}
+	if opts.TimeLayout == "" {
+		opts.TimeLayout = c.TimeLayout
+	}
+	if opts.Since, err = parseTime(since); err != nil {
+		return fmt.Errorf("invalid --since: %v", err)
+	}
+	if opts.Until, err = parseTime(until); err != nil {
+		return fmt.Errorf("invalid --until: %v", err)
+	}
+
+	total := 0
+	for _, file := range files {
+		n, err := log.ReplayFile(file, opts)
+		total += n
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d entries\n", file, n)
+	}
+	fmt.Fprintf(os.Stderr, "replayed %d entries from %d files\n", total, len(files))
+	return nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// 切割文件的压缩算法
+const (
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+	CompressionNone = "none"
+)
+
+var _compressSuffix = map[string]string{
+	CompressionGzip: ".gz",
+	CompressionZstd: ".zst",
+}
+
+// compressedGlobs 返回glob及其各压缩格式对应的glob
+func compressedGlobs(glob string) []string {
+	return []string{
+		glob,
+		glob + _compressSuffix[CompressionGzip],
+		glob + _compressSuffix[CompressionZstd],
+	}
+}
+
+type compressJob struct {
+	path string
+	done func(compressed string)
+}
+
+// compressor 在后台压缩切割出的日志文件，最多workers个文件同时压缩。
+// 提交任务不会阻塞日志写入，没有任务时不保留空闲的goroutine
+type compressor struct {
+	algorithm string
+	level     int
+	workers   int
+
+	mu      sync.Mutex
+	jobs    []compressJob
+	running int
+}
+
+// newCompressor workers不大于0时使用GOMAXPROCS，最多4个
+func newCompressor(algorithm string, level, workers int) *compressor {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+		if workers > 4 {
+			workers = 4
+		}
+	}
+	return &compressor{
+		algorithm: algorithm,
+		level:     level,
+		workers:   workers,
+	}
+}
+
+// submit 提交压缩任务，完成后以压缩文件路径调用done，压缩失败时传入原文件路径
+func (c *compressor) submit(path string, done func(compressed string)) {
+	c.mu.Lock()
+	c.jobs = append(c.jobs, compressJob{path: path, done: done})
+	if c.running < c.workers {
+		c.running++
+		go c.run()
+	}
+	c.mu.Unlock()
+}
+
+// pending 等待及正在压缩的文件数
+func (c *compressor) pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.jobs) + c.running
+}
+
+// run 按提交顺序取出任务压缩，队列为空时退出
+func (c *compressor) run() {
+	for {
+		c.mu.Lock()
+		if len(c.jobs) == 0 {
+			c.running--
+			c.mu.Unlock()
+			return
+		}
+		job := c.jobs[0]
+		c.jobs[0] = compressJob{}
+		c.jobs = c.jobs[1:]
+		c.mu.Unlock()
+
+		compressed, err := c.compress(job.path)
+		if err != nil {
+			handleError(fmt.Errorf("logger: compress %s failed: %v", job.path, err))
+			compressed = job.path
+		}
+		if job.done != nil {
+			go job.done(compressed)
+		}
+	}
+}
+
+// compress 压缩文件并删除原文件，压缩文件保留原文件的修改时间，以便按时间清理
+func (c *compressor) compress(path string) (string, error) {
+	suffix, ok := _compressSuffix[c.algorithm]
+	if !ok {
+		return "", fmt.Errorf("unknown compression %q", c.algorithm)
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	target := path + suffix
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return "", err
+	}
+	if err := c.copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(target)
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(target)
+		return "", err
+	}
+	_ = os.Chtimes(target, info.ModTime(), info.ModTime())
+	src.Close()
+	return target, os.Remove(path)
+}
+
+// openLogFile 打开日志文件，按后缀自动解压切割后压缩的 .gz、.zst 文件
+func openLogFile(filename string) (io.ReadCloser, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.HasSuffix(filename, _compressSuffix[CompressionGzip]):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &readCloser{Reader: gz, close: func() error {
+			gz.Close()
+			return f.Close()
+		}}, nil
+	case strings.HasSuffix(filename, _compressSuffix[CompressionZstd]):
+		dec, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &readCloser{Reader: dec, close: func() error {
+			dec.Close()
+			return f.Close()
+		}}, nil
+	}
+	return f, nil
+}
+
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r *readCloser) Close() error {
+	return r.close()
+}
+
+func (c *compressor) copy(dst io.Writer, src io.Reader) error {
+	var w io.WriteCloser
+	switch c.algorithm {
+	case CompressionZstd:
+		var opts []zstd.EOption
+		if c.level > 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(c.level)))
+		}
+		enc, err := zstd.NewWriter(dst, opts...)
+		if err != nil {
+			return err
+		}
+		w = enc
+	default:
+		level := gzip.DefaultCompression
+		if c.level != 0 {
+			level = c.level
+		}
+		gz, err := gzip.NewWriterLevel(dst, level)
+		if err != nil {
+			return err
+		}
+		w = gz
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// consoleSwitch 控制台输出的开关，Reload前后共用
+type consoleSwitch struct {
+	on int32
+}
+
+func (s *consoleSwitch) set(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&s.on, v)
+}
+
+func (s *consoleSwitch) enabled() bool {
+	return atomic.LoadInt32(&s.on) == 1
+}
+
+// consoleCore 开关关闭时不输出到控制台
+type consoleCore struct {
+	zapcore.Core
+	sw *consoleSwitch
+}
+
+func (c *consoleCore) Enabled(lvl zapcore.Level) bool {
+	return c.sw.enabled() && c.Core.Enabled(lvl)
+}
+
+func (c *consoleCore) With(fs []zapcore.Field) zapcore.Core {
+	return &consoleCore{Core: c.Core.With(fs), sw: c.sw}
+}
+
+func (c *consoleCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.sw.enabled() {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// EnableConsole 在运行中打开或关闭控制台输出，如只在连接终端或调试时输出到控制台，
+// 初始状态由CloseDisplay决定，Reload时按新配置的CloseDisplay重新设置
+func (log *Log) EnableConsole(on bool) {
+	log.console.set(on)
+}
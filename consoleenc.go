@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	_consolePool = buffer.NewPool()
+	_linePool    = sync.Pool{New: func() interface{} { return &lineArrayEncoder{} }}
+)
+
+// consoleEncoder 与zap的console编码器输出相同，时间、级别、名称、调用位置直接写入缓冲区，
+// 不经过[]interface{}和fmt.Fprint，避免每条日志的内存分配
+type consoleEncoder struct {
+	// context 去掉所有key的zap console编码器，负责With添加的字段和日志字段的 {...} 部分
+	context zapcore.Encoder
+	cfg     zapcore.EncoderConfig
+}
+
+func newConsoleEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	if cfg.ConsoleSeparator == "" {
+		cfg.ConsoleSeparator = "\t"
+	}
+	if cfg.SkipLineEnding {
+		cfg.LineEnding = ""
+	} else if cfg.LineEnding == "" {
+		cfg.LineEnding = zapcore.DefaultLineEnding
+	}
+	ctxCfg := cfg
+	ctxCfg.TimeKey, ctxCfg.LevelKey, ctxCfg.NameKey, ctxCfg.CallerKey = "", "", "", ""
+	ctxCfg.FunctionKey, ctxCfg.MessageKey, ctxCfg.StacktraceKey = "", "", ""
+	ctxCfg.LineEnding, ctxCfg.SkipLineEnding = "\n", false
+	return &consoleEncoder{context: zapcore.NewConsoleEncoder(ctxCfg), cfg: cfg}
+}
+
+func (c *consoleEncoder) Clone() zapcore.Encoder {
+	return &consoleEncoder{context: c.context.Clone(), cfg: c.cfg}
+}
+
+func (c *consoleEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line := _consolePool.Get()
+
+	arr := _linePool.Get().(*lineArrayEncoder)
+	arr.buf, arr.n, arr.separator = line, 0, c.cfg.ConsoleSeparator
+	if c.cfg.TimeKey != "" && c.cfg.EncodeTime != nil {
+		c.cfg.EncodeTime(ent.Time, arr)
+	}
+	if c.cfg.LevelKey != "" && c.cfg.EncodeLevel != nil {
+		c.cfg.EncodeLevel(ent.Level, arr)
+	}
+	if ent.LoggerName != "" && c.cfg.NameKey != "" {
+		nameEncoder := c.cfg.EncodeName
+		if nameEncoder == nil {
+			nameEncoder = zapcore.FullNameEncoder
+		}
+		nameEncoder(ent.LoggerName, arr)
+	}
+	if ent.Caller.Defined {
+		if c.cfg.CallerKey != "" && c.cfg.EncodeCaller != nil {
+			c.cfg.EncodeCaller(ent.Caller, arr)
+		}
+		if c.cfg.FunctionKey != "" {
+			arr.AppendString(ent.Caller.Function)
+		}
+	}
+	arr.buf = nil
+	_linePool.Put(arr)
+
+	if c.cfg.MessageKey != "" {
+		c.addSeparator(line)
+		line.AppendString(ent.Message)
+	}
+
+	context, err := c.context.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		line.Free()
+		return nil, err
+	}
+	if b := bytes.TrimSuffix(context.Bytes(), []byte("\n")); len(b) > 0 {
+		c.addSeparator(line)
+		_, _ = line.Write(b)
+	}
+	context.Free()
+
+	if ent.Stack != "" && c.cfg.StacktraceKey != "" {
+		line.AppendByte('\n')
+		line.AppendString(ent.Stack)
+	}
+	line.AppendString(c.cfg.LineEnding)
+	return line, nil
+}
+
+func (c *consoleEncoder) addSeparator(line *buffer.Buffer) {
+	if line.Len() > 0 {
+		line.AppendString(c.cfg.ConsoleSeparator)
+	}
+}
+
+// 字段相关的方法交给context
+func (c *consoleEncoder) AddArray(key string, v zapcore.ArrayMarshaler) error {
+	return c.context.AddArray(key, v)
+}
+func (c *consoleEncoder) AddObject(key string, v zapcore.ObjectMarshaler) error {
+	return c.context.AddObject(key, v)
+}
+func (c *consoleEncoder) AddBinary(key string, v []byte)          { c.context.AddBinary(key, v) }
+func (c *consoleEncoder) AddByteString(key string, v []byte)      { c.context.AddByteString(key, v) }
+func (c *consoleEncoder) AddBool(key string, v bool)              { c.context.AddBool(key, v) }
+func (c *consoleEncoder) AddComplex128(key string, v complex128)  { c.context.AddComplex128(key, v) }
+func (c *consoleEncoder) AddComplex64(key string, v complex64)    { c.context.AddComplex64(key, v) }
+func (c *consoleEncoder) AddDuration(key string, v time.Duration) { c.context.AddDuration(key, v) }
+func (c *consoleEncoder) AddFloat64(key string, v float64)        { c.context.AddFloat64(key, v) }
+func (c *consoleEncoder) AddFloat32(key string, v float32)        { c.context.AddFloat32(key, v) }
+func (c *consoleEncoder) AddInt(key string, v int)                { c.context.AddInt(key, v) }
+func (c *consoleEncoder) AddInt64(key string, v int64)            { c.context.AddInt64(key, v) }
+func (c *consoleEncoder) AddInt32(key string, v int32)            { c.context.AddInt32(key, v) }
+func (c *consoleEncoder) AddInt16(key string, v int16)            { c.context.AddInt16(key, v) }
+func (c *consoleEncoder) AddInt8(key string, v int8)              { c.context.AddInt8(key, v) }
+func (c *consoleEncoder) AddString(key, v string)                 { c.context.AddString(key, v) }
+func (c *consoleEncoder) AddTime(key string, v time.Time)         { c.context.AddTime(key, v) }
+func (c *consoleEncoder) AddUint(key string, v uint)              { c.context.AddUint(key, v) }
+func (c *consoleEncoder) AddUint64(key string, v uint64)          { c.context.AddUint64(key, v) }
+func (c *consoleEncoder) AddUint32(key string, v uint32)          { c.context.AddUint32(key, v) }
+func (c *consoleEncoder) AddUint16(key string, v uint16)          { c.context.AddUint16(key, v) }
+func (c *consoleEncoder) AddUint8(key string, v uint8)            { c.context.AddUint8(key, v) }
+func (c *consoleEncoder) AddUintptr(key string, v uintptr)        { c.context.AddUintptr(key, v) }
+func (c *consoleEncoder) AddReflected(key string, v interface{}) error {
+	return c.context.AddReflected(key, v)
+}
+func (c *consoleEncoder) OpenNamespace(key string) { c.context.OpenNamespace(key) }
+
+// lineArrayEncoder 把时间、级别等以separator分隔直接写入缓冲区，格式与fmt.Fprint一致
+type lineArrayEncoder struct {
+	buf       *buffer.Buffer
+	n         int
+	separator string
+}
+
+func (a *lineArrayEncoder) sep() {
+	if a.n > 0 {
+		a.buf.AppendString(a.separator)
+	}
+	a.n++
+}
+
+// AppendTimeLayout zap按格式输出时间的编码器会直接调用，不生成中间字符串
+func (a *lineArrayEncoder) AppendTimeLayout(t time.Time, layout string) {
+	a.sep()
+	a.buf.AppendTime(t, layout)
+}
+
+func (a *lineArrayEncoder) AppendBool(v bool)             { a.sep(); a.buf.AppendBool(v) }
+func (a *lineArrayEncoder) AppendByteString(v []byte)     { a.sep(); _, _ = a.buf.Write(v) }
+func (a *lineArrayEncoder) AppendComplex128(v complex128) { a.sep(); fmt.Fprint(a.buf, v) }
+func (a *lineArrayEncoder) AppendComplex64(v complex64)   { a.sep(); fmt.Fprint(a.buf, v) }
+func (a *lineArrayEncoder) AppendFloat64(v float64)       { a.sep(); fmt.Fprint(a.buf, v) }
+func (a *lineArrayEncoder) AppendFloat32(v float32)       { a.sep(); fmt.Fprint(a.buf, v) }
+func (a *lineArrayEncoder) AppendInt(v int)               { a.AppendInt64(int64(v)) }
+func (a *lineArrayEncoder) AppendInt64(v int64)           { a.sep(); a.buf.AppendInt(v) }
+func (a *lineArrayEncoder) AppendInt32(v int32)           { a.AppendInt64(int64(v)) }
+func (a *lineArrayEncoder) AppendInt16(v int16)           { a.AppendInt64(int64(v)) }
+func (a *lineArrayEncoder) AppendInt8(v int8)             { a.AppendInt64(int64(v)) }
+func (a *lineArrayEncoder) AppendString(v string)         { a.sep(); a.buf.AppendString(v) }
+func (a *lineArrayEncoder) AppendUint(v uint)             { a.AppendUint64(uint64(v)) }
+func (a *lineArrayEncoder) AppendUint64(v uint64)         { a.sep(); a.buf.AppendUint(v) }
+func (a *lineArrayEncoder) AppendUint32(v uint32)         { a.AppendUint64(uint64(v)) }
+func (a *lineArrayEncoder) AppendUint16(v uint16)         { a.AppendUint64(uint64(v)) }
+func (a *lineArrayEncoder) AppendUint8(v uint8)           { a.AppendUint64(uint64(v)) }
+func (a *lineArrayEncoder) AppendUintptr(v uintptr)       { a.AppendUint64(uint64(v)) }
@@ -0,0 +1,74 @@
+// Package consullog 从Consul KV的key中读取 logger.LogOptions 创建Log，并通过阻塞查询监听key的变化调用 Log.Reload，
+// 平台可以统一调整日志级别、输出等配置而不需要重新部署
+//
+//	log, err := consullog.New(ctx, client, "config/app/log", logger.FormatYaml, logger.EncoderOptions{})
+package consullog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/mae-pax/logger"
+	"go.uber.org/zap"
+)
+
+// _retryInterval 查询失败后重试的间隔
+const _retryInterval = 5 * time.Second
+
+// New 读取key的配置创建Log，并在ctx结束前监听key的变化，format见logger.NewFromBytes，
+// 变化后的配置不合法或key被删除时记录错误日志并保留原有输出
+func New(ctx context.Context, client *api.Client, key, format string, eo logger.EncoderOptions) (*logger.Log, error) {
+	pair, meta, err := client.KV().Get(key, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil {
+		return nil, fmt.Errorf("logger: consul key %q not found", key)
+	}
+	c, err := logger.NewFromBytes(pair.Value, format)
+	if err != nil {
+		return nil, err
+	}
+	log := c.InitLoggerWith(eo)
+	go watch(ctx, client.KV(), key, format, meta.LastIndex, log)
+	return log, nil
+}
+
+func watch(ctx context.Context, kv *api.KV, key, format string, index uint64, log *logger.Log) {
+	for ctx.Err() == nil {
+		pair, meta, err := kv.Get(key, (&api.QueryOptions{WaitIndex: index}).WithContext(ctx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Error("logger: consul watch", zap.String("key", key), zap.Error(err))
+			select {
+			case <-ctx.Done():
+			case <-time.After(_retryInterval):
+			}
+			continue
+		}
+		// 阻塞查询超时或索引回退时没有变化
+		if meta.LastIndex == index {
+			continue
+		}
+		if meta.LastIndex < index {
+			index = 0
+			continue
+		}
+		index = meta.LastIndex
+		if pair == nil {
+			log.Warn("logger: consul key deleted, keep current config", zap.String("key", key))
+			continue
+		}
+		c, err := logger.NewFromBytes(pair.Value, format)
+		if err == nil {
+			err = log.Reload(c)
+		}
+		if err != nil {
+			log.Error("logger: consul reload", zap.String("key", key), zap.Uint64("index", index), zap.Error(err))
+		}
+	}
+}
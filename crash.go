@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// recentCore 把日志编码为单行文本保存在ringBuffer中，供崩溃日志附带
+type recentCore struct {
+	zapcore.LevelEnabler
+	ring    *ringBuffer
+	encoder zapcore.Encoder
+}
+
+func (c *recentCore) With(fs []zapcore.Field) zapcore.Core {
+	enc := c.encoder.Clone()
+	for i := range fs {
+		fs[i].AddTo(enc)
+	}
+	return &recentCore{LevelEnabler: c.LevelEnabler, ring: c.ring, encoder: enc}
+}
+
+func (c *recentCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *recentCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	c.ring.addEntry(c.encoder, ent, fs)
+	return nil
+}
+
+func (c *recentCore) Sync() error {
+	return nil
+}
+
+type crashOptions struct {
+	crashFile   string
+	dumpSignals []os.Signal
+}
+
+// CrashOption CapturePanics的选项
+type CrashOption func(*crashOptions)
+
+// WithCrashFile 把运行时致命错误的输出(包括其他goroutine中未恢复的panic)追加写入path，
+// 这类错误无法被recover，只能由运行时直接写入文件
+func WithCrashFile(path string) CrashOption {
+	return func(o *crashOptions) {
+		o.crashFile = path
+	}
+}
+
+// WithDumpSignals 收到sigs时输出所有goroutine的堆栈及最近的日志，进程继续运行，如 syscall.SIGUSR1
+func WithDumpSignals(sigs ...os.Signal) CrashOption {
+	return func(o *crashOptions) {
+		o.dumpSignals = append(o.dumpSignals, sigs...)
+	}
+}
+
+// CapturePanics 在main中defer调用返回的函数，main goroutine中未恢复的panic会连同堆栈及最近的日志
+// (见CrashContextSize)以Error级别写入错误日志和Sentry，等待输出完成后继续panic使进程退出：
+//
+//	func main() {
+//		log := c.InitLoggerWith(logger.EncoderOptions{})
+//		defer logger.CapturePanics(log, logger.WithCrashFile("./logs/crash.log"))()
+//		...
+//	}
+//
+// 其他goroutine需要使用RecoverAndLog，或通过WithCrashFile记录运行时的崩溃输出
+func CapturePanics(log *Log, opts ...CrashOption) func() {
+	o := &crashOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.crashFile != "" {
+		if f, err := os.OpenFile(o.crashFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, _defaultFileMode); err != nil {
+			fmt.Println(err)
+		} else {
+			if err := debug.SetCrashOutput(f, debug.CrashOptions{}); err != nil {
+				fmt.Println(err)
+			}
+			// SetCrashOutput复制了文件描述符
+			f.Close()
+		}
+	}
+	stop := func() {}
+	if len(o.dumpSignals) > 0 {
+		stop = log.dumpOnSignal(o.dumpSignals...)
+	}
+	return func() {
+		stop()
+		r := recover()
+		if r == nil {
+			return
+		}
+		fields := []zap.Field{zap.String("panic", fmt.Sprint(r)), zap.Stack("stacktrace")}
+		if err, ok := r.(error); ok {
+			fields = append(fields, zap.Error(err))
+		}
+		if recent := log.recentEntries(); len(recent) > 0 {
+			fields = append(fields, zap.Strings("recent", recent))
+		}
+		log.L.Error("process panic", fields...)
+		log.L.Sync()
+		panic(r)
+	}
+}
+
+// recentEntries 返回最近的日志，未设置CrashContextSize时为空
+func (log *Log) recentEntries() []string {
+	if log.recent == nil {
+		return nil
+	}
+	return log.recent.snapshot()
+}
+
+// dumpOnSignal 收到sigs时以Warn级别输出所有goroutine的堆栈及最近的日志，返回停止监听的函数
+func (log *Log) dumpOnSignal(sigs ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				log.L.Warn("goroutine dump",
+					zap.String("signal", sig.String()),
+					zap.Int("goroutines", runtime.NumGoroutine()),
+					zap.String("stacktrace", allStacks()),
+					zap.Strings("recent", log.recentEntries()),
+				)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+// allStacks 返回所有goroutine的堆栈
+func allStacks() string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronClock 以cron表达式最近一次触发的时间作为rotatelogs的时钟，
+// 配合分钟级的文件名格式，使日志在每次触发时切换到新文件
+type cronClock struct {
+	mu       sync.Mutex
+	clock    Clock
+	schedule cron.Schedule
+	loc      *time.Location
+	current  time.Time
+	next     time.Time
+}
+
+func newCronClock(spec string, clock Clock, loc *time.Location) (*cronClock, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, err
+	}
+	now := clock.Now().In(loc)
+	return &cronClock{
+		clock:    clock,
+		schedule: schedule,
+		loc:      loc,
+		current:  now.Truncate(time.Minute),
+		next:     schedule.Next(now),
+	}, nil
+}
+
+// Now 返回不晚于当前时间的最近一次触发时间
+func (c *cronClock) Now() time.Time {
+	now := c.clock.Now().In(c.loc)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for !c.next.IsZero() && !now.Before(c.next) {
+		c.current = c.next
+		c.next = c.schedule.Next(c.current)
+	}
+	return c.current
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// manualClock 测试中手动设置时间的时钟
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
+
+func TestCronClock(t *testing.T) {
+	at := func(day, hour, min, sec int) time.Time {
+		return time.Date(2024, 1, day, hour, min, sec, 0, time.UTC)
+	}
+	for _, tc := range []struct {
+		spec  string
+		start time.Time
+		// steps 依次设置的时间和期望的Now
+		steps [][2]time.Time
+	}{
+		{
+			spec:  "0 0 * * *",
+			start: at(1, 10, 30, 15),
+			steps: [][2]time.Time{
+				{at(1, 10, 30, 15), at(1, 10, 30, 0)},
+				{at(1, 23, 59, 59), at(1, 10, 30, 0)},
+				{at(2, 0, 0, 0), at(2, 0, 0, 0)},
+				{at(2, 5, 0, 0), at(2, 0, 0, 0)},
+				{at(4, 1, 0, 0), at(4, 0, 0, 0)},
+			},
+		},
+		{
+			spec:  "*/15 * * * *",
+			start: at(1, 10, 7, 0),
+			steps: [][2]time.Time{
+				{at(1, 10, 14, 59), at(1, 10, 7, 0)},
+				{at(1, 10, 15, 30), at(1, 10, 15, 0)},
+				{at(1, 10, 44, 0), at(1, 10, 30, 0)},
+			},
+		},
+		{
+			spec:  "30 9 * * 1-5",
+			start: at(5, 12, 0, 0), // 周五
+			steps: [][2]time.Time{
+				{at(6, 9, 30, 0), at(5, 12, 0, 0)}, // 周六不触发
+				{at(8, 9, 30, 0), at(8, 9, 30, 0)}, // 周一
+			},
+		},
+	} {
+		clock := &manualClock{now: tc.start}
+		c, err := newCronClock(tc.spec, clock, time.UTC)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.spec, err)
+		}
+		for _, step := range tc.steps {
+			clock.set(step[0])
+			if got := c.Now(); !got.Equal(step[1]) {
+				t.Errorf("%s at %s: Now = %s, want %s", tc.spec, step[0], got, step[1])
+			}
+		}
+	}
+}
+
+func TestCronClockInvalidSpec(t *testing.T) {
+	for _, spec := range []string{"", "* * *", "61 * * * *", "0 0 * * * *", "every day"} {
+		if _, err := newCronClock(spec, FixedClock(time.Now()), time.UTC); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
@@ -0,0 +1,578 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// 数据库类型
+const (
+	DatabasePostgres   = "postgres"
+	DatabaseClickHouse = "clickhouse"
+)
+
+// sinkDatabase 数据库输出的指标名称
+const sinkDatabase = "database"
+
+// _dbWriteTimeout 每次写入一批日志的超时时间
+const _dbWriteTimeout = 30 * time.Second
+
+// DatabaseConfig 将日志批量写入PostgreSQL或ClickHouse，便于没有ELK的团队在已有的数据库中集中查询日志。
+// 本包不依赖具体的驱动，需要在程序中导入，如PostgreSQL的 github.com/lib/pq(驱动名 "postgres")、
+// github.com/jackc/pgx/v5/stdlib(驱动名 "pgx")，ClickHouse的 github.com/ClickHouse/clickhouse-go/v2(驱动名 "clickhouse")
+type DatabaseConfig struct {
+	// Type 可选 "postgres"、"clickhouse"
+	Type string `toml:"type" yaml:"type" json:"type"`
+	// DSN 驱动的连接字符串
+	DSN string `toml:"dsn" yaml:"dsn" json:"dsn"`
+	// Driver database/sql的驱动名，默认与Type相同。PostgreSQL使用 "postgres"(lib/pq)时通过COPY写入，
+	// 其他驱动使用多行INSERT
+	Driver string `toml:"driver" yaml:"driver" json:"driver"`
+	// Table 表名，可以带schema或database，如 "public.logs"，默认 "logs"。
+	// 表不存在时自动创建并在time和level、time上建立索引，已存在时补齐缺少的列
+	Table string `toml:"table" yaml:"table" json:"table"`
+	// Level 写入的最低级别，默认与Level相同
+	Level string `toml:"level" yaml:"level" json:"level"`
+	// Batch 合并写入的条件，每批在一个事务中写入，默认每1000条或每1秒写入一次
+	Batch BatchConfig `toml:"batch" yaml:"batch" json:"batch"`
+	// Retries 写入失败后最多重试的次数，默认3，小于0时不重试。重试间隔从1秒开始每次翻倍，仍然失败时丢弃这批日志。
+	// Sync最多等待3秒，重试期间不等待
+	Retries int `toml:"retries" yaml:"retries" json:"retries"`
+	// QueueSize 等待写入的批数，写入跟不上时超出的批被丢弃，不阻塞写日志，默认16
+	QueueSize int `toml:"queue_size" yaml:"queue_size" json:"queue_size"`
+}
+
+var (
+	_sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// _sqlTableName 表名，可以带一级schema或database
+	_sqlTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+)
+
+func (cfg DatabaseConfig) withDefaults() DatabaseConfig {
+	if cfg.Driver == "" {
+		cfg.Driver = cfg.Type
+	}
+	if cfg.Table == "" {
+		cfg.Table = "logs"
+	}
+	if cfg.Batch.MaxEntries <= 0 {
+		cfg.Batch.MaxEntries = 1000
+	}
+	if cfg.Batch.MaxAge <= 0 {
+		cfg.Batch.MaxAge = 1
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = 3
+	} else if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 16
+	}
+	return cfg
+}
+
+// dbRow 一条日志对应的行，fields为json对象
+type dbRow struct {
+	time       time.Time
+	level      string
+	logger     string
+	caller     string
+	message    string
+	stacktrace string
+	fields     string
+}
+
+func (r dbRow) size() int {
+	return len(r.logger) + len(r.caller) + len(r.message) + len(r.stacktrace) + len(r.fields)
+}
+
+// _dbColumns 写入的列，与dbRow.values的顺序一致
+var _dbColumns = []string{"time", "level", "logger", "caller", "msg", "stacktrace", "fields"}
+
+func (r dbRow) values() []interface{} {
+	return []interface{}{r.time, r.level, r.logger, r.caller, r.message, r.stacktrace, r.fields}
+}
+
+// dbDialect 不同数据库的建表和写入方式
+type dbDialect interface {
+	// migrate 创建表和索引，补齐已存在的表中缺少的列
+	migrate(ctx context.Context, db *sql.DB, table string) error
+	insert(ctx context.Context, db *sql.DB, table string, rows []dbRow) error
+}
+
+func newDialect(cfg DatabaseConfig) (dbDialect, error) {
+	switch cfg.Type {
+	case DatabasePostgres:
+		return postgresDialect{copy: cfg.Driver == "postgres"}, nil
+	case DatabaseClickHouse:
+		return clickhouseDialect{}, nil
+	}
+	return nil, fmt.Errorf("logger: unknown database type %q", cfg.Type)
+}
+
+// indexName 由表名生成索引名，去掉schema中的点
+func indexName(table, suffix string) string {
+	return strings.ReplaceAll(table, ".", "_") + "_" + suffix
+}
+
+// addColumns 为旧版本创建的表补齐缺少的列，types与_dbColumns对应
+func addColumns(ctx context.Context, db *sql.DB, table string, types []string) error {
+	for i, col := range _dbColumns {
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, col, types[i])
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postgresDialect 字段写入jsonb列，可以用 fields->>'key' 查询并建立GIN索引
+type postgresDialect struct {
+	copy bool
+}
+
+var _postgresTypes = []string{
+	"TIMESTAMPTZ NOT NULL DEFAULT now()",
+	"TEXT NOT NULL DEFAULT ''",
+	"TEXT NOT NULL DEFAULT ''",
+	"TEXT NOT NULL DEFAULT ''",
+	"TEXT NOT NULL DEFAULT ''",
+	"TEXT NOT NULL DEFAULT ''",
+	"JSONB NOT NULL DEFAULT '{}'",
+}
+
+func (d postgresDialect) migrate(ctx context.Context, db *sql.DB, table string) error {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id BIGSERIAL PRIMARY KEY)", table)); err != nil {
+		return err
+	}
+	if err := addColumns(ctx, db, table, _postgresTypes); err != nil {
+		return err
+	}
+	for _, stmt := range []string{
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (time)", indexName(table, "time"), table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (level, time)", indexName(table, "level_time"), table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING GIN (fields)", indexName(table, "fields"), table),
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// _postgresMaxRows 多行INSERT每条语句的行数，参数总数不能超过65535
+const _postgresMaxRows = 1000
+
+func (d postgresDialect) insert(ctx context.Context, db *sql.DB, table string, rows []dbRow) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if d.copy {
+		err = d.copyIn(ctx, tx, table, rows)
+	} else {
+		for len(rows) > 0 && err == nil {
+			n := len(rows)
+			if n > _postgresMaxRows {
+				n = _postgresMaxRows
+			}
+			err = d.insertValues(ctx, tx, table, rows[:n])
+			rows = rows[n:]
+		}
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// copyIn 使用lib/pq在事务中prepare COPY语句的方式批量写入
+func (d postgresDialect) copyIn(ctx context.Context, tx *sql.Tx, table string, rows []dbRow) error {
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("COPY %s (%s) FROM STDIN", table, strings.Join(_dbColumns, ", ")))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, r := range rows {
+		if _, err := stmt.ExecContext(ctx, r.values()...); err != nil {
+			return err
+		}
+	}
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+func (d postgresDialect) insertValues(ctx context.Context, tx *sql.Tx, table string, rows []dbRow) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES ", table, strings.Join(_dbColumns, ", "))
+	args := make([]interface{}, 0, len(rows)*len(_dbColumns))
+	for i, r := range rows {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j := range _dbColumns {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "$%d", len(args)+j+1)
+		}
+		b.WriteByte(')')
+		args = append(args, r.values()...)
+	}
+	_, err := tx.ExecContext(ctx, b.String(), args...)
+	return err
+}
+
+// clickhouseDialect 按天分区，按level、time排序，字段以json字符串保存，可以用JSONExtract*函数查询
+type clickhouseDialect struct{}
+
+var _clickhouseTypes = []string{
+	"DateTime64(3)",
+	"LowCardinality(String)",
+	"LowCardinality(String)",
+	"String",
+	"String",
+	"String",
+	"String",
+}
+
+func (d clickhouseDialect) migrate(ctx context.Context, db *sql.DB, table string) error {
+	cols := make([]string, len(_dbColumns))
+	for i, col := range _dbColumns {
+		cols[i] = col + " " + _clickhouseTypes[i]
+	}
+	stmt := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s) ENGINE = MergeTree PARTITION BY toYYYYMMDD(time) ORDER BY (level, time)",
+		table, strings.Join(cols, ", "))
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return err
+	}
+	return addColumns(ctx, db, table, _clickhouseTypes)
+}
+
+// insert clickhouse-go在事务中把prepare的INSERT语句的多次Exec合并为一个数据块写入
+func (d clickhouseDialect) insert(ctx context.Context, db *sql.DB, table string, rows []dbRow) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s)", table, strings.Join(_dbColumns, ", ")))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, r := range rows {
+		if _, err := stmt.ExecContext(ctx, r.values()...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// dbSink 打开的数据库及写入队列，在单独的goroutine中按顺序写入，Reload时Type、Driver、DSN和Table不变则继续使用
+type dbSink struct {
+	cfg     DatabaseConfig
+	db      *sql.DB
+	dialect dbDialect
+	queue   chan dbJob
+	// queued 队列中等待写入的条数
+	queued int64
+	// retrying 写入失败正在等待重试，此时Sync不等待
+	retrying int32
+
+	// mu 保护closed，关闭queue后不能再加入
+	mu     sync.RWMutex
+	closed bool
+}
+
+// dbJob 一批等待写入的日志，retries和metrics取自加入时的配置；done不为nil时表示等待之前的日志写入完成
+type dbJob struct {
+	rows    []dbRow
+	retries int
+	metrics *metrics
+	done    chan struct{}
+}
+
+func openDatabase(cfg DatabaseConfig) (*dbSink, error) {
+	if !_sqlTableName.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("logger: invalid database table name %q", cfg.Table)
+	}
+	dialect, err := newDialect(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("logger: open %s database: %v", cfg.Type, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), _dbWriteTimeout)
+	defer cancel()
+	if err := dialect.migrate(ctx, db, cfg.Table); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("logger: migrate %s table %s: %v", cfg.Type, cfg.Table, err)
+	}
+	s := &dbSink{
+		cfg:     cfg,
+		db:      db,
+		dialect: dialect,
+		queue:   make(chan dbJob, cfg.QueueSize),
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *dbSink) run() {
+	for job := range s.queue {
+		if job.done != nil {
+			close(job.done)
+			continue
+		}
+		s.write(job)
+		atomic.AddInt64(&s.queued, -int64(len(job.rows)))
+	}
+	if err := s.db.Close(); err != nil {
+		handleError(fmt.Errorf("logger: close %s database: %v", s.cfg.Type, err))
+	}
+}
+
+// close Reload后不再使用时调用，队列中的日志写入后关闭数据库
+func (s *dbSink) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+}
+
+// write 写入一批日志，失败时按指数退避重试
+func (s *dbSink) write(job dbJob) {
+	size := 0
+	for _, r := range job.rows {
+		size += r.size()
+	}
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), _dbWriteTimeout)
+		err := s.dialect.insert(ctx, s.db, s.cfg.Table, job.rows)
+		cancel()
+		job.metrics.observe(sinkDatabase, time.Since(start))
+		if err == nil {
+			atomic.StoreInt32(&s.retrying, 0)
+			job.metrics.written(sinkDatabase, size)
+			return
+		}
+		job.metrics.writeError(sinkDatabase, err)
+		if attempt >= job.retries {
+			atomic.StoreInt32(&s.retrying, 0)
+			for range job.rows {
+				job.metrics.drop(sinkDatabase)
+			}
+			handleError(fmt.Errorf("logger: write %s table %s failed, %d entries dropped: %v", s.cfg.Type, s.cfg.Table, len(job.rows), err))
+			return
+		}
+		atomic.StoreInt32(&s.retrying, 1)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// enqueue 作为batcher的send，队列已满时丢弃这批日志
+func (s *dbSink) enqueue(rows []dbRow, retries int, m *metrics) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	atomic.AddInt64(&s.queued, int64(len(rows)))
+	if s.closed {
+		atomic.AddInt64(&s.queued, -int64(len(rows)))
+		for range rows {
+			m.drop(sinkDatabase)
+		}
+		return
+	}
+	select {
+	case s.queue <- dbJob{rows: rows, retries: retries, metrics: m}:
+	default:
+		atomic.AddInt64(&s.queued, -int64(len(rows)))
+		for range rows {
+			m.drop(sinkDatabase)
+		}
+	}
+}
+
+// wait 等待已加入队列的日志写入完成，最多等待timeout。数据库不可用、写入正在重试时不再等待，返回false
+func (s *dbSink) wait(timeout time.Duration) bool {
+	if atomic.LoadInt32(&s.retrying) != 0 {
+		return false
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	done := make(chan struct{})
+	s.mu.RLock()
+	if s.closed {
+		s.mu.RUnlock()
+		return false
+	}
+	select {
+	case s.queue <- dbJob{done: done}:
+	case <-timer.C:
+		s.mu.RUnlock()
+		return false
+	}
+	s.mu.RUnlock()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return true
+		case <-timer.C:
+			return false
+		case <-ticker.C:
+			if atomic.LoadInt32(&s.retrying) != 0 {
+				return false
+			}
+		}
+	}
+}
+
+// dbCore 实现zapcore.Core，将日志转换为dbRow交给batcher合并写入，字段编码为json
+type dbCore struct {
+	zapcore.LevelEnabler
+	enc   zapcore.Encoder
+	batch *batcher[dbRow]
+	// wait 不为nil时Sync等待异步写入完成
+	wait func(timeout time.Duration) bool
+}
+
+// newFieldsEncoder 只输出字段的json编码器，时间、时长等字段的格式与日志文件相同
+func newFieldsEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	cfg.TimeKey = ""
+	cfg.LevelKey = ""
+	cfg.NameKey = ""
+	cfg.CallerKey = ""
+	cfg.FunctionKey = ""
+	cfg.MessageKey = ""
+	cfg.StacktraceKey = ""
+	cfg.LineEnding = zapcore.DefaultLineEnding
+	return zapcore.NewJSONEncoder(cfg)
+}
+
+func (c *dbCore) With(fs []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fs {
+		f.AddTo(enc)
+	}
+	return &dbCore{LevelEnabler: c.LevelEnabler, enc: enc, batch: c.batch, wait: c.wait}
+}
+
+func (c *dbCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *dbCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(zapcore.Entry{}, fs)
+	if err != nil {
+		return err
+	}
+	row := dbRow{
+		time:       ent.Time,
+		level:      ent.Level.String(),
+		logger:     ent.LoggerName,
+		message:    ent.Message,
+		stacktrace: ent.Stack,
+		fields:     fieldsJSON(buf),
+	}
+	if ent.Caller.Defined {
+		row.caller = ent.Caller.TrimmedPath()
+	}
+	c.batch.add(row, row.size())
+	// fatal、panic之后进程可能立即退出，等待写入完成
+	if ent.Level > zapcore.ErrorLevel {
+		return c.Sync()
+	}
+	return nil
+}
+
+func (c *dbCore) Sync() error {
+	c.batch.wait(_batchFlushTimeout)
+	if c.wait != nil {
+		c.wait(_batchFlushTimeout)
+	}
+	return nil
+}
+
+func fieldsJSON(buf *buffer.Buffer) string {
+	s := string(bytes.TrimSuffix(buf.Bytes(), []byte(zapcore.DefaultLineEnding)))
+	buf.Free()
+	return s
+}
+
+// dbLevel 输出的级别，text为空时与日志级别相同
+func dbLevel(text string, level int8) (zapcore.LevelEnabler, error) {
+	if text == "" {
+		return logLevel(level), nil
+	}
+	var min zapcore.Level
+	if err := unmarshalLevel(&min, text); err != nil {
+		return nil, err
+	}
+	return min, nil
+}
+
+// databaseCores 根据Databases配置生成core，prev为Reload前使用的数据库
+func (c *LogOptions) databaseCores(level int8, encoderConfig zapcore.EncoderConfig, prev []*dbSink) ([]zapcore.Core, []*dbSink) {
+	var cores []zapcore.Core
+	var sinks []*dbSink
+	for _, cfg := range c.Databases {
+		cfg = cfg.withDefaults()
+		enabler, err := dbLevel(cfg.Level, level)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		var sink *dbSink
+		for _, s := range prev {
+			if s.cfg.Type == cfg.Type && s.cfg.Driver == cfg.Driver && s.cfg.DSN == cfg.DSN && s.cfg.Table == cfg.Table {
+				sink = s
+				break
+			}
+		}
+		if sink == nil {
+			if sink, err = openDatabase(cfg); err != nil {
+				fmt.Println(err)
+				continue
+			}
+		}
+		sinks = append(sinks, sink)
+
+		retries, m := cfg.Retries, c.metrics
+		batch := newBatcher(sinkDatabase, cfg.Batch, func(rows []dbRow) {
+			sink.enqueue(rows, retries, m)
+		})
+		batch.metrics = c.metrics
+		c.metrics.queue(sinkDatabase, func() int {
+			return batch.len() + int(atomic.LoadInt64(&sink.queued))
+		})
+		c.closeOnReload(batch.close)
+		cores = append(cores, &dbCore{LevelEnabler: enabler, enc: newFieldsEncoder(encoderConfig), batch: batch, wait: sink.wait})
+	}
+	return cores, sinks
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDB 记录写入的日志，fail为true时写入失败
+type fakeDB struct {
+	mu   sync.Mutex
+	rows [][]driver.Value
+	fail bool
+}
+
+var _fakeDB = &fakeDB{}
+
+func init() {
+	sql.Register("logger_fakedb", fakeDriver{})
+}
+
+func (db *fakeDB) setFail(fail bool) {
+	db.mu.Lock()
+	db.fail = fail
+	db.mu.Unlock()
+}
+
+func (db *fakeDB) messages() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var msgs []string
+	for _, row := range db.rows {
+		msgs = append(msgs, row[4].(string))
+	}
+	return msgs
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeConn{}, nil }
+func (fakeConn) Commit() error                             { return nil }
+func (fakeConn) Rollback() error                           { return nil }
+
+type fakeStmt struct{ query string }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !strings.HasPrefix(s.query, "INSERT") {
+		return driver.RowsAffected(0), nil
+	}
+	_fakeDB.mu.Lock()
+	defer _fakeDB.mu.Unlock()
+	if _fakeDB.fail {
+		return nil, errors.New("connection refused")
+	}
+	for i := 0; i+len(_dbColumns) <= len(args); i += len(_dbColumns) {
+		_fakeDB.rows = append(_fakeDB.rows, args[i:i+len(_dbColumns)])
+	}
+	return driver.RowsAffected(len(args) / len(_dbColumns)), nil
+}
+
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func newDatabaseLogger(t *testing.T, dsn string) *Log {
+	t.Helper()
+	c := New(WithoutConsole())
+	c.Databases = []DatabaseConfig{{Type: DatabasePostgres, Driver: "logger_fakedb", DSN: dsn,
+		Batch: BatchConfig{MaxEntries: 10}, Retries: 1}}
+	return c.InitLoggerWith(EncoderOptions{})
+}
+
+func TestDatabaseSync(t *testing.T) {
+	log := newDatabaseLogger(t, "sync")
+	log.Info("first")
+	log.Info("second")
+	log.L.Sync()
+	if got := strings.Join(_fakeDB.messages(), ","); !strings.Contains(got, "first,second") {
+		t.Fatalf("written %q, want first and second", got)
+	}
+}
+
+func TestDatabaseSyncWhileRetrying(t *testing.T) {
+	captureErrors(t)
+	_fakeDB.setFail(true)
+	// 数据库恢复后重试成功，不会在之后的测试中报告错误
+	defer _fakeDB.setFail(false)
+
+	log := newDatabaseLogger(t, "retrying")
+	log.Info("unavailable")
+	start := time.Now()
+	log.L.Sync()
+	// 写入失败后等待1秒重试，Sync不等待重试
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Fatalf("Sync took %v while the database was retrying", d)
+	}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// _dayDirLayout 日期目录的命名格式
+const (
+	_dayDirLayout  = "%Y-%m-%d"
+	_dayDirTimeFmt = "2006-01-02"
+)
+
+// dayDirPattern 返回按日期分目录的切割文件格式，如 logs/server.log -> logs/%Y-%m-%d/server.log，
+// 切割单位小于一天时在文件名后追加时间
+func dayDirPattern(filename string, unit TimeUnit) string {
+	dir, base := filepath.Split(filename)
+	pattern := filepath.Join(dir, _dayDirLayout, base)
+	switch unit {
+	case Minute, Hour:
+		pattern += unit.Format()
+	}
+	return pattern
+}
+
+// pruneDayDirs 删除root下超过maxAge天的日期目录及已经清空的日期目录，正在写入的目录不会删除，返回删除的目录
+func pruneDayDirs(root string, maxAge int, active func() string, now time.Time, loc *time.Location) []string {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	var removed []string
+	activeDir := filepath.Dir(active())
+	cutoff := now.In(loc).AddDate(0, 0, -maxAge)
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		day, err := time.ParseInLocation(_dayDirTimeFmt, e.Name(), loc)
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		if dir == activeDir {
+			continue
+		}
+		// 日期目录中最新的日志写于第二天零点之前
+		if maxAge > 0 && day.AddDate(0, 0, 1).Before(cutoff) {
+			if err := os.RemoveAll(dir); err == nil {
+				removed = append(removed, dir)
+			}
+			continue
+		}
+		if rest, err := os.ReadDir(dir); err == nil && len(rest) == 0 {
+			if err := os.Remove(dir); err == nil {
+				removed = append(removed, dir)
+			}
+		}
+	}
+	return removed
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DeterministicTime 确定性模式下未设置Clock时所有日志使用的时间
+var DeterministicTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// _deterministicMetadata 确定性模式下替换随运行环境变化的元数据
+var _deterministicMetadata = map[string]zapcore.Field{
+	MetadataHostname:    zap.String(MetadataHostname, "localhost"),
+	MetadataPid:         zap.Int(MetadataPid, 1),
+	MetadataGoVersion:   zap.String(MetadataGoVersion, "go"),
+	MetadataContainerID: zap.String(MetadataContainerID, "container"),
+}
+
+// sortFields 按字段名排序，包含Namespace时字段的顺序决定嵌套关系，保持原顺序
+func sortFields(fs []zapcore.Field) []zapcore.Field {
+	for _, f := range fs {
+		if f.Type == zapcore.NamespaceType {
+			return fs
+		}
+	}
+	if sort.SliceIsSorted(fs, func(i, j int) bool { return fs[i].Key < fs[j].Key }) {
+		return fs
+	}
+	sorted := append([]zapcore.Field(nil), fs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	return sorted
+}
+
+// addFixedGoroutineID 确定性模式下goroutine字段固定为1
+func addFixedGoroutineID(ent *zapcore.Entry, fs []zapcore.Field) []zapcore.Field {
+	return append(fs[:len(fs):len(fs)], zap.Int64("goroutine", 1))
+}
+
+// entryClock 返回日志时间戳使用的时钟：设置的Clock，确定性模式下未设置时为DeterministicTime，否则为系统时钟
+func (c *LogOptions) entryClock() Clock {
+	if c.clock == nil && c.Deterministic {
+		return FixedClock(DeterministicTime)
+	}
+	return c.getClock()
+}
+
+// clockEntry 返回使用entryClock设置日志时间的函数
+func (c *LogOptions) clockEntry() func(*zapcore.Entry, []zapcore.Field) []zapcore.Field {
+	clock := c.entryClock()
+	return func(ent *zapcore.Entry, fs []zapcore.Field) []zapcore.Field {
+		ent.Time = clock.Now()
+		return fs
+	}
+}
@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigPath RegisterConfigHandler使用的默认路径
+const ConfigPath = "/logz/config"
+
+// _secretKeys Dump时隐藏的配置，包括DSN、密码、token、密钥以及可能包含token的webhook地址
+var _secretKeys = map[string]bool{
+	"dsn":         true,
+	"password":    true,
+	"token":       true,
+	"api_key":     true,
+	"hmac_key":    true,
+	"routing_key": true,
+	"key":         true,
+	"private_key": true,
+	"url":         true,
+}
+
+const _secretMask = "******"
+
+// resolved 返回创建Log时实际生效的配置副本，补全默认的压缩算法和时区
+func (c *LogOptions) resolved() *LogOptions {
+	r := *c
+	r.outputs, r.prevOutputs = nil, nil
+	r.Compression = c.compression()
+	if r.TimeZone == "" && c.loc != nil {
+		r.TimeZone = c.loc.String()
+	}
+	return &r
+}
+
+// Config 返回当前生效的配置，包括默认值、环境变量及Set*的设置，Reload后返回新的配置，返回值不应修改
+func (log *Log) Config() *LogOptions {
+	if log.reload == nil {
+		return nil
+	}
+	return log.reload.load().config
+}
+
+// Dump 按format(FormatToml、FormatYaml或FormatJson)输出配置，DSN、密码、token等敏感配置以 "******" 代替
+func (c *LogOptions) Dump(w io.Writer, format string) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return err
+	}
+	maskSecrets(m)
+	switch strings.ToLower(format) {
+	case FormatToml:
+		return toml.NewEncoder(w).Encode(m)
+	case FormatYaml, "yml":
+		b, err = yaml.Marshal(m)
+	case FormatJson:
+		b, err = json.MarshalIndent(m, "", "  ")
+		b = append(b, '\n')
+	default:
+		return fmt.Errorf("logger: unknown config format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// maskSecrets 隐藏敏感配置，去掉toml无法表示的null，并把json数字还原为整数或浮点数
+func maskSecrets(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			switch {
+			case e == nil:
+				delete(v, k)
+			case _secretKeys[strings.ToLower(k)]:
+				if s, ok := e.(string); !ok || s != "" {
+					v[k] = _secretMask
+				}
+			default:
+				v[k] = maskSecrets(e)
+			}
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = maskSecrets(e)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
+
+// ConfigHandler 输出当前生效的配置，默认为json，可以通过 ?format=yaml 或 ?format=toml 指定格式
+func (log *Log) ConfigHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = FormatJson
+		}
+		c := log.Config()
+		if c == nil {
+			http.Error(w, "logger: config unavailable", http.StatusNotFound)
+			return
+		}
+		var buf bytes.Buffer
+		if err := c.Dump(&buf, format); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = buf.WriteTo(w)
+	})
+}
+
+// RegisterConfigHandler 在mux的 /logz/config 上注册ConfigHandler，配置中的敏感信息已隐藏，但仍应只对内部开放
+func (log *Log) RegisterConfigHandler(mux *http.ServeMux) {
+	mux.Handle(ConfigPath, log.ConfigHandler())
+}
@@ -0,0 +1,129 @@
+// Package echolog 提供使用 logger.Log 的 Echo 请求日志和panic恢复中间件
+package echolog
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mae-pax/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type options struct {
+	header string
+	levels map[string]zapcore.Level
+	skip   func(c echo.Context) bool
+}
+
+// Option Middleware的选项
+type Option func(*options)
+
+// WithRequestIDHeader 设置读取和返回请求ID的header，默认 X-Request-ID
+func WithRequestIDHeader(header string) Option {
+	return func(o *options) {
+		o.header = header
+	}
+}
+
+// WithRouteLevel 设置路由的日志级别，route为注册路由时的路径，如 "/users/:id"，
+// 低于该级别的访问日志不输出，5xx不受影响
+func WithRouteLevel(route string, level zapcore.Level) Option {
+	return func(o *options) {
+		o.levels[route] = level
+	}
+}
+
+// WithSkip 设置不输出访问日志的请求
+func WithSkip(skip func(c echo.Context) bool) Option {
+	return func(o *options) {
+		o.skip = skip
+	}
+}
+
+// Middleware 每个请求输出一条访问日志，并生成或透传请求ID，请求ID同时保存在请求的context中(见logger.RequestIDFrom)，
+// 5xx使用Error级别，4xx使用Warn级别，其他使用Info级别
+func Middleware(log *logger.Log, opts ...Option) echo.MiddlewareFunc {
+	o := &options{header: logger.RequestIDHeader, levels: make(map[string]zapcore.Level)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if o.skip != nil && o.skip(c) {
+				return next(c)
+			}
+			start := time.Now()
+			req, res := c.Request(), c.Response()
+			id := req.Header.Get(o.header)
+			if id == "" {
+				id = logger.NewRequestID()
+				req.Header.Set(o.header, id)
+			}
+			res.Header().Set(o.header, id)
+			req = req.WithContext(logger.WithRequestID(req.Context(), id))
+			c.SetRequest(req)
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			level := statusLevel(res.Status)
+			if min, ok := o.levels[c.Path()]; ok && level < zapcore.ErrorLevel && level < min {
+				return nil
+			}
+			ce := log.Ctx(req.Context()).L.Check(level, "http request")
+			if ce == nil {
+				return nil
+			}
+			fields := []zap.Field{
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path),
+				zap.String("route", c.Path()),
+				zap.Int("status", res.Status),
+				zap.Duration("latency", time.Since(start)),
+				zap.Int64("bytes", res.Size),
+				zap.String("remote_ip", c.RealIP()),
+			}
+			if err != nil {
+				fields = append(fields, zap.Error(err))
+			}
+			ce.Write(fields...)
+			return nil
+		}
+	}
+}
+
+// Recover 恢复handler中的panic，输出带堆栈的Error日志后返回500
+func Recover(log *logger.Log) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					if r == http.ErrAbortHandler {
+						panic(r)
+					}
+					log.Ctx(c.Request().Context()).L.Error("panic recovered",
+						zap.String("panic", fmt.Sprint(r)),
+						zap.Stack("stacktrace"),
+					)
+					err = echo.NewHTTPError(http.StatusInternalServerError)
+				}
+			}()
+			return next(c)
+		}
+	}
+}
+
+func statusLevel(status int) zapcore.Level {
+	switch {
+	case status >= 500:
+		return zapcore.ErrorLevel
+	case status >= 400:
+		return zapcore.WarnLevel
+	}
+	return zapcore.InfoLevel
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// EncodingECS Elastic Common Schema格式，基于json编码
+const EncodingECS = "ecs"
+
+// ECSVersion 输出的ecs.version
+const ECSVersion = "1.6.0"
+
+// _ecsFields 本包及常见字段对应的ECS字段名，ECS字段名中的 "." 由Elasticsearch展开为嵌套对象
+var _ecsFields = map[string]string{
+	"error":              "error.message",
+	"error_stacktrace":   "error.stack_trace",
+	"trace_id":           "trace.id",
+	"span_id":            "span.id",
+	"transaction_id":     "transaction.id",
+	"request_id":         "http.request.id",
+	"method":             "http.request.method",
+	"status":             "http.response.status_code",
+	"bytes":              "http.response.body.bytes",
+	"path":               "url.path",
+	"remote_ip":          "client.ip",
+	"latency":            "event.duration",
+	"service":            "service.name",
+	"version":            "service.version",
+	"goroutine":          "process.thread.id",
+	MetadataHostname:     "host.name",
+	MetadataPid:          "process.pid",
+	MetadataContainerID:  "container.id",
+	MetadataPodName:      "kubernetes.pod.name",
+	MetadataPodNamespace: "kubernetes.namespace",
+	MetadataNodeName:     "kubernetes.node.name",
+}
+
+// newECSEncoder 使用ECS的字段名创建json编码器，时间使用UTC的ISO8601格式
+func newECSEncoder(ec zapcore.EncoderConfig) zapcore.Encoder {
+	ec.TimeKey = "@timestamp"
+	ec.LevelKey = "log.level"
+	ec.MessageKey = "message"
+	ec.NameKey = "log.logger"
+	ec.CallerKey = "log.origin.file.name"
+	ec.StacktraceKey = "error.stack_trace"
+	ec.EncodeLevel = levelEncoder(zapcore.LowercaseLevelEncoder, false)
+	ec.EncodeCaller = zapcore.ShortCallerEncoder
+	ec.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+		enc.AppendString(t.UTC().Format("2006-01-02T15:04:05.000Z"))
+	}
+	return zapcore.NewJSONEncoder(ec)
+}
+
+// ecsFields 把字段名转换为ECS字段名，event.duration按ECS要求使用纳秒
+func ecsFields(fs []zapcore.Field) []zapcore.Field {
+	var renamed []zapcore.Field
+	for i, f := range fs {
+		name, ok := _ecsFields[f.Key]
+		if !ok {
+			if renamed != nil {
+				renamed = append(renamed, f)
+			}
+			continue
+		}
+		if renamed == nil {
+			renamed = append(make([]zapcore.Field, 0, len(fs)), fs[:i]...)
+		}
+		if f.Type == zapcore.DurationType {
+			renamed = append(renamed, zap.Int64(name, f.Integer))
+			continue
+		}
+		f.Key = name
+		renamed = append(renamed, f)
+	}
+	if renamed == nil {
+		return fs
+	}
+	return renamed
+}
+
+// encodedCore 创建写入ws的core，ECS编码时转换字段名并加上ecs.version
+func (c *LogOptions) encodedCore(enc zapcore.Encoder, ws zapcore.WriteSyncer, enab zapcore.LevelEnabler) zapcore.Core {
+	core := zapcore.NewCore(enc, ws, enab)
+	if c.Encoding != EncodingECS {
+		return core
+	}
+	core = core.With([]zapcore.Field{zap.String("ecs.version", ECSVersion)})
+	return newTransformCore(core, nil, ecsFields)
+}
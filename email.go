@@ -0,0 +1,338 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// EmailConfig 通过SMTP将fatal、panic级别的日志发送到邮箱，邮件在后台发送，
+// 写fatal日志及Sync时最多等待3秒
+type EmailConfig struct {
+	Host     string   `toml:"host" yaml:"host" json:"host"`
+	Port     int      `toml:"port" yaml:"port" json:"port"`
+	Username string   `toml:"username" yaml:"username" json:"username"`
+	Password string   `toml:"password" yaml:"password" json:"password"`
+	From     string   `toml:"from" yaml:"from" json:"from"`
+	To       []string `toml:"to" yaml:"to" json:"to"`
+	// Subject 邮件标题前缀，默认 "[logger]"
+	Subject string `toml:"subject" yaml:"subject" json:"subject"`
+	// Level 发送邮件的最低级别，默认 "dpanic"
+	Level string `toml:"level" yaml:"level" json:"level"`
+	// ContextLevel、ContextSize 邮件中附带最近ContextSize条不低于ContextLevel的日志，默认 "info"、20
+	ContextLevel string `toml:"context_level" yaml:"context_level" json:"context_level"`
+	ContextSize  int    `toml:"context_size" yaml:"context_size" json:"context_size"`
+	// MinInterval 两封邮件之间的最小间隔(秒)，期间的日志合并为一封摘要邮件，默认60
+	MinInterval int `toml:"min_interval" yaml:"min_interval" json:"min_interval"`
+}
+
+// _emailTimeout 连接SMTP服务器及发送一封邮件的最长时间
+const _emailTimeout = 30 * time.Second
+
+// emailSender 负责限流和合并，邮件由batcher的goroutine发送，写日志不等待SMTP服务器。
+// 由同一EmailConfig派生的core共享
+type emailSender struct {
+	cfg      EmailConfig
+	interval time.Duration
+	timeout  time.Duration
+	context  *ringBuffer
+	metrics  *metrics
+	encoder  zapcore.Encoder
+	// batch 每条为一封邮件的内容
+	batch *batcher[[]string]
+
+	mu       sync.Mutex
+	lastSent time.Time
+	digest   []string
+	timer    *time.Timer
+	closed   bool
+}
+
+func (s *emailSender) add(report string, now time.Time, immediate bool) {
+	s.mu.Lock()
+	s.digest = append(s.digest, report)
+	if !immediate && now.Sub(s.lastSent) < s.interval {
+		if s.timer == nil && !s.closed {
+			s.timer = time.AfterFunc(s.interval-now.Sub(s.lastSent), s.flush)
+		}
+		s.mu.Unlock()
+		return
+	}
+	reports := s.take(now)
+	s.mu.Unlock()
+	s.enqueue(reports)
+}
+
+// enqueue 将一封邮件放入发送队列
+func (s *emailSender) enqueue(reports []string) {
+	if len(reports) == 0 {
+		return
+	}
+	size := 0
+	for _, r := range reports {
+		size += len(r)
+	}
+	s.batch.add(reports, size)
+}
+
+// take 必须在持有锁时调用
+func (s *emailSender) take(now time.Time) []string {
+	reports := s.digest
+	s.digest = nil
+	s.lastSent = now
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	return reports
+}
+
+func (s *emailSender) flush() {
+	s.mu.Lock()
+	reports := s.take(time.Now())
+	s.mu.Unlock()
+	s.enqueue(reports)
+}
+
+// close Reload后不再使用时调用，发送合并中的邮件，最多等待_batchFlushTimeout
+func (s *emailSender) close() {
+	s.mu.Lock()
+	reports := s.take(time.Now())
+	s.closed = true
+	s.mu.Unlock()
+	s.enqueue(reports)
+	s.batch.close()
+}
+
+// deliver 在batcher的goroutine中依次发送邮件
+func (s *emailSender) deliver(mails [][]string) {
+	for _, reports := range mails {
+		s.send(reports)
+	}
+}
+
+func (s *emailSender) send(reports []string) {
+	subject := s.cfg.Subject
+	if subject == "" {
+		subject = "[logger]"
+	}
+	if len(reports) > 1 {
+		subject = fmt.Sprintf("%s %d errors", subject, len(reports))
+	} else {
+		subject = subject + " " + firstLine(reports[0])
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.Join(reports, "\n\n----------------------------------------\n\n"))
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	start := time.Now()
+	err := sendMail(addr, s.cfg.Host, auth, s.cfg.From, s.cfg.To, msg.Bytes(), s.timeout)
+	s.metrics.observe(sinkEmail, time.Since(start))
+	if err != nil {
+		s.metrics.writeError(sinkEmail, err)
+		handleError(fmt.Errorf("logger: send alert email failed: %v", err))
+		return
+	}
+	s.metrics.written(sinkEmail, msg.Len())
+}
+
+// sendMail 与smtp.SendMail相同，但连接和整个会话最多使用timeout，SMTP服务器无响应时不会一直等待
+func sendMail(addr, host string, a smtp.Auth, from string, to []string, msg []byte, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if a != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(a); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// emailCore 实现zapcore.Core，不低于ContextLevel的日志记录到上下文中，不低于Level的日志发送邮件
+type emailCore struct {
+	zapcore.LevelEnabler
+	level  zapcore.Level
+	sender *emailSender
+	fields []zapcore.Field
+}
+
+func newEmailCore(cfg EmailConfig) (*emailCore, error) {
+	if cfg.Host == "" || len(cfg.To) == 0 {
+		return nil, fmt.Errorf("logger: email host and recipients are required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 25
+	}
+	level := zapcore.DPanicLevel
+	if cfg.Level != "" {
+		if err := unmarshalLevel(&level, cfg.Level); err != nil {
+			return nil, err
+		}
+	}
+	contextLevel := zapcore.InfoLevel
+	if cfg.ContextLevel != "" {
+		if err := unmarshalLevel(&contextLevel, cfg.ContextLevel); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ContextSize <= 0 {
+		cfg.ContextSize = 20
+	}
+	interval := time.Duration(cfg.MinInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	enabler := level
+	if contextLevel < level {
+		enabler = contextLevel
+	}
+	sender := &emailSender{
+		cfg:      cfg,
+		interval: interval,
+		timeout:  _emailTimeout,
+		context:  newRingBuffer(cfg.ContextSize),
+		encoder:  newLineEncoder(zapcore.ISO8601TimeEncoder),
+	}
+	sender.batch = newBatcher(sinkEmail, BatchConfig{}, sender.deliver)
+	return &emailCore{
+		LevelEnabler: enabler,
+		level:        level,
+		sender:       sender,
+	}, nil
+}
+
+func (c *emailCore) With(fs []zapcore.Field) zapcore.Core {
+	return &emailCore{
+		LevelEnabler: c.LevelEnabler,
+		level:        c.level,
+		sender:       c.sender,
+		fields:       append(c.fields[:len(c.fields):len(c.fields)], fs...),
+	}
+}
+
+func (c *emailCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *emailCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	fields := append(c.fields[:len(c.fields):len(c.fields)], fs...)
+	line := encodeLine(c.sender.encoder, ent, fields)
+	if ent.Level < c.level {
+		c.sender.context.add(line)
+		return nil
+	}
+
+	stack := ent.Stack
+	if stack == "" {
+		stack = string(debug.Stack())
+	}
+	var report bytes.Buffer
+	report.WriteString(line)
+	report.WriteString("\n\nStacktrace:\n")
+	report.WriteString(stack)
+	if recent := c.sender.context.snapshot(); len(recent) > 0 {
+		report.WriteString("\n\nRecent logs:\n")
+		report.WriteString(strings.Join(recent, "\n"))
+	}
+	c.sender.context.add(line)
+
+	// fatal之后进程立即退出，不再等待合并，并等待发送完成
+	fatal := ent.Level == zapcore.FatalLevel
+	c.sender.add(report.String(), ent.Time, fatal)
+	if fatal {
+		c.sender.batch.wait(_batchFlushTimeout)
+	}
+	return nil
+}
+
+func (c *emailCore) Sync() error {
+	c.sender.flush()
+	c.sender.batch.wait(_batchFlushTimeout)
+	return nil
+}
+
+// emailCores 根据Emails配置生成邮件告警core
+func (c *LogOptions) emailCores() []zapcore.Core {
+	var cores []zapcore.Core
+	for _, cfg := range c.Emails {
+		core, err := newEmailCore(cfg)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		core.sender.metrics = c.metrics
+		core.sender.batch.metrics = c.metrics
+		core.sender.encoder = newLineEncoder(c.encodeTime)
+		c.metrics.queue(sinkEmail, core.sender.batch.len)
+		c.closeOnReload(core.sender.close)
+		cores = append(cores, core)
+	}
+	return cores
+}
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// smtpServer 最简单的SMTP服务器，记录收到的邮件内容；silent为true时接受连接但不响应
+type smtpServer struct {
+	ln     net.Listener
+	silent bool
+
+	mu    sync.Mutex
+	mails []string
+}
+
+func newSMTPServer(t *testing.T, silent bool) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{ln: ln, silent: silent}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	if s.silent {
+		// 客户端超时后关闭连接
+		conn.Read(make([]byte, 1))
+		return
+	}
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case cmd == "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.mu.Lock()
+			s.mails = append(s.mails, data.String())
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.mails...)
+}
+
+func (s *smtpServer) config() EmailConfig {
+	addr := s.ln.Addr().(*net.TCPAddr)
+	return EmailConfig{Host: "127.0.0.1", Port: addr.Port, From: "logger@example.com", To: []string{"ops@example.com"}, Level: "error"}
+}
+
+func TestEmailSendsInBackground(t *testing.T) {
+	srv := newSMTPServer(t, false)
+	core, err := newEmailCore(srv.config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer core.sender.close()
+	core.Write(zapcore.Entry{Level: zapcore.ErrorLevel, Time: time.Now(), Message: "disk full"}, nil)
+	core.Sync()
+	mails := srv.received()
+	if len(mails) != 1 || !strings.Contains(mails[0], "disk full") {
+		t.Fatalf("received %q", mails)
+	}
+}
+
+func TestEmailDoesNotBlockOnSilentServer(t *testing.T) {
+	captureErrors(t)
+	srv := newSMTPServer(t, true)
+	core, err := newEmailCore(srv.config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.sender.timeout = 200 * time.Millisecond
+
+	// SMTP服务器无响应时写日志不等待
+	start := time.Now()
+	core.Write(zapcore.Entry{Level: zapcore.ErrorLevel, Time: time.Now(), Message: "disk full"}, nil)
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Fatalf("Write blocked for %v", d)
+	}
+	// 超时后发送失败，Sync不会一直等待
+	if !core.sender.batch.wait(2 * time.Second) {
+		t.Fatal("send did not time out")
+	}
+}
@@ -0,0 +1,152 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// _omitKey 作为key时表示不输出该项
+const _omitKey = "-"
+
+// EncoderOptions InitLoggerWith的编码选项，零值使用默认设置
+type EncoderOptions struct {
+	// TimeKey 时间的key，默认 "time"，"-" 表示不输出时间
+	TimeKey string
+	// LevelKey 级别的key，默认 "level"，"-" 表示不输出级别
+	LevelKey string
+	// TimeLayout 时间格式，可以是Go时间格式(如 "2006-01-02 15:04:05")或预设格式
+	// "iso8601"(默认)、"rfc3339"、"rfc3339nano"、"epoch"、"epoch_millis"、"epoch_nanos"
+	TimeLayout string
+	// ShortCaller 调用位置只输出 包/文件:行号，默认输出完整路径
+	ShortCaller bool
+	// LevelEncoder 级别的格式，可选 "lowercase"(默认)、"capital"、"color"、"capitalColor"
+	LevelEncoder string
+}
+
+func (o EncoderOptions) withDefaults() EncoderOptions {
+	if o.TimeKey == "" {
+		o.TimeKey = "time"
+	}
+	if o.LevelKey == "" {
+		o.LevelKey = "level"
+	}
+	return o
+}
+
+func omitKey(key string) string {
+	if key == _omitKey {
+		return ""
+	}
+	return key
+}
+
+// encoderConfig 根据EncoderOptions生成zapcore.EncoderConfig，时间使用loc时区
+func (o EncoderOptions) encoderConfig(loc *time.Location) zapcore.EncoderConfig {
+	encodeTime := timeEncoder(o.TimeLayout, loc)
+
+	var encodeLevel zapcore.LevelEncoder
+	// 未知的格式使用lowercase
+	_ = encodeLevel.UnmarshalText([]byte(o.LevelEncoder))
+
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        omitKey(o.TimeKey),
+		LevelKey:       omitKey(o.LevelKey),
+		NameKey:        "logger",
+		CallerKey:      "file",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    encodeLevel,
+		EncodeTime:     encodeTime,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+		EncodeCaller:   zapcore.FullCallerEncoder,
+	}
+	if o.ShortCaller {
+		encoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
+	}
+	return encoderConfig
+}
+
+// EncoderConfig 配置文件中的编码设置，设置的项覆盖InitLogger/InitLoggerWith的参数，
+// key为 "-" 表示不输出该项
+type EncoderConfig struct {
+	MessageKey    string `json:"message_key" yaml:"message_key" toml:"message_key"`
+	LevelKey      string `json:"level_key" yaml:"level_key" toml:"level_key"`
+	TimeKey       string `json:"time_key" yaml:"time_key" toml:"time_key"`
+	NameKey       string `json:"name_key" yaml:"name_key" toml:"name_key"`
+	CallerKey     string `json:"caller_key" yaml:"caller_key" toml:"caller_key"`
+	StacktraceKey string `json:"stacktrace_key" yaml:"stacktrace_key" toml:"stacktrace_key"`
+	LineEnding    string `json:"line_ending" yaml:"line_ending" toml:"line_ending"`
+	// LevelEncoder 可选 "lowercase"、"capital"、"color"、"capitalColor"
+	LevelEncoder string `json:"level_encoder" yaml:"level_encoder" toml:"level_encoder"`
+	// DurationEncoder 可选 "seconds"、"ms"、"nanos"、"string"
+	DurationEncoder string `json:"duration_encoder" yaml:"duration_encoder" toml:"duration_encoder"`
+	// CallerEncoder 可选 "full"、"short"
+	CallerEncoder string `json:"caller_encoder" yaml:"caller_encoder" toml:"caller_encoder"`
+}
+
+// apply 用配置中设置的项覆盖ec
+func (e EncoderConfig) apply(ec *zapcore.EncoderConfig) {
+	for _, k := range []struct {
+		dst *string
+		src string
+	}{
+		{&ec.MessageKey, e.MessageKey},
+		{&ec.LevelKey, e.LevelKey},
+		{&ec.TimeKey, e.TimeKey},
+		{&ec.NameKey, e.NameKey},
+		{&ec.CallerKey, e.CallerKey},
+		{&ec.StacktraceKey, e.StacktraceKey},
+		{&ec.LineEnding, e.LineEnding},
+	} {
+		if k.src != "" {
+			*k.dst = omitKey(k.src)
+		}
+	}
+	if e.LevelEncoder != "" {
+		_ = ec.EncodeLevel.UnmarshalText([]byte(e.LevelEncoder))
+	}
+	if e.DurationEncoder != "" {
+		_ = ec.EncodeDuration.UnmarshalText([]byte(e.DurationEncoder))
+	}
+	if e.CallerEncoder != "" {
+		_ = ec.EncodeCaller.UnmarshalText([]byte(e.CallerEncoder))
+	}
+}
+
+// TimeLayout的预设格式
+const (
+	TimeLayoutISO8601     = "iso8601"
+	TimeLayoutRFC3339     = "rfc3339"
+	TimeLayoutRFC3339Nano = "rfc3339nano"
+	TimeLayoutEpoch       = "epoch"
+	TimeLayoutEpochMillis = "epoch_millis"
+	TimeLayoutEpochNanos  = "epoch_nanos"
+)
+
+// timeEncoder 按layout在loc时区输出时间，layout为预设格式名或Go时间格式，为空时使用ISO8601
+func timeEncoder(layout string, loc *time.Location) zapcore.TimeEncoder {
+	switch layout {
+	case "", TimeLayoutISO8601:
+		return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			zapcore.ISO8601TimeEncoder(t.In(loc), enc)
+		}
+	case TimeLayoutRFC3339:
+		layout = time.RFC3339
+	case TimeLayoutRFC3339Nano:
+		layout = time.RFC3339Nano
+	case TimeLayoutEpoch:
+		return zapcore.EpochTimeEncoder
+	case TimeLayoutEpochMillis:
+		return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendInt64(t.UnixNano() / int64(time.Millisecond))
+		}
+	case TimeLayoutEpochNanos:
+		return zapcore.EpochNanosTimeEncoder
+	}
+	encode := zapcore.TimeEncoderOfLayout(layout)
+	return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+		encode(t.In(loc), enc)
+	}
+}
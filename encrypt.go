@@ -0,0 +1,299 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// 加密日志中每条记录的格式:
+//
+//	version(1) [临时公钥(32)，仅version为2时] nonce(12) 密文长度(4) 密文
+//
+// 每次Write生成独立的记录(过长时拆分为多条)，切割、压缩后的文件同样可以解密
+const (
+	_encryptSymmetric = 1 // AES-256-GCM，使用配置的Key
+	_encryptX25519    = 2 // X25519 + AES-256-GCM，使用配置的PublicKey，解密需要对应的私钥
+
+	// _maxEncryptedRecord 单条记录密文的最大长度，更长的写入拆分为多条记录，
+	// 解密时长度超过该值的记录视为文件损坏，避免按损坏的长度分配内存
+	_maxEncryptedRecord = 16 * 1024 * 1024
+)
+
+// EncryptionConfig 日志文件加密配置，Key与PublicKey二选一
+type EncryptionConfig struct {
+	// Key 32字节AES-256密钥，hex或base64编码，见GenerateEncryptionKey
+	Key string `toml:"key" yaml:"key" json:"key"`
+	// KeyFile 从文件读取Key
+	KeyFile string `toml:"key_file" yaml:"key_file" json:"key_file"`
+	// PublicKey X25519公钥，base64编码，见GenerateEncryptionKeyPair，写日志的机器上不需要保存私钥
+	PublicKey string `toml:"public_key" yaml:"public_key" json:"public_key"`
+	// PrivateKey X25519私钥，base64编码，只用于解密
+	PrivateKey string `toml:"private_key" yaml:"private_key" json:"private_key"`
+}
+
+func (e EncryptionConfig) enabled() bool {
+	return e.Key != "" || e.KeyFile != "" || e.PublicKey != ""
+}
+
+// GenerateEncryptionKey 生成base64编码的AES-256密钥
+func GenerateEncryptionKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// GenerateEncryptionKeyPair 生成base64编码的X25519公钥和私钥
+func GenerateEncryptionKeyPair() (publicKey, privateKey string, err error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes()),
+		base64.StdEncoding.EncodeToString(priv.Bytes()), nil
+}
+
+// decodeKey 解析hex或base64编码的密钥
+func decodeKey(s string, size int) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if b, err := hex.DecodeString(s); err == nil && len(b) == size {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil && len(b) == size {
+		return b, nil
+	}
+	return nil, fmt.Errorf("logger: invalid encryption key, want %d bytes in hex or base64", size)
+}
+
+func (e EncryptionConfig) symmetricKey() ([]byte, error) {
+	key := e.Key
+	if key == "" && e.KeyFile != "" {
+		b, err := os.ReadFile(e.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key = string(b)
+	}
+	return decodeKey(key, 32)
+}
+
+// deriveKey 由X25519共享密钥及双方公钥生成AES密钥
+func deriveKey(shared, ephemeral, recipient []byte) []byte {
+	h := sha256.New()
+	h.Write(shared)
+	h.Write(ephemeral)
+	h.Write(recipient)
+	return h.Sum(nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptWriter 将每次Write的数据加密为一条记录写入w
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	version byte
+	// ephemeral X25519模式下本进程的临时公钥，写入每条记录
+	ephemeral []byte
+}
+
+func newEncryptWriter(w io.Writer, cfg EncryptionConfig) (*encryptWriter, error) {
+	if cfg.PublicKey != "" {
+		pub, err := decodeKey(cfg.PublicKey, 32)
+		if err != nil {
+			return nil, err
+		}
+		recipient, err := ecdh.X25519().NewPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		shared, err := priv.ECDH(recipient)
+		if err != nil {
+			return nil, err
+		}
+		ephemeral := priv.PublicKey().Bytes()
+		aead, err := newGCM(deriveKey(shared, ephemeral, pub))
+		if err != nil {
+			return nil, err
+		}
+		return &encryptWriter{w: w, aead: aead, version: _encryptX25519, ephemeral: ephemeral}, nil
+	}
+
+	key, err := cfg.symmetricKey()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, aead: aead, version: _encryptSymmetric}, nil
+}
+
+// Write 一次写入完整的记录，保证切割时记录不会被拆分到两个文件中，超过_maxEncryptedRecord时拆分为多条记录
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	max := _maxEncryptedRecord - e.aead.Overhead()
+	n := 0
+	for len(p) > max {
+		if _, err := e.writeRecord(p[:max]); err != nil {
+			return n, err
+		}
+		p, n = p[max:], n+max
+	}
+	m, err := e.writeRecord(p)
+	return n + m, err
+}
+
+func (e *encryptWriter) writeRecord(p []byte) (int, error) {
+	nonceSize := e.aead.NonceSize()
+	header := 1 + len(e.ephemeral) + nonceSize + 4
+	buf := make([]byte, header, header+len(p)+e.aead.Overhead())
+	buf[0] = e.version
+	copy(buf[1:], e.ephemeral)
+	nonce := buf[1+len(e.ephemeral) : 1+len(e.ephemeral)+nonceSize]
+	if _, err := rand.Read(nonce); err != nil {
+		return 0, err
+	}
+	buf = e.aead.Seal(buf, nonce, p, nil)
+	binary.BigEndian.PutUint32(buf[header-4:header], uint32(len(buf)-header))
+	if _, err := e.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Decrypt 解密src中的日志并写入dst，cfg中配置Key(对称加密)或PrivateKey(X25519)
+func Decrypt(dst io.Writer, src io.Reader, cfg EncryptionConfig) error {
+	var symmetric cipher.AEAD
+	if cfg.Key != "" || cfg.KeyFile != "" {
+		key, err := cfg.symmetricKey()
+		if err != nil {
+			return err
+		}
+		if symmetric, err = newGCM(key); err != nil {
+			return err
+		}
+	}
+	var priv *ecdh.PrivateKey
+	if cfg.PrivateKey != "" {
+		b, err := decodeKey(cfg.PrivateKey, 32)
+		if err != nil {
+			return err
+		}
+		if priv, err = ecdh.X25519().NewPrivateKey(b); err != nil {
+			return err
+		}
+	}
+	// 同一进程写入的记录使用相同的临时公钥，缓存派生的密钥
+	derived := make(map[string]cipher.AEAD)
+
+	r := bufio.NewReader(src)
+	version := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(r, version); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		var aead cipher.AEAD
+		switch version[0] {
+		case _encryptSymmetric:
+			if symmetric == nil {
+				return errors.New("logger: log is encrypted with a symmetric key")
+			}
+			aead = symmetric
+		case _encryptX25519:
+			if priv == nil {
+				return errors.New("logger: log is encrypted with a public key, private key is required")
+			}
+			ephemeral := make([]byte, 32)
+			if _, err := io.ReadFull(r, ephemeral); err != nil {
+				return err
+			}
+			aead = derived[string(ephemeral)]
+			if aead == nil {
+				pub, err := ecdh.X25519().NewPublicKey(ephemeral)
+				if err != nil {
+					return err
+				}
+				shared, err := priv.ECDH(pub)
+				if err != nil {
+					return err
+				}
+				if aead, err = newGCM(deriveKey(shared, ephemeral, priv.PublicKey().Bytes())); err != nil {
+					return err
+				}
+				derived[string(ephemeral)] = aead
+			}
+		default:
+			return fmt.Errorf("logger: unknown encrypted record version %d", version[0])
+		}
+
+		header := make([]byte, aead.NonceSize()+4)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return err
+		}
+		nonce := header[:aead.NonceSize()]
+		size := binary.BigEndian.Uint32(header[aead.NonceSize():])
+		if size > _maxEncryptedRecord {
+			return fmt.Errorf("logger: encrypted record of %d bytes exceeds the maximum %d, log is corrupted", size, _maxEncryptedRecord)
+		}
+		ciphertext := make([]byte, size)
+		if _, err := io.ReadFull(r, ciphertext); err != nil {
+			return err
+		}
+		plaintext, err := aead.Open(ciphertext[:0], nonce, ciphertext, nil)
+		if err != nil {
+			return fmt.Errorf("logger: decrypt log record: %v", err)
+		}
+		if _, err := dst.Write(plaintext); err != nil {
+			return err
+		}
+	}
+}
+
+// DecryptFile 解密日志文件并写入dst，按后缀自动解压切割后压缩的 .gz、.zst 文件
+func DecryptFile(dst io.Writer, filename string, cfg EncryptionConfig) error {
+	src, err := openLogFile(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return Decrypt(dst, src, cfg)
+}
+
+// encrypt 配置了Encryption时返回加密写入w的Writer，否则返回w
+func (c *LogOptions) encrypt(w io.Writer) io.Writer {
+	if w == nil || !c.Encryption.enabled() {
+		return w
+	}
+	ew, err := newEncryptWriter(w, c.Encryption)
+	if err != nil {
+		panic(err)
+	}
+	return ew
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key, err := GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, priv, err := GenerateEncryptionKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct{ write, read EncryptionConfig }{
+		"symmetric": {EncryptionConfig{Key: key}, EncryptionConfig{Key: key}},
+		"x25519":    {EncryptionConfig{PublicKey: pub}, EncryptionConfig{PrivateKey: priv}},
+	} {
+		var buf bytes.Buffer
+		w, err := newEncryptWriter(&buf, tc.write)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range []string{"first line\n", "second line\n"} {
+			if _, err := w.Write([]byte(line)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if strings.Contains(buf.String(), "line") {
+			t.Fatalf("%s: plaintext in encrypted output", name)
+		}
+		var out bytes.Buffer
+		if err := Decrypt(&out, &buf, tc.read); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out.String() != "first line\nsecond line\n" {
+			t.Fatalf("%s: decrypted %q", name, out.String())
+		}
+	}
+}
+
+func TestEncryptSplitsLargeWrite(t *testing.T) {
+	key, err := GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := EncryptionConfig{Key: key}
+	var buf bytes.Buffer
+	w, err := newEncryptWriter(&buf, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := bytes.Repeat([]byte("x"), _maxEncryptedRecord+100)
+	n, err := w.Write(p)
+	if err != nil || n != len(p) {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	// 拆分后的每条记录都不超过_maxEncryptedRecord，可以正常解密
+	var out bytes.Buffer
+	if err := Decrypt(&out, bytes.NewReader(buf.Bytes()), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), p) {
+		t.Fatalf("decrypted %d bytes, want %d", out.Len(), len(p))
+	}
+}
+
+func TestDecryptRejectsCorruptLength(t *testing.T) {
+	key, err := GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := EncryptionConfig{Key: key}
+	var buf bytes.Buffer
+	w, err := newEncryptWriter(&buf, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("line\n")); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	// version(1) nonce(12) 之后是密文长度
+	binary.BigEndian.PutUint32(b[13:17], 0xffffffff)
+	err = Decrypt(&bytes.Buffer{}, bytes.NewReader(b), cfg)
+	if err == nil || !strings.Contains(err.Error(), "log is corrupted") {
+		t.Fatalf("Decrypt = %v, want corrupted error", err)
+	}
+}
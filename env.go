@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// EnvPrefix 环境变量覆盖配置的前缀，如 LOGGER_LEVEL、LOGGER_ENCODING、LOGGER_SENTRY_CONFIG_DSN
+const EnvPrefix = "LOGGER_"
+
+// _envPattern 匹配 ${VAR} 及带默认值的 ${VAR:-default}，不展开 $VAR 以免误伤密码等配置中的 "$"
+var _envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+var _textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// expandEnv 展开s中的 ${VAR} 占位符，VAR未设置或为空时使用默认值
+func expandEnv(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return _envPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := _envPattern.FindStringSubmatch(m)
+		if v := os.Getenv(sub[1]); v != "" || sub[2] == "" {
+			return v
+		}
+		return sub[3]
+	})
+}
+
+// ApplyEnv 展开配置中所有字符串的 ${VAR} 占位符，然后按 EnvPrefix+配置名 的环境变量覆盖配置，
+// 嵌套配置以 "_" 连接，如 LOGGER_SENTRY_CONFIG_DSN，列表以 "," 分隔，NewFromToml/NewFromYaml/NewFromJson 解析后自动调用
+func (c *LogOptions) ApplyEnv() error {
+	v := reflect.ValueOf(c).Elem()
+	expandValue(v)
+	return overrideEnv(v, EnvPrefix)
+}
+
+// expandValue 递归展开v中导出的字符串
+func expandValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(expandEnv(v.String()))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			expandValue(v.Elem())
+		}
+	case reflect.Interface:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		e := reflect.New(v.Elem().Type()).Elem()
+		e.Set(v.Elem())
+		expandValue(e)
+		v.Set(e)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				expandValue(v.Field(i))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			expandValue(v.Index(i))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(iter.Value())
+			expandValue(e)
+			v.SetMapIndex(iter.Key(), e)
+		}
+	}
+}
+
+// overrideEnv 按环境变量覆盖结构体v中的标量、字符串列表及实现了encoding.TextUnmarshaler的配置
+func overrideEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := yamlName(f)
+		// profile在解析时已由ProfileEnv选择
+		if f.PkgPath != "" || name == "-" || (prefix == EnvPrefix && name == "profile") {
+			continue
+		}
+		key := prefix + strings.ToUpper(name)
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && !reflect.PtrTo(fv.Type()).Implements(_textUnmarshalerType) {
+			if err := overrideEnv(fv, key+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+		s, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+		if err := setEnvValue(fv, s); err != nil {
+			return fmt.Errorf("logger: invalid %s=%q: %v", key, s, err)
+		}
+	}
+	return nil
+}
+
+// yamlName 返回字段在配置文件中的名称，与yaml一致，没有tag时使用小写的字段名
+func yamlName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	return name
+}
+
+func setEnvValue(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil && v.Kind() == reflect.Int8 {
+			// level 也可以使用级别名称，如 LOGGER_LEVEL=debug
+			var l zapcore.Level
+			if unmarshalLevel(&l, s) == nil {
+				n, err = int64(l), nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items).Convert(v.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("LOGGER_TEST_HOST", "db.local")
+	t.Setenv("LOGGER_TEST_EMPTY", "")
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"${LOGGER_TEST_HOST}", "db.local"},
+		{"tcp://${LOGGER_TEST_HOST}:5432", "tcp://db.local:5432"},
+		{"${LOGGER_TEST_UNSET}", ""},
+		{"${LOGGER_TEST_UNSET:-fallback}", "fallback"},
+		// 变量为空时同样使用默认值
+		{"${LOGGER_TEST_EMPTY:-fallback}", "fallback"},
+		{"${LOGGER_TEST_HOST:-fallback}", "db.local"},
+		// 不展开$VAR，密码中的"$"保持原样
+		{"pa$LOGGER_TEST_HOST", "pa$LOGGER_TEST_HOST"},
+		{"${LOGGER_TEST_HOST", "${LOGGER_TEST_HOST"},
+	}
+	for _, tt := range tests {
+		if got := expandEnv(tt.in); got != tt.want {
+			t.Errorf("expandEnv(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		opts LogOptions
+		want func(c *LogOptions) interface{}
+		val  interface{}
+	}{
+		{
+			name: "level name",
+			env:  map[string]string{"LOGGER_LEVEL": "debug"},
+			want: func(c *LogOptions) interface{} { return c.Level },
+			val:  int8(-1),
+		},
+		{
+			name: "level number",
+			env:  map[string]string{"LOGGER_LEVEL": "2"},
+			want: func(c *LogOptions) interface{} { return c.Level },
+			val:  int8(2),
+		},
+		{
+			name: "string",
+			env:  map[string]string{"LOGGER_INFO_FILENAME": "/var/log/app.log"},
+			opts: LogOptions{InfoFilename: "app.log"},
+			want: func(c *LogOptions) interface{} { return c.InfoFilename },
+			val:  "/var/log/app.log",
+		},
+		{
+			name: "int",
+			env:  map[string]string{"LOGGER_MAX_SIZE": "64"},
+			want: func(c *LogOptions) interface{} { return c.MaxSize },
+			val:  64,
+		},
+		{
+			name: "bool",
+			env:  map[string]string{"LOGGER_LEVEL_SEPARATE": "true"},
+			want: func(c *LogOptions) interface{} { return c.LevelSeparate },
+			val:  true,
+		},
+		{
+			name: "list",
+			env:  map[string]string{"LOGGER_LEVEL_RULES": "app/db=debug,app/http=warn"},
+			want: func(c *LogOptions) interface{} { return c.LevelRules },
+			val:  []string{"app/db=debug", "app/http=warn"},
+		},
+		{
+			name: "nested",
+			env:  map[string]string{"LOGGER_SENTRY_CONFIG_DSN": "https://key@sentry.local/1"},
+			want: func(c *LogOptions) interface{} { return c.SentryConfig.DSN },
+			val:  "https://key@sentry.local/1",
+		},
+		{
+			name: "placeholder",
+			env:  map[string]string{"LOGGER_TEST_DIR": "/data"},
+			opts: LogOptions{InfoFilename: "${LOGGER_TEST_DIR}/app.log"},
+			want: func(c *LogOptions) interface{} { return c.InfoFilename },
+			val:  "/data/app.log",
+		},
+		{
+			// 环境变量覆盖优先于配置中的占位符
+			name: "override placeholder",
+			env:  map[string]string{"LOGGER_TEST_DIR": "/data", "LOGGER_INFO_FILENAME": "/tmp/app.log"},
+			opts: LogOptions{InfoFilename: "${LOGGER_TEST_DIR}/app.log"},
+			want: func(c *LogOptions) interface{} { return c.InfoFilename },
+			val:  "/tmp/app.log",
+		},
+		{
+			name: "unset keeps config",
+			opts: LogOptions{MaxSize: 10},
+			want: func(c *LogOptions) interface{} { return c.MaxSize },
+			val:  10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			c := tt.opts
+			if err := c.ApplyEnv(); err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.want(&c); !reflect.DeepEqual(got, tt.val) {
+				t.Errorf("got %#v, want %#v", got, tt.val)
+			}
+		})
+	}
+}
+
+func TestApplyEnvInvalid(t *testing.T) {
+	tests := []struct {
+		key, value string
+	}{
+		{"LOGGER_MAX_SIZE", "big"},
+		{"LOGGER_LEVEL_SEPARATE", "maybe"},
+		{"LOGGER_LEVEL", "verbose"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			var c LogOptions
+			err := c.ApplyEnv()
+			if err == nil || !strings.Contains(err.Error(), tt.key) {
+				t.Fatalf("ApplyEnv() = %v, want error for %s", err, tt.key)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// chainError 标记由WithError生成的错误字段，输出时展开为错误链
+type chainError struct {
+	err error
+}
+
+func (e *chainError) Error() string {
+	return e.err.Error()
+}
+
+func (e *chainError) Unwrap() error {
+	return e.err
+}
+
+// Format 保持与原错误相同的格式化结果
+func (e *chainError) Format(s fmt.State, verb rune) {
+	if f, ok := e.err.(fmt.Formatter); ok {
+		f.Format(s, verb)
+		return
+	}
+	fmt.Fprint(s, e.err.Error())
+}
+
+// unwrap 返回err包装的下层错误，支持errors.Unwrap及pkg/errors的Cause
+func unwrap(err error) error {
+	if cause := errors.Unwrap(err); cause != nil {
+		return cause
+	}
+	if e, ok := err.(interface{ Cause() error }); ok {
+		return e.Cause()
+	}
+	return nil
+}
+
+// errorCauses 返回err展开后的所有下层错误的消息，支持errors.Unwrap、pkg/errors、errors.Join及multierr
+func errorCauses(err error) []string {
+	var causes []string
+	var walk func(err error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, cause := range e.Unwrap() {
+				causes = append(causes, cause.Error())
+				walk(cause)
+			}
+		case interface{ Errors() []error }:
+			for _, cause := range e.Errors() {
+				causes = append(causes, cause.Error())
+				walk(cause)
+			}
+		default:
+			if cause := unwrap(err); cause != nil {
+				causes = append(causes, cause.Error())
+				walk(cause)
+			}
+		}
+	}
+	walk(err)
+	return causes
+}
+
+// walkErrors 依次对err及其展开的下层错误调用fn，支持的错误链同errorCauses，fn返回true时停止并返回true
+func walkErrors(err error, fn func(error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if fn(err) {
+		return true
+	}
+	var causes []error
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		causes = e.Unwrap()
+	case interface{ Errors() []error }:
+		causes = e.Errors()
+	default:
+		causes = []error{unwrap(err)}
+	}
+	for _, cause := range causes {
+		if walkErrors(cause, fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorStack 返回错误链中第一个带调用栈的错误(如pkg/errors)的 %+v 输出
+func errorStack(err error) string {
+	for ; err != nil; err = unwrap(err) {
+		if _, ok := err.(fmt.Formatter); !ok {
+			continue
+		}
+		if verbose := fmt.Sprintf("%+v", err); verbose != err.Error() {
+			return verbose
+		}
+	}
+	return ""
+}
+
+// expandErrors 把WithError生成的字段展开为 key、key_causes、key_stacktrace 三个字段
+func expandErrors(fs []zapcore.Field) []zapcore.Field {
+	var expanded []zapcore.Field
+	for i, f := range fs {
+		e, ok := f.Interface.(*chainError)
+		if !ok || f.Type != zapcore.ErrorType {
+			if expanded != nil {
+				expanded = append(expanded, f)
+			}
+			continue
+		}
+		if expanded == nil {
+			expanded = append(make([]zapcore.Field, 0, len(fs)+2), fs[:i]...)
+		}
+		// 保留原错误，sentry等需要按错误类型处理的输出仍然可以取到
+		expanded = append(expanded, zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: e.err.Error(), Interface: e.err})
+		if causes := errorCauses(e.err); len(causes) > 0 {
+			expanded = append(expanded, zap.Strings(f.Key+"_causes", causes))
+		}
+		if stack := errorStack(e.err); stack != "" {
+			expanded = append(expanded, zap.String(f.Key+"_stacktrace", stack))
+		}
+	}
+	if expanded == nil {
+		return fs
+	}
+	return expanded
+}
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrorCodeKey 错误码字段的名称
+const ErrorCodeKey = "error_code"
+
+// WithCode 设置日志的错误码，告警和sentry指纹可以按错误码而不是消息文本区分错误
+func WithCode(code string) zap.Field {
+	return zap.String(ErrorCodeKey, code)
+}
+
+// ErrorCoder 实现该接口的错误使用ErrorCode的返回值作为错误码
+type ErrorCoder interface {
+	ErrorCode() string
+}
+
+// ErrorClassifier 返回错误对应的错误码，ok为false表示无法分类
+type ErrorClassifier func(err error) (code string, ok bool)
+
+var (
+	_classifiersMu sync.RWMutex
+	_classifiers   []ErrorClassifier
+)
+
+// RegisterErrorClassifier 注册错误分类函数，按注册顺序使用第一个能分类的结果
+func RegisterErrorClassifier(fn ErrorClassifier) {
+	_classifiersMu.Lock()
+	defer _classifiersMu.Unlock()
+	_classifiers = append(_classifiers, fn)
+}
+
+// RegisterErrorCode 错误链中包含target(errors.Is)时使用code作为错误码，如
+//
+//	logger.RegisterErrorCode(sql.ErrNoRows, "DB_NOT_FOUND")
+func RegisterErrorCode(target error, code string) {
+	RegisterErrorClassifier(func(err error) (string, bool) {
+		return code, errors.Is(err, target)
+	})
+}
+
+// ErrorCode 返回err的错误码：错误链中实现了ErrorCoder的错误优先，其次是注册的分类函数
+func ErrorCode(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	var code string
+	if walkErrors(err, func(e error) bool {
+		if coder, ok := e.(ErrorCoder); ok {
+			code = coder.ErrorCode()
+		}
+		return code != ""
+	}) {
+		return code, true
+	}
+	_classifiersMu.RLock()
+	defer _classifiersMu.RUnlock()
+	for _, fn := range _classifiers {
+		if code, ok := fn(err); ok && code != "" {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// classifyErrors 没有error_code字段时按第一个能分类的错误字段加上error_code
+func classifyErrors(fs []zapcore.Field) []zapcore.Field {
+	var code string
+	for _, f := range fs {
+		if f.Key == ErrorCodeKey {
+			return fs
+		}
+		if code != "" || f.Type != zapcore.ErrorType {
+			continue
+		}
+		if err, ok := f.Interface.(error); ok {
+			code, _ = ErrorCode(err)
+		}
+	}
+	if code == "" {
+		return fs
+	}
+	return append(fs[:len(fs):len(fs)], WithCode(code))
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_errorHandlerMu sync.RWMutex
+	_errorHandler   func(error)
+	// _stderrLimiter 默认处理方式下，相同的错误每分钟最多输出5次，避免磁盘写满等持续性错误刷屏
+	_stderrLimiter = newRateLimiter(5, time.Minute)
+)
+
+// SetErrorHandler 设置日志自身出错(写文件失败、网络输出失败、上报sentry失败等)时的处理函数，
+// 为nil时恢复默认处理：限流后输出到stderr
+func SetErrorHandler(fn func(error)) {
+	_errorHandlerMu.Lock()
+	_errorHandler = fn
+	_errorHandlerMu.Unlock()
+}
+
+// handleError 将日志自身的错误交给SetErrorHandler设置的处理函数
+func handleError(err error) {
+	_errorHandlerMu.RLock()
+	fn := _errorHandler
+	_errorHandlerMu.RUnlock()
+	if fn != nil {
+		fn(err)
+		return
+	}
+
+	ok, suppressed := _stderrLimiter.allow(err.Error(), time.Now())
+	if !ok {
+		return
+	}
+	if suppressed > 0 {
+		fmt.Fprintf(os.Stderr, "%v (%d similar errors suppressed)\n", err, suppressed)
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+}
+
+// errorOutput 作为zap的ErrorOutput，将zap写入失败等内部错误交给handleError
+type errorOutput struct{}
+
+func (errorOutput) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(string(p))
+	// 去掉zap加在前面的时间，便于限流时合并相同的错误
+	if i := strings.Index(msg, " write error: "); i >= 0 {
+		msg = msg[i+1:]
+	}
+	handleError(fmt.Errorf("logger: %s", msg))
+	return len(p), nil
+}
+
+func (errorOutput) Sync() error {
+	return nil
+}
@@ -0,0 +1,55 @@
+// Package etcdlog 从etcd的key中读取 logger.LogOptions 创建Log，并监听key的变化调用 Log.Reload，
+// 平台可以统一调整日志级别、输出等配置而不需要重新部署
+//
+//	log, err := etcdlog.New(ctx, cli, "/config/app/log", logger.FormatYaml, logger.EncoderOptions{})
+package etcdlog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mae-pax/logger"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// New 读取key的配置创建Log，并在ctx结束前监听key的变化，format见logger.NewFromBytes，
+// 变化后的配置不合法或key被删除时记录错误日志并保留原有输出
+func New(ctx context.Context, cli *clientv3.Client, key, format string, eo logger.EncoderOptions) (*logger.Log, error) {
+	resp, err := cli.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, fmt.Errorf("logger: etcd key %q not found", key)
+	}
+	c, err := logger.NewFromBytes(resp.Kvs[0].Value, format)
+	if err != nil {
+		return nil, err
+	}
+	log := c.InitLoggerWith(eo)
+	go watch(ctx, cli, key, format, resp.Header.Revision+1, log)
+	return log, nil
+}
+
+func watch(ctx context.Context, cli *clientv3.Client, key, format string, rev int64, log *logger.Log) {
+	for wresp := range cli.Watch(ctx, key, clientv3.WithRev(rev)) {
+		if err := wresp.Err(); err != nil {
+			log.Error("logger: etcd watch", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		for _, ev := range wresp.Events {
+			if ev.Type == clientv3.EventTypeDelete {
+				log.Warn("logger: etcd key deleted, keep current config", zap.String("key", key))
+				continue
+			}
+			c, err := logger.NewFromBytes(ev.Kv.Value, format)
+			if err == nil {
+				err = log.Reload(c)
+			}
+			if err != nil {
+				log.Error("logger: etcd reload", zap.String("key", key), zap.Int64("revision", ev.Kv.ModRevision), zap.Error(err))
+			}
+		}
+	}
+}
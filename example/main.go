@@ -12,10 +12,12 @@ import (
 
 var (
 	confPath string
+	profile  string
 )
 
 func init() {
 	pflag.StringVar(&confPath, "conf", "configs/config.yaml", "default configs path")
+	pflag.StringVar(&profile, "profile", "", "config profile, defaults to $LOG_PROFILE or profile in config")
 }
 
 func main() {
@@ -38,10 +40,11 @@ func main() {
 	// 	},
 	// }
 
-	c := baselogger.NewFromYaml(confPath)
+	pflag.Parse()
+	c := baselogger.NewFromYamlProfile(confPath, profile)
 	// c.CloseConsoleDisplay()
 	c.SetCaller(true, 2)
-	logger := c.InitLogger("time", "level", false, true)
+	logger := c.InitLoggerWith(baselogger.EncoderOptions{ShortCaller: true})
 
 	logger.Info("info level test")
 	logger.Error("dsdadadad level test", baselogger.WithError(errors.New("sabhksasas")))
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"os"
+	"runtime"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// fatalHooks Fatal日志输出后执行的回调和退出函数
+type fatalHooks struct {
+	mu   sync.RWMutex
+	fns  []func()
+	exit func(code int)
+}
+
+func newFatalHooks() *fatalHooks {
+	return &fatalHooks{exit: os.Exit}
+}
+
+// run 依次执行回调后调用退出函数，退出函数返回时结束当前goroutine，
+// 否则zap会在写入后直接调用os.Exit
+func (h *fatalHooks) run() {
+	h.mu.RLock()
+	fns, exit := h.fns, h.exit
+	h.mu.RUnlock()
+	for _, fn := range fns {
+		fn()
+	}
+	exit(1)
+	runtime.Goexit()
+}
+
+// fatalCore 在Fatal日志写入所有输出后执行fatalHooks，先于zap的os.Exit
+type fatalCore struct {
+	zapcore.Core
+	hooks *fatalHooks
+}
+
+func (c *fatalCore) With(fs []zapcore.Field) zapcore.Core {
+	return &fatalCore{Core: c.Core.With(fs), hooks: c.hooks}
+}
+
+func (c *fatalCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < zapcore.FatalLevel {
+		return c.Core.Check(ent, ce)
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *fatalCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	if inner := c.Core.Check(ent, nil); inner != nil {
+		inner.ErrorOutput = errorOutput{}
+		inner.Write(fs...)
+	}
+	c.Core.Sync()
+	// 重放归档中的fatal日志时不退出
+	if !isReplay(fs) {
+		c.hooks.run()
+	}
+	return nil
+}
+
+// SetExitFunc 设置Fatal日志输出后调用的退出函数，默认os.Exit，
+// 测试中可以替换为panic或记录退出码，避免结束测试进程；退出函数返回时通过runtime.Goexit结束当前goroutine
+func (log *Log) SetExitFunc(fn func(code int)) {
+	if log.fatal == nil {
+		return
+	}
+	log.fatal.mu.Lock()
+	defer log.fatal.mu.Unlock()
+	log.fatal.exit = fn
+}
+
+// OnFatal 注册Fatal日志输出后、退出前执行的回调，用于刷新缓冲、释放资源等清理工作，
+// os.Exit不会执行defer，需要在退出前完成的工作应注册在这里
+func (log *Log) OnFatal(fn func()) {
+	if log.fatal == nil {
+		return
+	}
+	log.fatal.mu.Lock()
+	defer log.fatal.mu.Unlock()
+	log.fatal.fns = append(log.fatal.fns, fn)
+}
@@ -0,0 +1,157 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 备用输出中的特殊名称，其余按文件路径处理
+const (
+	FailoverStderr  = "stderr"
+	FailoverStdout  = "stdout"
+	FailoverDiscard = "discard"
+)
+
+// FailoverConfig 输出连续失败时切换到备用输出，之后定期探测主输出，恢复后切换回来
+type FailoverConfig struct {
+	// Fallbacks 备用输出，按顺序切换，可选 "stderr"、"stdout"、"discard" 或文件路径
+	Fallbacks []string `toml:"fallbacks" yaml:"fallbacks" json:"fallbacks"`
+	// MaxErrors 连续失败多少次后切换，默认3
+	MaxErrors int `toml:"max_errors" yaml:"max_errors" json:"max_errors"`
+	// ProbeInterval 切换后探测主输出的间隔(秒)，默认30
+	ProbeInterval int `toml:"probe_interval" yaml:"probe_interval" json:"probe_interval"`
+}
+
+// failoverWriter 依次尝试writers，当前输出失败的日志会写入后面的输出而不是丢弃
+type failoverWriter struct {
+	mu        sync.Mutex
+	writers   []io.Writer
+	names     []string
+	active    int
+	failures  int
+	maxErrors int
+	probe     time.Duration
+	lastProbe time.Time
+}
+
+func (w *failoverWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	n, report, err := w.write(p)
+	w.mu.Unlock()
+	// 错误处理函数可能通过同一个Log再写日志，在释放锁之后报告
+	if report != nil {
+		handleError(report)
+	}
+	return n, err
+}
+
+// write 必须在持有锁时调用，report为需要报告的切换事件
+func (w *failoverWriter) write(p []byte) (n int, report, err error) {
+	now := time.Now()
+	if w.active > 0 && now.Sub(w.lastProbe) >= w.probe {
+		w.lastProbe = now
+		if _, err := w.writers[0].Write(p); err == nil {
+			report = fmt.Errorf("logger: %s recovered, switching back from %s", w.names[0], w.names[w.active])
+			w.active, w.failures = 0, 0
+			return len(p), report, nil
+		}
+	}
+
+	var lastErr error
+	for i := w.active; i < len(w.writers); i++ {
+		_, err := w.writers[i].Write(p)
+		if err == nil {
+			if i == w.active {
+				w.failures = 0
+			}
+			return len(p), report, nil
+		}
+		lastErr = err
+		if i == w.active {
+			w.failures++
+			if w.failures >= w.maxErrors && w.active < len(w.writers)-1 {
+				w.active++
+				w.failures = 0
+				w.lastProbe = now
+				report = fmt.Errorf("logger: %s failed %d times (%v), failing over to %s",
+					w.names[i], w.maxErrors, err, w.names[w.active])
+			}
+		}
+	}
+	return 0, report, lastErr
+}
+
+func (w *failoverWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if s, ok := w.writers[w.active].(zapcore.WriteSyncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// close 关闭打开的备用文件，主输出由创建者关闭
+func (w *failoverWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, fw := range w.writers[1:] {
+		if f, ok := fw.(*os.File); ok && f != os.Stdout && f != os.Stderr {
+			f.Close()
+		}
+	}
+}
+
+// fallbackWriter 打开备用输出
+func (c *LogOptions) fallbackWriter(name string) (io.Writer, error) {
+	switch name {
+	case FailoverStderr:
+		return os.Stderr, nil
+	case FailoverStdout:
+		return os.Stdout, nil
+	case FailoverDiscard:
+		return io.Discard, nil
+	}
+	if err := c.prepareLogFile(name, false); err != nil {
+		return nil, err
+	}
+	mode, err := c.fileMode()
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, mode)
+}
+
+// failover 按Failover中output对应的配置为w增加备用输出，未配置时返回w
+func (c *LogOptions) failover(output, name string, w io.Writer) io.Writer {
+	cfg, ok := c.Failover[output]
+	if w == nil || !ok || len(cfg.Fallbacks) == 0 {
+		return w
+	}
+	fw := &failoverWriter{
+		writers:   []io.Writer{w},
+		names:     []string{name},
+		maxErrors: cfg.MaxErrors,
+		probe:     time.Duration(cfg.ProbeInterval) * time.Second,
+	}
+	if fw.maxErrors <= 0 {
+		fw.maxErrors = 3
+	}
+	if fw.probe <= 0 {
+		fw.probe = 30 * time.Second
+	}
+	for _, fallback := range cfg.Fallbacks {
+		fb, err := c.fallbackWriter(fallback)
+		if err != nil {
+			panic(err)
+		}
+		fw.writers = append(fw.writers, fb)
+		fw.names = append(fw.names, fallback)
+	}
+	c.closeOnReload(fw.close)
+	return fw
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestFailoverReportsOutsideLock(t *testing.T) {
+	var fallback bytes.Buffer
+	w := &failoverWriter{
+		writers:   []io.Writer{failingWriter{}, &fallback},
+		names:     []string{"app.log", FailoverStderr},
+		maxErrors: 1,
+		probe:     time.Hour,
+	}
+	// 错误处理函数通过同一个输出再写日志，不能死锁
+	reported := make(chan error, 1)
+	SetErrorHandler(func(err error) {
+		w.Write([]byte("failover reported\n"))
+		reported <- err
+	})
+	defer SetErrorHandler(nil)
+
+	done := make(chan struct{})
+	go func() {
+		w.Write([]byte("first\n"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Write deadlocked while reporting the failover")
+	}
+	if err := <-reported; err == nil {
+		t.Fatal("failover was not reported")
+	}
+	if got := fallback.String(); got != "first\nfailover reported\n" {
+		t.Fatalf("fallback got %q", got)
+	}
+}
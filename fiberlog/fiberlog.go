@@ -0,0 +1,126 @@
+// Package fiberlog 提供使用 logger.Log 的 Fiber 请求日志和panic恢复中间件
+package fiberlog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mae-pax/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type options struct {
+	header string
+	levels map[string]zapcore.Level
+	skip   func(c *fiber.Ctx) bool
+}
+
+// Option Middleware的选项
+type Option func(*options)
+
+// WithRequestIDHeader 设置读取和返回请求ID的header，默认 X-Request-ID
+func WithRequestIDHeader(header string) Option {
+	return func(o *options) {
+		o.header = header
+	}
+}
+
+// WithRouteLevel 设置路由的日志级别，route为注册路由时的路径，如 "/users/:id"，
+// 低于该级别的访问日志不输出，5xx不受影响
+func WithRouteLevel(route string, level zapcore.Level) Option {
+	return func(o *options) {
+		o.levels[route] = level
+	}
+}
+
+// WithSkip 设置不输出访问日志的请求
+func WithSkip(skip func(c *fiber.Ctx) bool) Option {
+	return func(o *options) {
+		o.skip = skip
+	}
+}
+
+// Middleware 每个请求输出一条访问日志，并生成或透传请求ID，请求ID同时保存在 c.Locals("request_id") 和 c.UserContext() 中，
+// 5xx使用Error级别，4xx使用Warn级别，其他使用Info级别
+func Middleware(log *logger.Log, opts ...Option) fiber.Handler {
+	o := &options{header: logger.RequestIDHeader, levels: make(map[string]zapcore.Level)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(c *fiber.Ctx) error {
+		if o.skip != nil && o.skip(c) {
+			return c.Next()
+		}
+		start := time.Now()
+		// fiber返回的字符串引用请求缓冲区，请求结束后会被复用，需要复制后再写入日志
+		id := strings.Clone(c.Get(o.header))
+		if id == "" {
+			id = logger.NewRequestID()
+			c.Request().Header.Set(o.header, id)
+		}
+		c.Set(o.header, id)
+		c.Locals("request_id", id)
+		c.SetUserContext(logger.WithRequestID(c.UserContext(), id))
+
+		err := c.Next()
+		if err != nil {
+			if herr := c.App().ErrorHandler(c, err); herr != nil {
+				c.Status(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		route := strings.Clone(c.Route().Path)
+		level := statusLevel(status)
+		if min, ok := o.levels[route]; ok && level < zapcore.ErrorLevel && level < min {
+			return nil
+		}
+		ce := log.Ctx(c.UserContext()).L.Check(level, "http request")
+		if ce == nil {
+			return nil
+		}
+		fields := []zap.Field{
+			zap.String("method", strings.Clone(c.Method())),
+			zap.String("path", strings.Clone(c.Path())),
+			zap.String("route", route),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.Int("bytes", len(c.Response().Body())),
+			zap.String("remote_ip", strings.Clone(c.IP())),
+		}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+		ce.Write(fields...)
+		return nil
+	}
+}
+
+// Recover 恢复handler中的panic，输出带堆栈的Error日志后返回500
+func Recover(log *logger.Log) fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Ctx(c.UserContext()).L.Error("panic recovered",
+					zap.String("panic", fmt.Sprint(r)),
+					zap.Stack("stacktrace"),
+				)
+				err = fiber.ErrInternalServerError
+			}
+		}()
+		return c.Next()
+	}
+}
+
+func statusLevel(status int) zapcore.Level {
+	switch {
+	case status >= 500:
+		return zapcore.ErrorLevel
+	case status >= 400:
+		return zapcore.WarnLevel
+	}
+	return zapcore.InfoLevel
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// globalFields 添加到每条日志的字段，可以在运行中通过Log.SetGlobalFields替换
+type globalFields struct {
+	v atomic.Value // []zapcore.Field
+}
+
+func (g *globalFields) load() []zapcore.Field {
+	fs, _ := g.v.Load().([]zapcore.Field)
+	return fs
+}
+
+func (g *globalFields) store(fs []zapcore.Field) {
+	g.v.Store(append([]zapcore.Field(nil), fs...))
+}
+
+// globalCore 写入时在日志字段前加上全局字段，包括SetGlobalFields之前通过With派生的logger
+type globalCore struct {
+	zapcore.Core
+	globals *globalFields
+}
+
+func (c *globalCore) With(fs []zapcore.Field) zapcore.Core {
+	return &globalCore{Core: c.Core.With(fs), globals: c.globals}
+}
+
+func (c *globalCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	// 没有全局字段时直接交给内部core，不增加开销
+	if len(c.globals.load()) == 0 {
+		return c.Core.Check(ent, ce)
+	}
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *globalCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	inner := c.Core.Check(ent, nil)
+	if inner == nil {
+		return nil
+	}
+	globals := c.globals.load()
+	all := make([]zapcore.Field, 0, len(globals)+len(fs))
+	inner.ErrorOutput = errorOutput{}
+	inner.Write(append(append(all, globals...), fs...)...)
+	return nil
+}
+
+// configFields 将配置中的Fields按key排序后转换为zap字段
+func configFields(m map[string]interface{}) []zapcore.Field {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fs := make([]zapcore.Field, 0, len(keys))
+	for _, k := range keys {
+		fs = append(fs, zap.Any(k, m[k]))
+	}
+	return fs
+}
+
+// SetGlobalFields 替换添加到每条日志的全局字段，如服务名、环境、区域、版本，
+// 对所有派生的logger立即生效
+func (log *Log) SetGlobalFields(fields ...zap.Field) {
+	if log.globals != nil {
+		log.globals.store(fields)
+	}
+}
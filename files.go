@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogFile 日志文件或切割出的备份文件
+type LogFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	// Current 正在写入的文件，清理时不会删除
+	Current bool `json:"current"`
+}
+
+// logSource 一个配置的日志文件及其切割文件的glob
+type logSource struct {
+	filename string
+	globs    []string
+	current  string
+}
+
+// logSources 按配置返回InfoFilename、ErrorFilename和访问日志，不需要创建Log，用于离线查看和清理
+func (c *LogOptions) logSources() ([]logSource, error) {
+	var names []string
+	if c.InfoFilename != "" {
+		names = append(names, c.InfoFilename)
+	}
+	if c.LevelSeparate && c.ErrorFilename != "" {
+		names = append(names, c.ErrorFilename)
+	}
+	if c.AccessLog.Filename != "" {
+		names = append(names, c.AccessLog.Filename)
+	}
+
+	keep := c.logFilenames()
+	var sources []logSource
+	for _, name := range names {
+		s := logSource{filename: c.resolvePlaceholders(name)}
+		s.current = s.filename
+		switch c.Division {
+		case SizeDivision:
+			glob, err := c.sizeBackupGlob(s.filename)
+			if err != nil {
+				return nil, err
+			}
+			s.globs = compressedGlobs(glob)
+		case TimeDivision, HybridDivision:
+			pattern, err := c.timePattern(s.filename)
+			if err != nil {
+				return nil, err
+			}
+			glob := _strftimeVerb.ReplaceAllString(pattern, "*")
+			s.globs = compressedGlobs(glob)
+			// 按时间切割时最新的未压缩文件正在写入
+			var newest time.Time
+			for _, f := range backupFiles([]string{glob}, s.others(keep)) {
+				if f.ModTime.After(newest) {
+					s.current, newest = f.Path, f.ModTime
+				}
+			}
+		}
+		sources = append(sources, s)
+	}
+	return sources, nil
+}
+
+// others 返回keep中除本文件以外的日志文件，它们可能与本文件的glob匹配，但不属于本文件
+func (s logSource) others(keep []string) map[string]bool {
+	others := keepSet(keep)
+	delete(others, s.filename)
+	return others
+}
+
+// backupFiles 返回与globs匹配的普通文件，跳过seen中已有的文件和rotatelogs的临时文件
+func backupFiles(globs []string, seen map[string]bool) []LogFile {
+	if seen == nil {
+		seen = make(map[string]bool)
+	}
+	var files []LogFile
+	for _, glob := range globs {
+		matches, _ := filepath.Glob(glob)
+		for _, path := range matches {
+			if seen[path] || strings.HasSuffix(path, "_lock") || strings.HasSuffix(path, "_symlink") {
+				continue
+			}
+			seen[path] = true
+			if info, err := os.Lstat(path); err == nil && info.Mode().IsRegular() {
+				files = append(files, LogFile{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+			}
+		}
+	}
+	return files
+}
+
+// LogFiles 按配置列出日志文件及切割出的备份文件，每个日志文件的备份按修改时间排序，不需要创建Log
+func (c *LogOptions) LogFiles() ([]LogFile, error) {
+	sources, err := c.logSources()
+	if err != nil {
+		return nil, err
+	}
+	keep := c.logFilenames()
+	var files []LogFile
+	seen := make(map[string]bool)
+	for _, s := range sources {
+		skip := s.others(keep)
+		for path := range seen {
+			skip[path] = true
+		}
+		found := backupFiles(append([]string{s.filename}, s.globs...), skip)
+		for _, f := range found {
+			seen[f.Path] = true
+		}
+		sort.SliceStable(found, func(i, j int) bool {
+			return found[i].ModTime.Before(found[j].ModTime)
+		})
+		for i := range found {
+			found[i].Current = found[i].Path == s.current
+		}
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+// Prune 立即按MaxBackups、MaxAge、MaxTotalSize清理切割出的文件，规则与切割时相同，返回删除的文件和目录。
+// 正在写入的文件不会删除，可以在日志写入的同时执行
+func (c *LogOptions) Prune() ([]string, error) {
+	sources, err := c.logSources()
+	if err != nil {
+		return nil, err
+	}
+	var retention *retention
+	if c.MaxTotalSize > 0 {
+		retention = newRetention(c.MaxTotalSize, c.logFilenames())
+	}
+	now := c.getClock().Now()
+	var removed []string
+	for _, s := range sources {
+		if len(s.globs) == 0 {
+			continue
+		}
+		current := s.current
+		active := func() string { return current }
+		maxBackups := c.MaxBackups
+		if c.Division != SizeDivision {
+			// 按时间切割时只按MaxAge清理
+			maxBackups = 0
+		}
+		removed = append(removed, pruneBackups(s.globs, active, c.logFilenames(), maxBackups, c.MaxAge, now)...)
+		if c.Division != SizeDivision && c.DayDirectory && c.RotatePattern == "" {
+			loc := c.location()
+			removed = append(removed, pruneDayDirs(filepath.Dir(s.filename), c.MaxAge, active, now, loc)...)
+		}
+		retention.add(active, s.globs...)
+	}
+	if retention != nil {
+		removed = append(removed, retention.prune()...)
+	}
+	return removed, nil
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"fmt"
+	"path"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 可以配置FieldFilters和EntryFilters的输出
+const (
+	OutputConsole       = "console"
+	OutputFile          = "file"
+	OutputAlert         = "alert"
+	OutputEmail         = "email"
+	OutputIncident      = "incident"
+	OutputErrorReporter = "error_reporter"
+	OutputSQLite        = "sqlite"
+	OutputDatabase      = "database"
+)
+
+// FieldFilter 限制一个输出可以输出的字段，字段名支持path.Match通配符，如 "user_*"
+type FieldFilter struct {
+	// Allow 只输出这些字段，为空时输出所有字段
+	Allow []string `toml:"allow" yaml:"allow" json:"allow"`
+	// Deny 不输出这些字段，优先于Allow
+	Deny []string `toml:"deny" yaml:"deny" json:"deny"`
+}
+
+func matchAny(patterns []string, key string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f FieldFilter) allowed(key string) bool {
+	if matchAny(f.Deny, key) {
+		return false
+	}
+	return len(f.Allow) == 0 || matchAny(f.Allow, key)
+}
+
+// filter 返回过滤后的fields，没有被过滤的字段时返回原slice
+func (f FieldFilter) filter(fs []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, field := range fs {
+		// namespace及sentry指纹等内部字段不参与过滤
+		keep := field.Type == zapcore.NamespaceType || field.Type == zapcore.SkipType || f.allowed(field.Key)
+		if keep {
+			if out != nil {
+				out = append(out, field)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, i, len(fs))
+			copy(out, fs[:i])
+		}
+	}
+	if out == nil {
+		return fs
+	}
+	return out
+}
+
+// filterOutput 按FieldFilters和EntryFilters中name对应的配置过滤core的字段和日志，未配置时返回原core
+func (c *LogOptions) filterOutput(name string, core zapcore.Core) zapcore.Core {
+	if f, ok := c.FieldFilters[name]; ok && (len(f.Allow) > 0 || len(f.Deny) > 0) {
+		core = newTransformCore(core, nil, f.filter)
+	}
+	if f, ok := c.EntryFilters[name]; ok && (len(f.Drop) > 0 || len(f.Keep) > 0) {
+		ef, err := newEntryFilter(f)
+		if err != nil {
+			fmt.Println(err)
+			return core
+		}
+		core = &predicateCore{Core: core, filter: ef}
+	}
+	return core
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	_defaultDirMode  os.FileMode = 0755
+	_defaultFileMode os.FileMode = 0644
+)
+
+// parseFileMode 解析八进制权限字符串，如 "0750"，为空时返回def
+func parseFileMode(s string, def os.FileMode) (os.FileMode, error) {
+	if s == "" {
+		return def, nil
+	}
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("logger: invalid file mode %q: %v", s, err)
+	}
+	return os.FileMode(mode), nil
+}
+
+func (c *LogOptions) dirMode() (os.FileMode, error) {
+	return parseFileMode(c.DirMode, _defaultDirMode)
+}
+
+func (c *LogOptions) fileMode() (os.FileMode, error) {
+	return parseFileMode(c.FileMode, _defaultFileMode)
+}
+
+// prepareLogFile 创建日志文件所在的目录，create为true时按FileMode预先创建日志文件，
+// lumberjack切割后新建的文件会沿用该文件的权限
+func (c *LogOptions) prepareLogFile(filename string, create bool) error {
+	dirMode, err := c.dirMode()
+	if err != nil {
+		return err
+	}
+	fileMode, err := c.fileMode()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return fmt.Errorf("logger: create log directory %s: %v", dir, err)
+	}
+	if !create {
+		return nil
+	}
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, fileMode)
+	if err != nil {
+		return fmt.Errorf("logger: create log file %s: %v", filename, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// goroutineID 从runtime.Stack的第一行 "goroutine 123 [running]:" 中解析当前goroutine的ID
+func goroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
+
+// addGoroutineID 写入时加上goroutine字段，日志在调用方的goroutine中同步写入
+func addGoroutineID(ent *zapcore.Entry, fs []zapcore.Field) []zapcore.Field {
+	return append(fs[:len(fs):len(fs)], zap.Int64("goroutine", goroutineID()))
+}
+
+type workerKey struct{}
+
+// WithWorker 在ctx中设置worker标签，通过Log.Ctx输出的日志会带上worker字段，
+// 用于区分并发执行的任务交错输出的日志
+func WithWorker(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, workerKey{}, label)
+}
+
+// WorkerFrom 返回ctx中的worker标签
+func WorkerFrom(ctx context.Context) (string, bool) {
+	label, ok := ctx.Value(workerKey{}).(string)
+	return label, ok
+}
+
+// Ctx 返回带有ctx中请求ID、链路信息、worker标签和RegisterFieldProvider注册的字段的Log
+func (log *Log) Ctx(ctx context.Context) *Log {
+	fields := contextFields(ctx)
+	if len(fields) == 0 {
+		return log
+	}
+	clone := *log
+	clone.L = log.L.With(fields...)
+	return &clone
+}
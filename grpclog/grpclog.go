@@ -0,0 +1,81 @@
+// Package grpclog 提供在gRPC调用之间生成和透传请求ID的拦截器，请求ID保存在context中，
+// 通过 logger.Log.Ctx 输出的日志会带上request_id字段
+//
+//	grpc.NewServer(grpc.ChainUnaryInterceptor(grpclog.UnaryServerInterceptor()))
+//	grpc.Dial(addr, grpc.WithChainUnaryInterceptor(grpclog.UnaryClientInterceptor()))
+package grpclog
+
+import (
+	"context"
+
+	"github.com/mae-pax/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDKey 请求ID使用的metadata key，对应HTTP的 X-Request-ID
+const RequestIDKey = "x-request-id"
+
+// serverContext 从metadata读取请求ID，没有时生成，保存在ctx中并通过响应header返回
+func serverContext(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(RequestIDKey); len(ids) > 0 {
+			id = ids[0]
+		}
+	}
+	if id == "" {
+		id = logger.NewRequestID()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, id))
+	return logger.WithRequestID(ctx, id)
+}
+
+// clientContext 把ctx中的请求ID写入出站metadata，已有时不覆盖
+func clientContext(ctx context.Context) context.Context {
+	id, ok := logger.RequestIDFrom(ctx)
+	if !ok {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(RequestIDKey)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, RequestIDKey, id)
+}
+
+// UnaryServerInterceptor 读取或生成请求ID并保存在handler的ctx中
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(serverContext(ctx), req)
+	}
+}
+
+// StreamServerInterceptor 读取或生成请求ID并保存在stream的ctx中
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: serverContext(ss.Context())})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// UnaryClientInterceptor 把ctx中的请求ID透传给下游服务
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(clientContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor 把ctx中的请求ID透传给下游服务
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(clientContext(ctx), desc, cc, method, opts...)
+	}
+}
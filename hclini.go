@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v2"
+)
+
+// 配置格式，用于NewFromBytes和NewFromReader
+const (
+	FormatHCL = "hcl"
+	FormatINI = "ini"
+)
+
+var _logOptionsType = reflect.TypeOf(LogOptions{})
+
+// NewFromHCL 读取HCL配置，嵌套配置使用块，如 sentry_config { dsn = "..." }，profile及环境变量见NewFromYaml
+//
+//	encoding = "json"
+//	info_filename = "./logs/server.log"
+//
+//	sentry_config {
+//	  dsn = "${SENTRY_DSN}"
+//	}
+//
+//	alerts {
+//	  url = "..."
+//	}
+func NewFromHCL(confPath string) *LogOptions {
+	return newFromFile(confPath, FormatHCL, "")
+}
+
+// NewFromINI 读取INI配置，顶层配置写在第一个section之前，嵌套配置使用以 "." 连接的section，
+// 配置列表的元素使用序号，字符串列表以 "," 分隔，profile及环境变量见NewFromYaml
+//
+//	encoding = json
+//	metadata = hostname, pod
+//
+//	[sentry_config]
+//	dsn = ${SENTRY_DSN}
+//
+//	[sentry_config.tags]
+//	team = infra
+//
+//	[alerts.0]
+//	url = ...
+func NewFromINI(confPath string) *LogOptions {
+	return newFromFile(confPath, FormatINI, "")
+}
+
+// hclToYaml 把HCL配置转换为yaml
+func hclToYaml(b []byte) ([]byte, error) {
+	var m map[string]interface{}
+	if err := hcl.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(normalizeOptions(m))
+}
+
+// iniToYaml 把INI配置转换为yaml
+func iniToYaml(b []byte) ([]byte, error) {
+	f, err := ini.Load(b)
+	if err != nil {
+		return nil, err
+	}
+	root := map[string]interface{}{}
+	for _, s := range f.Sections() {
+		m := root
+		if s.Name() != ini.DefaultSection {
+			for _, part := range strings.Split(s.Name(), ".") {
+				child, ok := m[part].(map[string]interface{})
+				if !ok {
+					child = map[string]interface{}{}
+					m[part] = child
+				}
+				m = child
+			}
+		}
+		for _, k := range s.Keys() {
+			m[k.Name()] = k.String()
+		}
+	}
+	return yaml.Marshal(normalizeOptions(root))
+}
+
+// normalizeOptions 按LogOptions的结构整理配置，profiles和loggers下的每个配置同样按LogOptions整理
+func normalizeOptions(m map[string]interface{}) interface{} {
+	for _, key := range []string{"profiles", "loggers"} {
+		if sections, ok := unwrapBlock(m[key]).(map[string]interface{}); ok {
+			for name, section := range sections {
+				sections[name] = normalizeConfig(unwrapBlock(section), _logOptionsType)
+			}
+			m[key] = sections
+		}
+	}
+	return normalizeConfig(m, _logOptionsType)
+}
+
+// unwrapBlock HCL的块解码为 []map[string]interface{}，只有一个块时取出该块
+func unwrapBlock(v interface{}) interface{} {
+	if blocks, ok := v.([]map[string]interface{}); ok && len(blocks) == 1 {
+		return blocks[0]
+	}
+	return v
+}
+
+// normalizeConfig 按目标类型t整理HCL、INI解码的值：取出结构体和map的唯一块，把序号section转换为列表，
+// 把INI的字符串转换为数字、布尔值和字符串列表，使其能按yaml解码
+func normalizeConfig(v interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := unwrapBlock(v).(map[string]interface{})
+		if !ok {
+			return v
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if e, ok := m[yamlName(f)]; ok && f.PkgPath == "" {
+				m[yamlName(f)] = normalizeConfig(e, f.Type)
+			}
+		}
+		return m
+	case reflect.Map:
+		m, ok := unwrapBlock(v).(map[string]interface{})
+		if !ok {
+			return v
+		}
+		for k, e := range m {
+			m[k] = normalizeConfig(e, t.Elem())
+		}
+		return m
+	case reflect.Slice:
+		var items []interface{}
+		switch v := v.(type) {
+		case []map[string]interface{}:
+			for _, e := range v {
+				items = append(items, e)
+			}
+		case []interface{}:
+			items = v
+		case map[string]interface{}:
+			// INI的 [alerts.0]、[alerts.1] 按序号排列
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				a, _ := strconv.Atoi(keys[i])
+				b, _ := strconv.Atoi(keys[j])
+				return a < b
+			})
+			for _, k := range keys {
+				items = append(items, v[k])
+			}
+		case string:
+			if t.Elem().Kind() != reflect.String {
+				return v
+			}
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			return items
+		default:
+			return v
+		}
+		for i, e := range items {
+			items[i] = normalizeConfig(e, t.Elem())
+		}
+		return items
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		// INI的值都是字符串，按yaml解析为对应的类型
+		if s, ok := v.(string); ok {
+			var scalar interface{}
+			if err := yaml.Unmarshal([]byte(s), &scalar); err == nil && scalar != nil {
+				return scalar
+			}
+		}
+	}
+	return v
+}
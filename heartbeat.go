@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// HeartbeatConfig 定时输出心跳日志，日志监控可以据此发现没有输出或卡住的服务
+type HeartbeatConfig struct {
+	// Interval 输出间隔(秒)，0不输出
+	Interval int `toml:"interval" yaml:"interval" json:"interval"`
+	// Sink 心跳的输出，为空时以Info级别写入日志，也可以是 "stderr"、"stdout" 或文件路径，此时以json格式单独写入
+	Sink string `toml:"sink" yaml:"sink" json:"sink"`
+	// Message 心跳日志的消息，默认 "heartbeat"
+	Message string `toml:"message" yaml:"message" json:"message"`
+}
+
+// heartbeat 定时输出进程运行时间、运行时状态以及各级别日志条数
+type heartbeat struct {
+	logger  *zap.Logger
+	metrics *metrics
+	message string
+	start   time.Time
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// startHeartbeat 按cfg启动心跳，未配置Interval时返回nil
+func (c *LogOptions) startHeartbeat(cfg HeartbeatConfig, logger *zap.Logger) *heartbeat {
+	if cfg.Interval <= 0 {
+		return nil
+	}
+	if cfg.Sink != "" {
+		w, err := c.fallbackWriter(cfg.Sink)
+		if err != nil {
+			panic(err)
+		}
+		ec := zap.NewProductionEncoderConfig()
+		ec.EncodeTime = c.encodeTime
+		logger = zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(ec), zapcore.AddSync(w), zapcore.InfoLevel))
+	}
+	h := &heartbeat{
+		logger:  logger,
+		metrics: c.metrics,
+		message: cfg.Message,
+		start:   time.Now(),
+		stop:    make(chan struct{}),
+	}
+	if h.message == "" {
+		h.message = "heartbeat"
+	}
+	go h.run(time.Duration(cfg.Interval) * time.Second)
+	return h
+}
+
+func (h *heartbeat) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			h.beat()
+		case <-h.stop:
+			return
+		}
+	}
+}
+
+func (h *heartbeat) beat() {
+	h.logger.Info(h.message,
+		zap.Duration("uptime", time.Since(h.start)),
+		WithRuntimeStats(),
+		zap.Object("entries", h.metrics),
+	)
+}
+
+func (h *heartbeat) close() {
+	if h != nil {
+		h.once.Do(func() { close(h.stop) })
+	}
+}
+
+// StopHeartbeat 停止心跳，Reload不会重新启动心跳
+func (log *Log) StopHeartbeat() {
+	log.heartbeat.close()
+}
+
+// levelCounter 各级别日志条数，供心跳输出
+type levelCounter struct {
+	counts sync.Map // zapcore.Level -> *uint64
+}
+
+func (lc *levelCounter) inc(level zapcore.Level) {
+	v, ok := lc.counts.Load(level)
+	if !ok {
+		v, _ = lc.counts.LoadOrStore(level, new(uint64))
+	}
+	atomic.AddUint64(v.(*uint64), 1)
+}
+
+// MarshalLogObject 以级别名称为key输出日志条数
+func (m *metrics) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if m == nil {
+		return nil
+	}
+	m.levels.counts.Range(func(k, v interface{}) bool {
+		level := k.(zapcore.Level)
+		name, ok := levelName(level)
+		if !ok {
+			name = level.String()
+		}
+		enc.AddUint64(name, atomic.LoadUint64(v.(*uint64)))
+		return true
+	})
+	return nil
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// EntryHook 每条实际写入的日志都会调用，fields包含With添加的字段
+type EntryHook func(ent zapcore.Entry, fields []zapcore.Field) error
+
+// entryHooks AddHook注册的回调
+type entryHooks struct {
+	mu  sync.Mutex
+	fns atomic.Value // []EntryHook
+}
+
+func (h *entryHooks) load() []EntryHook {
+	fns, _ := h.fns.Load().([]EntryHook)
+	return fns
+}
+
+func (h *entryHooks) add(fn EntryHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fns := h.load()
+	h.fns.Store(append(fns[:len(fns):len(fns)], fn))
+}
+
+// hookCore 日志通过级别过滤并写入后调用注册的回调
+type hookCore struct {
+	zapcore.Core
+	hooks  *entryHooks
+	fields []zapcore.Field
+}
+
+func (c *hookCore) With(fs []zapcore.Field) zapcore.Core {
+	return &hookCore{
+		Core:   c.Core.With(fs),
+		hooks:  c.hooks,
+		fields: append(c.fields[:len(c.fields):len(c.fields)], fs...),
+	}
+}
+
+func (c *hookCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	// 没有回调时直接交给内部core，不增加开销
+	if len(c.hooks.load()) == 0 {
+		return c.Core.Check(ent, ce)
+	}
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *hookCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	inner := c.Core.Check(ent, nil)
+	if inner == nil {
+		return nil
+	}
+	inner.ErrorOutput = errorOutput{}
+	inner.Write(fs...)
+
+	fields := append(c.fields[:len(c.fields):len(c.fields)], fs...)
+	for _, fn := range c.hooks.load() {
+		if err := fn(ent, fields); err != nil {
+			handleError(fmt.Errorf("logger: entry hook failed: %v", err))
+		}
+	}
+	return nil
+}
+
+// AddHook 注册回调，每条通过级别过滤并写入的日志都会同步调用，可用于自定义计数、转发或触发逻辑，
+// 回调返回的错误交给SetErrorHandler处理
+func (log *Log) AddHook(fn func(zapcore.Entry, []zapcore.Field) error) {
+	if log.hooks != nil {
+		log.hooks.add(fn)
+	}
+}
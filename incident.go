@@ -0,0 +1,272 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 事故平台类型
+const (
+	IncidentPagerDuty = "pagerduty"
+	IncidentOpsgenie  = "opsgenie"
+)
+
+const (
+	_pagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+	_opsgenieURL  = "https://api.opsgenie.com/v2/alerts"
+)
+
+// IncidentConfig 出现fatal级别的日志时通过PagerDuty Events API v2或Opsgenie创建事故
+type IncidentConfig struct {
+	// Provider 可选 "pagerduty"、"opsgenie"
+	Provider string `toml:"provider" yaml:"provider" json:"provider"`
+	// RoutingKey PagerDuty的integration key或Opsgenie的API key，未匹配到Services时使用
+	RoutingKey string `toml:"routing_key" yaml:"routing_key" json:"routing_key"`
+	// Services 按服务名配置不同的RoutingKey，服务名取ServiceField字段的值，没有该字段时取日志名称
+	Services map[string]string `toml:"services" yaml:"services" json:"services"`
+	// ServiceField 服务名字段，默认 "service"
+	ServiceField string `toml:"service_field" yaml:"service_field" json:"service_field"`
+	// Level 创建事故的最低级别，默认 "fatal"
+	Level string `toml:"level" yaml:"level" json:"level"`
+	// DedupFields 参与生成去重key的字段，WithFingerprint优先，默认只使用日志名称和消息
+	DedupFields []string `toml:"dedup_fields" yaml:"dedup_fields" json:"dedup_fields"`
+	// Source 事故来源，默认为主机名
+	Source string `toml:"source" yaml:"source" json:"source"`
+	// URL 覆盖默认的API地址，如Opsgenie EU区域 https://api.eu.opsgenie.com/v2/alerts
+	URL string `toml:"url" yaml:"url" json:"url"`
+}
+
+// _incidentFlushTimeout 写入fatal日志及Sync时等待事故创建请求发送完成的最长时间
+const _incidentFlushTimeout = 5 * time.Second
+
+// incidentCore 实现zapcore.Core，达到级别的日志交给httpQueue在后台创建事故
+type incidentCore struct {
+	zapcore.LevelEnabler
+	cfg         IncidentConfig
+	queue       *httpQueue
+	fields      map[string]interface{}
+	fingerprint []string
+}
+
+func newIncidentCore(cfg IncidentConfig) (*incidentCore, error) {
+	switch cfg.Provider {
+	case IncidentPagerDuty, IncidentOpsgenie:
+	default:
+		return nil, fmt.Errorf("logger: unknown incident provider %q", cfg.Provider)
+	}
+	if cfg.RoutingKey == "" && len(cfg.Services) == 0 {
+		return nil, fmt.Errorf("logger: %s routing key is empty", cfg.Provider)
+	}
+	level := zapcore.FatalLevel
+	if cfg.Level != "" {
+		if err := unmarshalLevel(&level, cfg.Level); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ServiceField == "" {
+		cfg.ServiceField = "service"
+	}
+	if cfg.Source == "" {
+		cfg.Source, _ = os.Hostname()
+	}
+	if cfg.URL == "" {
+		cfg.URL = _pagerDutyURL
+		if cfg.Provider == IncidentOpsgenie {
+			cfg.URL = _opsgenieURL
+		}
+	}
+	return &incidentCore{
+		LevelEnabler: level,
+		cfg:          cfg,
+		fields:       make(map[string]interface{}),
+	}, nil
+}
+
+func (c *incidentCore) With(fs []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = mergeFields(c.fields, fs)
+	for _, f := range fs {
+		if parts, ok := fingerprintOf(f); ok {
+			clone.fingerprint = parts
+		}
+	}
+	return &clone
+}
+
+func (c *incidentCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *incidentCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	fields := mergeFields(c.fields, fs)
+	fingerprint := c.fingerprint
+	for _, f := range fs {
+		if parts, ok := fingerprintOf(f); ok {
+			fingerprint = parts
+		}
+	}
+
+	service := ent.LoggerName
+	if v, ok := fields[c.cfg.ServiceField]; ok {
+		service = fmt.Sprint(v)
+	}
+	key := c.cfg.RoutingKey
+	if k, ok := c.cfg.Services[service]; ok {
+		key = k
+	}
+	if key == "" {
+		return nil
+	}
+
+	var req *http.Request
+	var err error
+	if c.cfg.Provider == IncidentOpsgenie {
+		req, err = c.opsgenieRequest(ent, fields, service, key, c.dedupKey(ent, fields, fingerprint))
+	} else {
+		req, err = c.pagerDutyRequest(ent, fields, service, key, c.dedupKey(ent, fields, fingerprint))
+	}
+	if err != nil {
+		return err
+	}
+	c.queue.send(req)
+	// fatal之后进程立即退出，等待发送完成
+	if ent.Level > zapcore.ErrorLevel {
+		c.queue.wait(_incidentFlushTimeout)
+	}
+	return nil
+}
+
+// dedupKey 相同key的事故由平台合并，避免重复创建
+func (c *incidentCore) dedupKey(ent zapcore.Entry, fields map[string]interface{}, fingerprint []string) string {
+	parts := fingerprint
+	if len(parts) == 0 {
+		parts = []string{ent.LoggerName, ent.Message}
+		for _, k := range c.cfg.DedupFields {
+			if v, ok := fields[k]; ok {
+				parts = append(parts, fmt.Sprint(v))
+			}
+		}
+	}
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *incidentCore) pagerDutyRequest(ent zapcore.Entry, fields map[string]interface{}, service, key, dedup string) (*http.Request, error) {
+	severity := "critical"
+	switch {
+	case ent.Level < zapcore.WarnLevel:
+		severity = "info"
+	case ent.Level == zapcore.WarnLevel:
+		severity = "warning"
+	case ent.Level == zapcore.ErrorLevel:
+		severity = "error"
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"routing_key":  key,
+		"event_action": "trigger",
+		"dedup_key":    dedup,
+		"payload": map[string]interface{}{
+			"summary":        truncate(ent.Message, 1024),
+			"source":         c.cfg.Source,
+			"severity":       severity,
+			"timestamp":      ent.Time.Format(time.RFC3339),
+			"component":      service,
+			"class":          ent.Level.String(),
+			"custom_details": incidentDetails(ent, fields),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func (c *incidentCore) opsgenieRequest(ent zapcore.Entry, fields map[string]interface{}, service, key, dedup string) (*http.Request, error) {
+	priority := "P1"
+	if ent.Level < zapcore.DPanicLevel {
+		priority = "P3"
+	}
+	details := make(map[string]string)
+	for k, v := range incidentDetails(ent, fields) {
+		details[k] = fmt.Sprint(v)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"message":     truncate(ent.Message, 130),
+		"alias":       dedup,
+		"description": ent.Message,
+		"source":      c.cfg.Source,
+		"entity":      service,
+		"priority":    priority,
+		"details":     details,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+key)
+	return req, nil
+}
+
+func incidentDetails(ent zapcore.Entry, fields map[string]interface{}) map[string]interface{} {
+	details := make(map[string]interface{}, len(fields)+3)
+	for k, v := range fields {
+		details[k] = v
+	}
+	details["level"] = ent.Level.String()
+	if ent.Caller.Defined {
+		details["caller"] = ent.Caller.TrimmedPath()
+	}
+	if ent.Stack != "" {
+		details["stacktrace"] = ent.Stack
+	}
+	return details
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+func (c *incidentCore) Sync() error {
+	c.queue.wait(_incidentFlushTimeout)
+	return nil
+}
+
+// incidentCores 根据Incidents配置生成事故core
+func (c *LogOptions) incidentCores() []zapcore.Core {
+	var cores []zapcore.Core
+	for _, cfg := range c.Incidents {
+		core, err := newIncidentCore(cfg)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		// 请求中带有按服务选择的routing key，无法由配置恢复，不暂存
+		core.queue = newHTTPQueue(sinkIncident, 100, c, nil)
+		cores = append(cores, core)
+	}
+	return cores
+}
@@ -0,0 +1,126 @@
+// Package kafkalog 把Kafka客户端库内部的日志输出到 logger.Log
+//
+// sarama使用 sarama.StdLogger 接口，直接赋值即可：
+//
+//	sarama.Logger = kafkalog.NewSaramaLogger(log, zapcore.InfoLevel)
+//
+// franz-go的 kgo.Logger 接口使用kgo定义的LogLevel类型，为避免引入kgo依赖，KgoLogger的方法使用int8，
+// 在业务代码中用下面的类型转换后传给 kgo.WithLogger：
+//
+//	type kgoLogger struct{ *kafkalog.KgoLogger }
+//
+//	func (l kgoLogger) Level() kgo.LogLevel { return kgo.LogLevel(l.KgoLogger.Level()) }
+//
+//	func (l kgoLogger) Log(level kgo.LogLevel, msg string, keyvals ...any) {
+//		l.KgoLogger.Log(int8(level), msg, keyvals...)
+//	}
+package kafkalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mae-pax/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SaramaLogger 实现 sarama.StdLogger，所有输出使用同一个级别
+type SaramaLogger struct {
+	log   *zap.Logger
+	level zapcore.Level
+}
+
+// NewSaramaLogger 创建SaramaLogger，日志带有 component=sarama 字段
+func NewSaramaLogger(log *logger.Log, level zapcore.Level) *SaramaLogger {
+	return &SaramaLogger{
+		log:   log.L.WithOptions(zap.AddCallerSkip(2)).With(zap.String("component", "sarama")),
+		level: level,
+	}
+}
+
+func (l *SaramaLogger) write(msg string) {
+	if ce := l.log.Check(l.level, strings.TrimRight(msg, "\n")); ce != nil {
+		ce.Write()
+	}
+}
+
+// Print 同fmt.Print
+func (l *SaramaLogger) Print(v ...interface{}) {
+	l.write(fmt.Sprint(v...))
+}
+
+// Printf 同fmt.Printf
+func (l *SaramaLogger) Printf(format string, v ...interface{}) {
+	l.write(fmt.Sprintf(format, v...))
+}
+
+// Println 同fmt.Println
+func (l *SaramaLogger) Println(v ...interface{}) {
+	l.write(fmt.Sprintln(v...))
+}
+
+// kgo.LogLevel 的取值
+const (
+	KgoLevelNone int8 = iota
+	KgoLevelError
+	KgoLevelWarn
+	KgoLevelInfo
+	KgoLevelDebug
+)
+
+// KgoLogger 按 kgo.Logger 的语义输出franz-go的日志，keyvals转换为日志字段
+type KgoLogger struct {
+	log   *zap.Logger
+	level int8
+}
+
+// NewKgoLogger 创建KgoLogger，level为输出的最低级别，取KgoLevel*常量，日志带有 component=kgo 字段
+func NewKgoLogger(log *logger.Log, level int8) *KgoLogger {
+	return &KgoLogger{
+		log:   log.L.WithOptions(zap.AddCallerSkip(2)).With(zap.String("component", "kgo")),
+		level: level,
+	}
+}
+
+// Level 返回输出的最低级别，kgo据此跳过不需要的日志
+func (l *KgoLogger) Level() int8 {
+	return l.level
+}
+
+// Log 输出一条日志，keyvals为交替出现的key和value
+func (l *KgoLogger) Log(level int8, msg string, keyvals ...interface{}) {
+	if level == KgoLevelNone || level > l.level {
+		return
+	}
+	ce := l.log.Check(kgoLevel(level), msg)
+	if ce == nil {
+		return
+	}
+	fields := make([]zap.Field, 0, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if i+1 == len(keyvals) {
+			fields = append(fields, zap.Any("!BADKEY", keyvals[i]))
+			break
+		}
+		if err, ok := keyvals[i+1].(error); ok {
+			fields = append(fields, zap.NamedError(key, err))
+			continue
+		}
+		fields = append(fields, zap.Any(key, keyvals[i+1]))
+	}
+	ce.Write(fields...)
+}
+
+func kgoLevel(level int8) zapcore.Level {
+	switch level {
+	case KgoLevelError:
+		return zapcore.ErrorLevel
+	case KgoLevelWarn:
+		return zapcore.WarnLevel
+	case KgoLevelInfo:
+		return zapcore.InfoLevel
+	}
+	return zapcore.DebugLevel
+}
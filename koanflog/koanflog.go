@@ -0,0 +1,53 @@
+// Package koanflog 从koanf的配置树中读取 logger.LogOptions，已经使用koanf的应用不需要再单独维护日志配置文件
+//
+//	c, err := koanflog.NewFromKoanf(k, "log")
+//	log := c.InitLoggerWith(logger.EncoderOptions{})
+package koanflog
+
+import (
+	"fmt"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/mae-pax/logger"
+	"gopkg.in/yaml.v2"
+)
+
+// NewFromKoanf 读取k中key下的配置，key为空时读取全部配置，同样支持profile及环境变量，见logger.NewFromBytes
+func NewFromKoanf(k *koanf.Koanf, key string) (*logger.LogOptions, error) {
+	if key != "" {
+		if !k.Exists(key) {
+			return nil, fmt.Errorf("logger: koanf key %q not found", key)
+		}
+		k = k.Cut(key)
+	}
+	b, err := yaml.Marshal(k.Raw())
+	if err != nil {
+		return nil, err
+	}
+	return logger.NewFromBytes(b, logger.FormatYaml)
+}
+
+// WatchProvider 支持监听变化的koanf.Provider，如file.Provider
+type WatchProvider interface {
+	koanf.Provider
+	Watch(cb func(event interface{}, err error)) error
+}
+
+// Watch 监听p，变化后用parser重新加载配置，读取key下的配置并调用fn，fn中通常重建Log并替换旧的Log
+//
+//	f := file.Provider("config.yaml")
+//	koanflog.Watch(f, yaml.Parser(), "log", func(c *logger.LogOptions, err error) { ... })
+func Watch(p WatchProvider, parser koanf.Parser, key string, fn func(*logger.LogOptions, error)) error {
+	return p.Watch(func(_ interface{}, err error) {
+		if err != nil {
+			fn(nil, err)
+			return
+		}
+		k := koanf.New(".")
+		if err := k.Load(p, parser); err != nil {
+			fn(nil, err)
+			return
+		}
+		fn(NewFromKoanf(k, key))
+	})
+}
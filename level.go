@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TraceLevel 低于Debug的级别，用于输出比Debug更详细的跟踪信息
+const TraceLevel = zapcore.DebugLevel - 1
+
+var (
+	_levelsMu   sync.RWMutex
+	_levelNames = map[zapcore.Level]string{TraceLevel: "trace"}
+)
+
+// RegisterLevel 注册自定义级别的名称，名称用于编码器输出以及配置中的级别解析，
+// level不能是zap内置的级别，注册应在InitLogger之前完成，
+// 上报sentry时按数值归入相邻的内置级别，如低于Info的级别都作为debug
+func RegisterLevel(level zapcore.Level, name string) error {
+	if level >= zapcore.DebugLevel && level <= zapcore.FatalLevel {
+		return fmt.Errorf("logger: level %d is a built-in level", level)
+	}
+	name = strings.ToLower(name)
+	var builtin zapcore.Level
+	if builtin.UnmarshalText([]byte(name)) == nil {
+		return fmt.Errorf("logger: level name %q is a built-in level", name)
+	}
+	_levelsMu.Lock()
+	defer _levelsMu.Unlock()
+	for l, n := range _levelNames {
+		if n == name && l != level {
+			return fmt.Errorf("logger: level name %q is already registered", name)
+		}
+	}
+	_levelNames[level] = name
+	return nil
+}
+
+func levelName(level zapcore.Level) (string, bool) {
+	_levelsMu.RLock()
+	defer _levelsMu.RUnlock()
+	name, ok := _levelNames[level]
+	return name, ok
+}
+
+// unmarshalLevel 解析级别名称，支持内置级别和RegisterLevel注册的级别
+func unmarshalLevel(level *zapcore.Level, text string) error {
+	name := strings.ToLower(text)
+	_levelsMu.RLock()
+	for l, n := range _levelNames {
+		if n == name {
+			_levelsMu.RUnlock()
+			*level = l
+			return nil
+		}
+	}
+	_levelsMu.RUnlock()
+	return level.UnmarshalText([]byte(text))
+}
+
+// levelEncoder 包装内置的级别编码器，自定义级别输出注册的名称，capital为true时输出大写
+func levelEncoder(inner zapcore.LevelEncoder, capital bool) zapcore.LevelEncoder {
+	return func(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+		name, ok := levelName(l)
+		if !ok {
+			inner(l, enc)
+			return
+		}
+		if capital {
+			name = strings.ToUpper(name)
+		}
+		enc.AppendString(name)
+	}
+}
+
+// Trace 输出Trace级别的日志
+func (log *Log) Trace(msg string, args ...zapcore.Field) {
+	if ce := log.L.Check(TraceLevel, msg); ce != nil {
+		ce.Write(args...)
+	}
+}
+
+func (log *Log) Tracef(format string, args ...interface{}) {
+	if !log.L.Core().Enabled(TraceLevel) {
+		return
+	}
+	if ce := log.L.Check(TraceLevel, fmt.Sprintf(format, args...)); ce != nil {
+		ce.Write()
+	}
+}
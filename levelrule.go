@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// levelRule 一条级别规则，pattern包含 "/" 时匹配调用方的包路径(包括子包)，否则以glob匹配日志名称
+type levelRule struct {
+	pattern string
+	pkg     bool
+	level   zapcore.Level
+}
+
+// levelRules 按顺序匹配的级别规则，第一个匹配的规则生效，都不匹配时使用默认级别
+type levelRules struct {
+	rules    []levelRule
+	fallback zapcore.Level
+	min, max zapcore.Level
+	pkgs     bool
+	finder   *callerFinder
+	callers  sync.Map // pc -> 包路径，本包及zap内部的帧为空
+}
+
+func newLevelRules(specs []string, fallback zapcore.Level, finder *callerFinder) (*levelRules, error) {
+	r := &levelRules{fallback: fallback, min: fallback, max: fallback, finder: finder}
+	for _, spec := range specs {
+		i := strings.LastIndexByte(spec, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("logger: invalid level rule %q, want pattern=level", spec)
+		}
+		rule := levelRule{pattern: strings.TrimSpace(spec[:i])}
+		if err := unmarshalLevel(&rule.level, strings.TrimSpace(spec[i+1:])); err != nil {
+			return nil, fmt.Errorf("logger: invalid level rule %q: %v", spec, err)
+		}
+		if rule.pattern == "*" {
+			// "*" 作为默认级别，放在其他规则之后也不影响匹配顺序
+			r.fallback = rule.level
+		} else {
+			rule.pkg = strings.Contains(rule.pattern, "/")
+			if _, err := path.Match(rule.pattern, ""); !rule.pkg && err != nil {
+				return nil, fmt.Errorf("logger: invalid level rule %q: %v", spec, err)
+			}
+			r.pkgs = r.pkgs || rule.pkg
+			r.rules = append(r.rules, rule)
+		}
+		if rule.level < r.min {
+			r.min = rule.level
+		}
+		if rule.level > r.max {
+			r.max = rule.level
+		}
+	}
+	if r.fallback < r.min {
+		r.min = r.fallback
+	}
+	if r.fallback > r.max {
+		r.max = r.fallback
+	}
+	return r, nil
+}
+
+// threshold 返回日志适用的最低级别
+func (r *levelRules) threshold(ent zapcore.Entry) zapcore.Level {
+	var pkg string
+	pkgLoaded := false
+	for _, rule := range r.rules {
+		if !rule.pkg {
+			if ok, _ := path.Match(rule.pattern, ent.LoggerName); ok {
+				return rule.level
+			}
+			continue
+		}
+		if !pkgLoaded {
+			pkg, pkgLoaded = r.callerPackage(), true
+		}
+		if pkg == rule.pattern || strings.HasPrefix(pkg, rule.pattern+"/") {
+			return rule.level
+		}
+	}
+	return r.fallback
+}
+
+// callerPackage 返回第一个不属于本包、zap及CallerSkipPackages的调用帧的包路径，按pc缓存
+func (r *levelRules) callerPackage() string {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	for _, pc := range pcs[:n] {
+		if v, ok := r.callers.Load(pc); ok {
+			if pkg := v.(string); pkg != "" {
+				return pkg
+			}
+			continue
+		}
+		var pkg string
+		if fn := runtime.FuncForPC(pc - 1); fn != nil && !r.finder.skip(fn.Name()) {
+			pkg = funcPackage(fn.Name())
+		}
+		r.callers.Store(pc, pkg)
+		if pkg != "" {
+			return pkg
+		}
+	}
+	return ""
+}
+
+// funcPackage 从 "github.com/acme/app/db.(*Repo).Find" 中取出包路径 "github.com/acme/app/db"
+func funcPackage(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
+
+// levelRouterCore 按levelRules过滤日志，内部core需要启用所有规则中的最低级别
+type levelRouterCore struct {
+	zapcore.Core
+	rules *levelRules
+}
+
+func (c *levelRouterCore) With(fs []zapcore.Field) zapcore.Core {
+	return &levelRouterCore{Core: c.Core.With(fs), rules: c.rules}
+}
+
+func (c *levelRouterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < c.rules.max && ent.Level < c.rules.threshold(ent) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
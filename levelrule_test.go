@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelRulesPrecedence(t *testing.T) {
+	r, err := newLevelRules([]string{
+		"http.*=warn",
+		"http.admin=debug",
+		"*=error",
+		"db=info",
+	}, zapcore.InfoLevel, newCallerFinder(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		logger string
+		want   zapcore.Level
+	}{
+		// 按顺序第一个匹配的规则生效
+		{"http.admin", zapcore.WarnLevel},
+		{"http.api", zapcore.WarnLevel},
+		{"db", zapcore.InfoLevel},
+		// "*" 放在其他规则之前也只作为默认级别
+		{"http", zapcore.ErrorLevel},
+		{"", zapcore.ErrorLevel},
+	} {
+		if got := r.threshold(zapcore.Entry{LoggerName: tc.logger}); got != tc.want {
+			t.Errorf("%q: threshold = %s, want %s", tc.logger, got, tc.want)
+		}
+	}
+	if r.min != zapcore.DebugLevel || r.max != zapcore.ErrorLevel {
+		t.Errorf("min, max = %s, %s, want debug, error", r.min, r.max)
+	}
+}
+
+func TestLevelRulesInvalid(t *testing.T) {
+	for _, spec := range []string{"http", "=debug", "http=loud", "[=info"} {
+		if _, err := newLevelRules([]string{spec}, zapcore.InfoLevel, newCallerFinder(nil)); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestFuncPackage(t *testing.T) {
+	for function, want := range map[string]string{
+		"github.com/acme/app/db.(*Repo).Find":   "github.com/acme/app/db",
+		"github.com/acme/app/db.Open.func1":     "github.com/acme/app/db",
+		"github.com/acme/app.v2/db.(*Repo).Get": "github.com/acme/app.v2/db",
+		"main.main":                             "main",
+	} {
+		if got := funcPackage(function); got != want {
+			t.Errorf("funcPackage(%q) = %q, want %q", function, got, want)
+		}
+	}
+}
@@ -1,25 +1,30 @@
 package logger
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/BurntSushi/toml"
-	"github.com/getsentry/sentry-go"
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 	"gopkg.in/natefinch/lumberjack.v2"
-	"gopkg.in/yaml.v2"
 )
 
 const (
-	TimeDivision = "time"
-	SizeDivision = "size"
+	TimeDivision   = "time"
+	SizeDivision   = "size"
+	HybridDivision = "hybrid"
+
+	// ModeProduction 生产模式(默认)，DPanic级别的日志不会panic
+	ModeProduction = "production"
+	// ModeDevelopment 开发模式，DPanic级别的日志输出后panic，控制台输出彩色级别
+	ModeDevelopment = "development"
 
 	_defaultEncoding = "console"
 	_defaultDivision = "size"
@@ -29,38 +34,195 @@ const (
 var (
 	_encoderNameToConstructor = map[string]func(zapcore.EncoderConfig) zapcore.Encoder{
 		"console": func(encoderConfig zapcore.EncoderConfig) zapcore.Encoder {
-			return zapcore.NewConsoleEncoder(encoderConfig)
+			return newConsoleEncoder(encoderConfig)
 		},
 		"json": func(encoderConfig zapcore.EncoderConfig) zapcore.Encoder {
 			return zapcore.NewJSONEncoder(encoderConfig)
 		},
+		EncodingECS: newECSEncoder,
 	}
 )
 
 type Log struct {
-	L *zap.Logger
+	L           *zap.Logger
+	rotateHooks *rotateHooks
+	audit       *auditLog
+	metrics     *metrics
+	globals     *globalFields
+	hooks       *entryHooks
+	observed    *observer.ObservedLogs
+	fatal       *fatalHooks
+	encoder     EncoderOptions
+	reload      *reloadRoot
+	recent      *ringBuffer
+	heartbeat   *heartbeat
+	access      *accessLog
+	maintenance *maintenance
+	console     *consoleSwitch
 }
 
 type LogOptions struct {
+	// Profile 当前使用的profile，NewFrom*Profile按ProfileEnv或该配置选择profiles下的配置覆盖顶层配置
+	Profile string `json:"profile" yaml:"profile" toml:"profile"`
+	// Mode 可选 "production"(默认)、"development"
+	Mode string `json:"mode" yaml:"mode" toml:"mode"`
 	// Encoding sets the logger's encoding. Valid values are "json" and
 	// "console", as well as any third-party encodings registered via
 	// RegisterEncoder.
-	Encoding      string             `json:"encoding,omitempty" yaml:"encoding,omitempty" toml:"encoding,omitempty"`
-	InfoFilename  string             `json:"info_filename" yaml:"info_filename" toml:"info_filename"`
-	ErrorFilename string             `json:"error_filename" yaml:"error_filename" toml:"error_filename"`
-	MaxSize       int                `json:"max_size" yaml:"max_size" toml:"max_size"`
-	MaxBackups    int                `json:"max_backups" yaml:"max_backups" toml:"max_backups"`
-	MaxAge        int                `json:"max_age" yaml:"max_age" toml:"max_age"`
-	Compress      bool               `json:"compress" yaml:"compress" toml:"compress"`
-	Division      string             `json:"division" yaml:"division" toml:"division"`
-	LevelSeparate bool               `json:"level_separate" yaml:"level_separate" toml:"level_separate"`
-	TimeUnit      TimeUnit           `json:"time_unit" yaml:"time_unit" toml:"time_unit"`
-	Stacktrace    bool               `json:"stacktrace" yaml:"stacktrace" toml:"stacktrace"`
-	SentryConfig  SentryLoggerConfig `json:"sentry_config" yaml:"sentry_config" toml:"sentry_config"`
-	Level         int8               `json:"level" yaml:"level" toml:"level"`
-	CloseDisplay  int                `json:"close_display" yaml:"close_display" toml:"close_display"`
+	Encoding string `json:"encoding,omitempty" yaml:"encoding,omitempty" toml:"encoding,omitempty"`
+	// Encoder 输出的字段名和各项的编码格式，用于匹配ECS等公司规范
+	Encoder EncoderConfig `json:"encoder" yaml:"encoder" toml:"encoder"`
+	// TimeLayout 所有输出使用的时间格式，可以是Go时间格式(如 "2006-01-02 15:04:05.000")或预设格式
+	// "iso8601"、"rfc3339"、"rfc3339nano"、"epoch"、"epoch_millis"、"epoch_nanos"，覆盖InitLogger的参数
+	TimeLayout string `json:"time_layout" yaml:"time_layout" toml:"time_layout"`
+	// InfoFilename、ErrorFilename 可以包含 {hostname} {pid} {service} {env} 占位符，创建Log时替换
+	InfoFilename  string   `json:"info_filename" yaml:"info_filename" toml:"info_filename"`
+	ErrorFilename string   `json:"error_filename" yaml:"error_filename" toml:"error_filename"`
+	MaxSize       int      `json:"max_size" yaml:"max_size" toml:"max_size"`
+	MaxBackups    int      `json:"max_backups" yaml:"max_backups" toml:"max_backups"`
+	MaxAge        int      `json:"max_age" yaml:"max_age" toml:"max_age"`
+	Compress      bool     `json:"compress" yaml:"compress" toml:"compress"`
+	Division      string   `json:"division" yaml:"division" toml:"division"`
+	LevelSeparate bool     `json:"level_separate" yaml:"level_separate" toml:"level_separate"`
+	TimeUnit      TimeUnit `json:"time_unit" yaml:"time_unit" toml:"time_unit"`
+	// RotationCron 标准5段cron表达式(如 "0 0 * * *", "*/15 * * * *")，
+	// 设置后按cron触发时间切割日志，取代TimeUnit的固定切割间隔
+	RotationCron string `json:"rotation_cron" yaml:"rotation_cron" toml:"rotation_cron"`
+	Stacktrace   bool   `json:"stacktrace" yaml:"stacktrace" toml:"stacktrace"`
+	// LevelRules 按调用方的包路径或日志名称设置级别，格式为 "pattern=level"，按顺序匹配，第一个匹配的规则生效，
+	// pattern包含 "/" 时匹配包路径(包括子包)，如 "github.com/acme/app/db=debug"，否则以glob匹配日志名称，如 "http.*=warn"，
+	// "*=info" 设置其他日志的级别，未设置时使用Level
+	LevelRules []string `json:"level_rules" yaml:"level_rules" toml:"level_rules"`
+	// CallerAuto 输出调用位置，并自动跳过本包和CallerSkipPackages中的帧，封装了Log的包不需要设置跳过的层数
+	CallerAuto bool `json:"caller_auto" yaml:"caller_auto" toml:"caller_auto"`
+	// CallerSkipPackages CallerAuto时跳过的封装包路径，如 "github.com/acme/app/log"，包含子包
+	CallerSkipPackages []string `json:"caller_skip_packages" yaml:"caller_skip_packages" toml:"caller_skip_packages"`
+	// StacktraceLevel 输出调用栈的最低级别，默认 "warn"，Stacktrace为true时生效
+	StacktraceLevel string `json:"stacktrace_level" yaml:"stacktrace_level" toml:"stacktrace_level"`
+	// StacktraceSkipFrames 跳过调用栈顶部的帧数
+	StacktraceSkipFrames int `json:"stacktrace_skip_frames" yaml:"stacktrace_skip_frames" toml:"stacktrace_skip_frames"`
+	// StacktraceMaxFrames 调用栈最多保留的帧数，0表示不限制
+	StacktraceMaxFrames int                `json:"stacktrace_max_frames" yaml:"stacktrace_max_frames" toml:"stacktrace_max_frames"`
+	SentryConfig        SentryLoggerConfig `json:"sentry_config" yaml:"sentry_config" toml:"sentry_config"`
+	// Alerts 将warn及以上级别的日志推送到Slack、Discord、钉钉等webhook
+	Alerts []AlertConfig `json:"alerts" yaml:"alerts" toml:"alerts"`
+	// Emails 通过SMTP发送fatal、panic级别的日志，附带调用栈和最近的日志
+	Emails []EmailConfig `json:"emails" yaml:"emails" toml:"emails"`
+	// Incidents 出现fatal级别的日志时在PagerDuty或Opsgenie创建事故
+	Incidents []IncidentConfig `json:"incidents" yaml:"incidents" toml:"incidents"`
+	// Redact 字段脱敏规则
+	Redact []RedactRule `json:"redact" yaml:"redact" toml:"redact"`
+	// Scrub 按正则替换日志消息和字符串字段中的敏感信息，ScrubDefaults为true时同时启用DefaultScrubRules
+	Scrub         []ScrubRule `json:"scrub" yaml:"scrub" toml:"scrub"`
+	ScrubDefaults bool        `json:"scrub_defaults" yaml:"scrub_defaults" toml:"scrub_defaults"`
+	// FieldFilters 按输出限制可以输出的字段，key为 "console"、"file"、"alert"、"email"、"incident"、"error_reporter"、"sqlite"、"database"
+	FieldFilters map[string]FieldFilter `json:"field_filters" yaml:"field_filters" toml:"field_filters"`
+	// EntryFilters 按输出配置字段条件过滤日志，key同FieldFilters
+	EntryFilters map[string]EntryFilter `json:"entry_filters" yaml:"entry_filters" toml:"entry_filters"`
+	// MaxMessageSize、MaxFieldSize、MaxEntrySize 日志消息、单个字段、整条日志的最大字节数，
+	// 超过时截断并加上省略号，同时增加 truncated=true 字段，0不限制
+	MaxMessageSize int `json:"max_message_size" yaml:"max_message_size" toml:"max_message_size"`
+	MaxFieldSize   int `json:"max_field_size" yaml:"max_field_size" toml:"max_field_size"`
+	MaxEntrySize   int `json:"max_entry_size" yaml:"max_entry_size" toml:"max_entry_size"`
+	// Encryption 加密写入日志文件，使用Decrypt、DecryptFile解密，
+	// 同时配置了Failover时写入file的备用输出的同样是加密后的记录
+	Encryption EncryptionConfig `json:"encryption" yaml:"encryption" toml:"encryption"`
+	// MetricsNamespace Log.Collector返回的prometheus指标的namespace，默认 "logger"
+	MetricsNamespace string `json:"metrics_namespace" yaml:"metrics_namespace" toml:"metrics_namespace"`
+	// Audit 防篡改的审计日志，通过Log.Audit写入，VerifyAuditLog校验
+	Audit AuditConfig `json:"audit" yaml:"audit" toml:"audit"`
+	// Failover 输出连续失败时切换到备用输出，key为 "file"、"console"
+	Failover map[string]FailoverConfig `json:"failover" yaml:"failover" toml:"failover"`
+	// RuntimeStats 在日志中附加goroutine数量、堆内存、GC停顿等运行时状态
+	RuntimeStats RuntimeStatsConfig `json:"runtime_stats" yaml:"runtime_stats" toml:"runtime_stats"`
+	// AccessLog 单独的访问日志文件，配置后HTTPMiddleware按Apache/Nginx格式写入该文件
+	AccessLog AccessLogConfig `json:"access_log" yaml:"access_log" toml:"access_log"`
+	// SQLite 将日志写入本地SQLite数据库，需要导入SQLite驱动
+	SQLite SQLiteConfig `json:"sqlite" yaml:"sqlite" toml:"sqlite"`
+	// Databases 将日志批量写入PostgreSQL或ClickHouse集中查询，需要导入对应的驱动
+	Databases []DatabaseConfig `json:"databases" yaml:"databases" toml:"databases"`
+	// Maintenance 维护窗口，窗口内抑制或降级告警、邮件、事故和sentry等输出，日志文件不受影响
+	Maintenance []MaintenanceWindow `json:"maintenance" yaml:"maintenance" toml:"maintenance"`
+	// Sampling 日志采样，可以按消息或字段为不同的日志设置不同的采样率
+	Sampling SamplingConfig `json:"sampling" yaml:"sampling" toml:"sampling"`
+	// Heartbeat 定时输出心跳日志
+	Heartbeat HeartbeatConfig `json:"heartbeat" yaml:"heartbeat" toml:"heartbeat"`
+	// Spool 告警、rollbar、bugsnag等网络输出不可用时暂存到本地磁盘，恢复后按顺序重放
+	Spool SpoolConfig `json:"spool" yaml:"spool" toml:"spool"`
+	// Fields 添加到每条日志的全局字段，如 {"service": "order", "env": "prod"}
+	Fields map[string]interface{} `json:"fields" yaml:"fields" toml:"fields"`
+	// Metadata 添加到每条日志的主机、进程及容器信息，可选 "hostname"、"pid"、"go_version"、"pod_name"、
+	// "pod_namespace"、"node_name"、"container_id"、"env:变量名"，"all" 表示除环境变量外的所有字段
+	Metadata []string `json:"metadata" yaml:"metadata" toml:"metadata"`
+	// BuildInfo 在每条日志中加上编译信息：version、vcs_revision、vcs_time，sentry的release同样取自编译信息
+	BuildInfo bool `json:"build_info" yaml:"build_info" toml:"build_info"`
+	// GoroutineID 在每条日志中加上goroutine字段，记录输出日志的goroutine ID
+	GoroutineID bool `json:"goroutine_id" yaml:"goroutine_id" toml:"goroutine_id"`
+	// FileBufferSize 日志文件的写入缓冲区大小(KB)，0不缓冲，高频写日志时可以减少系统调用，
+	// 缓冲的日志在缓冲区满、每FileFlushInterval秒(默认1)、Sync以及dpanic及以上级别的日志写入后写出
+	FileBufferSize    int `json:"file_buffer_size" yaml:"file_buffer_size" toml:"file_buffer_size"`
+	FileFlushInterval int `json:"file_flush_interval" yaml:"file_flush_interval" toml:"file_flush_interval"`
+	// CrashContextSize 保存最近的日志条数，CapturePanics输出崩溃日志时附带这些日志，为0时不保存
+	CrashContextSize int `json:"crash_context_size" yaml:"crash_context_size" toml:"crash_context_size"`
+	// ZapOptions 额外的zap选项，如zap.Hooks、zap.WrapCore，在本包的默认选项之后应用，只能通过代码设置
+	ZapOptions []zap.Option `json:"-" yaml:"-" toml:"-"`
+	// TestMode 测试模式，所有级别的日志同时记录在内存中，通过Log.ObservedLogs获取，用于单元测试断言
+	TestMode bool `json:"test_mode" yaml:"test_mode" toml:"test_mode"`
+	// Deterministic 确定性输出模式，用于与golden文件比较：时间固定为DeterministicTime(或SetClock设置的时钟)，
+	// 未设置TimeZone时使用UTC，hostname、pid等元数据和goroutine字段使用固定值，不输出BuildInfo，字段按名称排序
+	Deterministic bool `json:"deterministic" yaml:"deterministic" toml:"deterministic"`
+	// ErrorReporter 错误上报平台，可选 "sentry"、"rollbar"、"bugsnag"，默认 "sentry"
+	ErrorReporter string        `json:"error_reporter" yaml:"error_reporter" toml:"error_reporter"`
+	RollbarConfig RollbarConfig `json:"rollbar_config" yaml:"rollbar_config" toml:"rollbar_config"`
+	BugsnagConfig BugsnagConfig `json:"bugsnag_config" yaml:"bugsnag_config" toml:"bugsnag_config"`
+	// SentryRoutes 按日志名称、环境或字段将日志上报到不同的sentry项目，未匹配的日志上报到SentryConfig
+	SentryRoutes []SentryRoute `json:"sentry_routes" yaml:"sentry_routes" toml:"sentry_routes"`
+	Level        int8          `json:"level" yaml:"level" toml:"level"`
+	CloseDisplay int           `json:"close_display" yaml:"close_display" toml:"close_display"`
+	// MaxTotalSize 所有切割出的日志文件的总大小上限(MB)，超出时从最旧的文件开始删除，
+	// 与MaxAge、MaxBackups同时生效
+	MaxTotalSize int `json:"max_total_size" yaml:"max_total_size" toml:"max_total_size"`
+	// Compression 切割文件的压缩算法，可选 "gzip"、"zstd"、"none"，
+	// 为空时由Compress决定是否使用gzip压缩
+	Compression string `json:"compression" yaml:"compression" toml:"compression"`
+	// CompressionLevel 压缩级别，gzip为1-9，zstd为1-22，0使用默认级别
+	CompressionLevel int `json:"compression_level" yaml:"compression_level" toml:"compression_level"`
+	// CompressionWorkers 同时压缩的文件数，多个文件同时切割时并行压缩，默认为GOMAXPROCS，最多4
+	CompressionWorkers int `json:"compression_workers" yaml:"compression_workers" toml:"compression_workers"`
+	// RotatePattern 切割后的文件命名规则，支持strftime格式(%Y%m%d%H等)以及
+	// {filename} {hostname} {pid} {seq} 占位符，如 "{filename}.%Y%m%d-{hostname}"，
+	// 为空时按时间切割使用 filename+TimeUnit.Format()，按大小切割使用lumberjack默认命名
+	RotatePattern string `json:"rotate_pattern" yaml:"rotate_pattern" toml:"rotate_pattern"`
+	// CurrentLink 按时间切割时在InfoFilename/ErrorFilename处维护指向当前日志文件的软链接，
+	// 方便 tail -F 等工具使用固定路径
+	CurrentLink bool `json:"current_link" yaml:"current_link" toml:"current_link"`
+	// DayDirectory 按时间切割时把日志写入按日期命名的目录，如 logs/2024-05-19/server.log，目录自动创建，
+	// 切割时整体删除超过MaxAge天的日期目录及已经清空的日期目录，设置了RotatePattern时不生效
+	DayDirectory bool `json:"day_directory" yaml:"day_directory" toml:"day_directory"`
+	// TimeZone IANA时区名(如 "UTC"、"Asia/Shanghai")，同时作用于日志时间戳和按时间切割的边界及文件名，
+	// 为空时使用本地时区
+	TimeZone string `json:"time_zone" yaml:"time_zone" toml:"time_zone"`
+	// DirMode 自动创建日志目录时使用的权限，八进制字符串，默认 "0755"
+	DirMode string `json:"dir_mode" yaml:"dir_mode" toml:"dir_mode"`
+	// FileMode 日志文件的权限，八进制字符串，默认 "0644"
+	FileMode string `json:"file_mode" yaml:"file_mode" toml:"file_mode"`
+	// RotateOnSighup 收到SIGHUP时切割日志文件，兼容logrotate等工具
+	RotateOnSighup bool `json:"rotate_on_sighup" yaml:"rotate_on_sighup" toml:"rotate_on_sighup"`
+	// RotateCommand 每次切割后执行的外部命令，参数中的{old}、{new}会被替换为切割前后的文件路径
+	RotateCommand string `json:"rotate_command" yaml:"rotate_command" toml:"rotate_command"`
 	caller        bool
 	skip          int
+	rotateHooks   *rotateHooks
+	retention     *retention
+	loc           *time.Location
+	clock         Clock
+	encodeTime    zapcore.TimeEncoder
+	reporters     []ErrorReporter
+	metrics       *metrics
+	maintenance   *maintenance
+	// outputs 本次创建的输出，prevOutputs为Reload前使用的输出
+	outputs     *logOutputs
+	prevOutputs *logOutputs
 }
 
 func infoLevel(level int8) zap.LevelEnablerFunc {
@@ -81,54 +243,47 @@ func logLevel(level int8) zap.LevelEnablerFunc {
 	})
 }
 
-func New() *LogOptions {
-	return &LogOptions{
+// New 返回默认配置，opts依次作用于默认配置，配置不合法时panic
+func New(opts ...Option) *LogOptions {
+	c := &LogOptions{
 		Division:      _defaultDivision,
 		LevelSeparate: false,
 		TimeUnit:      _defaultUnit,
 		Encoding:      _defaultEncoding,
 		caller:        false,
 	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			panic(err)
+		}
+	}
+	return c
 }
 
+// NewFromToml 读取toml配置，按profile覆盖后展开环境变量并应用环境变量覆盖，见NewFromTomlProfile和ApplyEnv
 func NewFromToml(confPath string) *LogOptions {
-	var c *LogOptions
-	if _, err := toml.DecodeFile(confPath, &c); err != nil {
-		panic(err)
-	}
-	return c
+	return NewFromTomlProfile(confPath, "")
 }
 
+// NewFromYaml 读取yaml配置，按profile覆盖后展开环境变量并应用环境变量覆盖，见NewFromYamlProfile和ApplyEnv
 func NewFromYaml(confPath string) *LogOptions {
-	var c *LogOptions
-	file, err := ioutil.ReadFile(confPath)
-	if err != nil {
-		fmt.Printf("yamlFile.Get err   #%v ", err)
-	}
-	err = yaml.Unmarshal(file, &c)
-	if err != nil {
-		fmt.Printf("error: %v", err)
-	}
-	return c
+	return NewFromYamlProfile(confPath, "")
 }
 
+// NewFromJson 读取json配置，按profile覆盖后展开环境变量并应用环境变量覆盖，见NewFromJsonProfile和ApplyEnv
 func NewFromJson(confPath string) *LogOptions {
-	var c *LogOptions
-	file, err := ioutil.ReadFile(confPath)
-	if err != nil {
-		fmt.Printf("yamlFile.Get err   #%v ", err)
-	}
-	err = json.Unmarshal(file, &c)
-	if err != nil {
-		fmt.Printf("error: %v", err)
-	}
-	return c
+	return NewFromJsonProfile(confPath, "")
 }
 
 func (c *LogOptions) SetDivision(division string) {
 	c.Division = division
 }
 
+// development 是否为开发模式
+func (c *LogOptions) development() bool {
+	return strings.EqualFold(c.Mode, ModeDevelopment)
+}
+
 func (c *LogOptions) CloseConsoleDisplay() {
 	c.CloseDisplay = 1
 }
@@ -142,6 +297,22 @@ func (c *LogOptions) SetTimeUnit(t TimeUnit) {
 	c.TimeUnit = t
 }
 
+func (c *LogOptions) SetRotationCron(spec string) {
+	c.RotationCron = spec
+}
+
+func (c *LogOptions) SetCurrentLink(enable bool) {
+	c.CurrentLink = enable
+}
+
+func (c *LogOptions) SetRotatePattern(pattern string) {
+	c.RotatePattern = pattern
+}
+
+func (c *LogOptions) SetRotateCommand(command string) {
+	c.RotateCommand = command
+}
+
 func (c *LogOptions) SetErrorFile(path string) {
 	c.LevelSeparate = true
 	c.ErrorFilename = path
@@ -155,12 +326,76 @@ func (c *LogOptions) SetEncoding(encoding string) {
 	c.Encoding = encoding
 }
 
+func (c *LogOptions) SetCompression(algorithm string, level int) {
+	c.Compression = algorithm
+	c.CompressionLevel = level
+}
+
+func (c *LogOptions) SetFileMode(dirMode, fileMode string) {
+	c.DirMode = dirMode
+	c.FileMode = fileMode
+}
+
+func (c *LogOptions) SetTimeZone(name string) {
+	c.TimeZone = name
+}
+
+// location 返回TimeZone对应的时区
+func (c *LogOptions) location() *time.Location {
+	if c.TimeZone == "" {
+		if c.Deterministic {
+			return time.UTC
+		}
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// compression 返回实际使用的压缩算法
+func (c *LogOptions) compression() string {
+	if c.Compression == "" {
+		if c.Compress {
+			return CompressionGzip
+		}
+		return CompressionNone
+	}
+	return c.Compression
+}
+
 // isOutput whether set output file
 func (c *LogOptions) isOutput() bool {
 	return c.InfoFilename != ""
 }
 
+// InitLogger 创建Log，customEncodeTime为true时时间格式为 "2006-01-02 15:04:05"
+//
+// Deprecated: 使用InitLoggerWith，位置参数的含义在调用处不直观
 func (c *LogOptions) InitLogger(timeKey, levelKey string, customEncodeTime, shortCaller bool) *Log {
+	eo := EncoderOptions{TimeKey: timeKey, LevelKey: levelKey, ShortCaller: shortCaller}
+	// 保持原有行为：key为空时不输出
+	if timeKey == "" {
+		eo.TimeKey = _omitKey
+	}
+	if levelKey == "" {
+		eo.LevelKey = _omitKey
+	}
+	if customEncodeTime {
+		eo.TimeLayout = "2006-01-02 15:04:05"
+	}
+	return c.initLogger(eo, nil)
+}
+
+// InitLoggerWith 按EncoderOptions创建Log，EncoderOptions的零值使用默认设置
+func (c *LogOptions) InitLoggerWith(eo EncoderOptions) *Log {
+	return c.initLogger(eo.withDefaults(), nil)
+}
+
+// initLogger 创建Log，prev不为空时为Log.Reload重新创建，沿用prev中运行时设置的全局字段、钩子和Fatal处理
+func (c *LogOptions) initLogger(eo EncoderOptions, prev *Log) *Log {
 	var (
 		logger             *zap.Logger
 		infoHook, warnHook io.Writer
@@ -171,137 +406,518 @@ func (c *LogOptions) InitLogger(timeKey, levelKey string, customEncodeTime, shor
 	if c.Encoding == "" {
 		c.Encoding = _defaultEncoding
 	}
+	c.InfoFilename = c.resolvePlaceholders(c.InfoFilename)
+	c.ErrorFilename = c.resolvePlaceholders(c.ErrorFilename)
+	c.Audit.Filename = c.resolvePlaceholders(c.Audit.Filename)
+	c.RotatePattern = c.resolvePlaceholders(c.RotatePattern)
 	encoder := _encoderNameToConstructor[c.Encoding]
+	c.metrics = newMetrics(c.MetricsNamespace)
+	c.outputs = newLogOutputs()
+	c.prevOutputs = nil
+	if prev != nil && prev.reload != nil {
+		c.prevOutputs = prev.reload.load().outputs
+	}
+	c.rotateHooks = newRotateHooks(c.RotateCommand)
+	if c.FileMode != "" {
+		mode, err := c.fileMode()
+		if err != nil {
+			panic(err)
+		}
+		c.rotateHooks.fileMode = mode
+	}
+	if compression := c.compression(); compression != CompressionNone {
+		c.rotateHooks.compressor = newCompressor(compression, c.CompressionLevel, c.CompressionWorkers)
+	}
+	c.retention = nil
+	if c.MaxTotalSize > 0 {
+		r := newRetention(c.MaxTotalSize, c.logFilenames())
+		c.rotateHooks.add(func(string, string) { r.prune() })
+		c.retention = r
+	}
 
-	encodeTime := zapcore.ISO8601TimeEncoder
-	if customEncodeTime {
-		encodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
-			enc.AppendString(t.Format("2006-01-02 15:04:05"))
+	// 设置了LevelRules时各输出启用所有规则中的最低级别，由levelRouterCore按规则过滤
+	level := c.Level
+	var rules *levelRules
+	if len(c.LevelRules) > 0 {
+		r, err := newLevelRules(c.LevelRules, zapcore.Level(c.Level), newCallerFinder(c.CallerSkipPackages))
+		if err != nil {
+			panic(err)
 		}
+		rules, level = r, int8(r.min)
 	}
 
-	encoderConfig := zapcore.EncoderConfig{
-		TimeKey:        timeKey,
-		LevelKey:       levelKey,
-		NameKey:        "logger",
-		CallerKey:      "file",
-		MessageKey:     "msg",
-		StacktraceKey:  "stacktrace",
-		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.LowercaseLevelEncoder,
-		EncodeTime:     encodeTime,
-		EncodeDuration: zapcore.SecondsDurationEncoder,
-		EncodeCaller:   zapcore.FullCallerEncoder,
+	c.loc = c.location()
+	var prevMaintenance *maintenance
+	if prev != nil {
+		prevMaintenance = prev.maintenance
+	}
+	maintenance, err := c.newMaintenance(prevMaintenance)
+	if err != nil {
+		panic(err)
 	}
-	if shortCaller {
-		encoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
+	c.maintenance = maintenance
+	if c.TimeLayout != "" {
+		eo.TimeLayout = c.TimeLayout
 	}
-
-	if c.CloseDisplay == 0 {
-		wsInfo = append(wsInfo, zapcore.AddSync(os.Stdout))
-		wsWarn = append(wsWarn, zapcore.AddSync(os.Stdout))
+	encoderConfig := eo.encoderConfig(c.loc)
+	c.Encoder.apply(&encoderConfig)
+	levelEncoderName := eo.LevelEncoder
+	if c.Encoder.LevelEncoder != "" {
+		levelEncoderName = c.Encoder.LevelEncoder
+	}
+	encoderConfig.EncodeLevel = levelEncoder(encoderConfig.EncodeLevel, strings.HasPrefix(levelEncoderName, "capital"))
+	c.encodeTime = encoderConfig.EncodeTime
+	// 开发模式下未指定级别格式时控制台使用彩色级别，文件不受影响
+	consoleEncoderConfig := encoderConfig
+	if c.development() && levelEncoderName == "" && c.Encoding == "console" {
+		consoleEncoderConfig.EncodeLevel = levelEncoder(zapcore.LowercaseColorLevelEncoder, false)
 	}
 
 	// zapcore WriteSyncer setting
 	if c.isOutput() {
-		switch c.Division {
-		case TimeDivision:
-			infoHook = c.timeDivisionWriter(c.InfoFilename)
-			if c.LevelSeparate {
-				warnHook = c.timeDivisionWriter(c.ErrorFilename)
+		filenames := []string{c.InfoFilename}
+		if c.LevelSeparate {
+			filenames = append(filenames, c.ErrorFilename)
+		}
+		for _, filename := range filenames {
+			if err := c.prepareLogFile(filename, c.Division == SizeDivision); err != nil {
+				panic(err)
 			}
-		case SizeDivision:
-			infoHook = c.sizeDivisionWriter(c.InfoFilename)
-			if c.LevelSeparate {
-				warnHook = c.sizeDivisionWriter(c.ErrorFilename)
+		}
+		infoHook = c.divisionWriter(c.InfoFilename)
+		if c.LevelSeparate {
+			warnHook = c.divisionWriter(c.ErrorFilename)
+		}
+		for _, hook := range []io.Writer{infoHook, warnHook} {
+			if closer, ok := hook.(io.Closer); ok {
+				c.closeOnReload(func() { closer.Close() })
 			}
 		}
-		wsInfo = append(wsInfo, zapcore.AddSync(infoHook))
+		wsInfo = append(wsInfo, c.buffer(c.countWrites(sinkInfoFile, c.encrypt(c.failover(OutputFile, c.InfoFilename, infoHook)))))
 	}
 
 	if c.ErrorFilename != "" {
-		wsWarn = append(wsWarn, zapcore.AddSync(warnHook))
+		wsWarn = append(wsWarn, c.buffer(c.countWrites(sinkErrorFile, c.encrypt(c.failover(OutputFile, c.ErrorFilename, warnHook)))))
+	}
+
+	if c.retention != nil {
+		go c.retention.prune()
+	}
+
+	for _, hook := range []io.Writer{infoHook, warnHook} {
+		if r, ok := hook.(rotator); ok {
+			c.outputs.rotators = append(c.outputs.rotators, r)
+		}
 	}
 
 	opts := make([]zap.Option, 0)
 	cos := make([]zapcore.Core, 0)
 
+	// 控制台输出总是创建，由开关决定是否输出，见Log.EnableConsole
+	console := &consoleSwitch{}
+	if prev != nil {
+		console = prev.console
+	}
+	console.set(c.CloseDisplay == 0)
+	var consoleEnabler zapcore.LevelEnabler = logLevel(level)
 	if c.LevelSeparate {
-		cos = append(
-			cos,
-			zapcore.NewCore(encoder(encoderConfig), zapcore.NewMultiWriteSyncer(wsInfo...), infoLevel(c.Level)),
-			zapcore.NewCore(encoder(encoderConfig), zapcore.NewMultiWriteSyncer(wsWarn...), warnLevel()),
-		)
-	} else {
-		cos = append(
-			cos,
-			zapcore.NewCore(encoder(encoderConfig), zapcore.NewMultiWriteSyncer(wsInfo...), logLevel(c.Level)),
-		)
+		consoleEnabler = zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+			return infoLevel(level)(lvl) || warnLevel()(lvl)
+		})
+	}
+	cos = append(cos, &consoleCore{Core: c.filterOutput(OutputConsole,
+		c.encodedCore(encoder(consoleEncoderConfig), zapcore.AddSync(c.countWrites(sinkConsole, c.failover(OutputConsole, FailoverStdout, os.Stdout))), consoleEnabler)), sw: console})
+	if c.LevelSeparate {
+		if len(wsInfo) > 0 {
+			cos = append(cos, c.filterOutput(OutputFile,
+				c.encodedCore(encoder(encoderConfig), zapcore.NewMultiWriteSyncer(wsInfo...), infoLevel(level))))
+		}
+		if len(wsWarn) > 0 {
+			cos = append(cos, c.filterOutput(OutputFile,
+				c.encodedCore(encoder(encoderConfig), zapcore.NewMultiWriteSyncer(wsWarn...), warnLevel())))
+		}
+	} else if len(wsInfo) > 0 {
+		cos = append(cos, c.filterOutput(OutputFile,
+			c.encodedCore(encoder(encoderConfig), zapcore.NewMultiWriteSyncer(wsInfo...), logLevel(level))))
+	}
+	for _, core := range c.alertCores() {
+		cos = append(cos, c.maintain(OutputAlert, c.filterOutput(OutputAlert, core)))
+	}
+	for _, core := range c.emailCores() {
+		cos = append(cos, c.maintain(OutputEmail, c.filterOutput(OutputEmail, core)))
+	}
+	for _, core := range c.incidentCores() {
+		cos = append(cos, c.maintain(OutputIncident, c.filterOutput(OutputIncident, core)))
+	}
+	var prevSQLite *sqliteDB
+	var prevDatabases []*dbSink
+	if c.prevOutputs != nil {
+		prevSQLite, prevDatabases = c.prevOutputs.sqlite, c.prevOutputs.databases
+	}
+	sqliteCore, sqlite := c.sqliteCore(level, encoderConfig, prevSQLite)
+	if sqliteCore != nil {
+		cos = append(cos, c.filterOutput(OutputSQLite, sqliteCore))
+	}
+	dbCores, databases := c.databaseCores(level, encoderConfig, prevDatabases)
+	c.outputs.sqlite, c.outputs.databases = sqlite, databases
+	for _, core := range dbCores {
+		cos = append(cos, c.filterOutput(OutputDatabase, core))
+	}
+	var observed *observer.ObservedLogs
+	if c.TestMode {
+		var core zapcore.Core
+		core, observed = observer.New(zapcore.DebugLevel)
+		cos = append(cos, core)
+	}
+	var recent *ringBuffer
+	if prev != nil && prev.recent != nil {
+		recent = prev.recent
+	} else if c.CrashContextSize > 0 {
+		recent = newRingBuffer(c.CrashContextSize)
+	}
+	if recent != nil {
+		cos = append(cos, &recentCore{LevelEnabler: logLevel(level), ring: recent, encoder: newLineEncoder(c.encodeTime)})
 	}
 
-	opts = append(opts, zap.Development())
+	opts = append(opts, zap.Hooks(c.metrics.entry), zap.ErrorOutput(errorOutput{}))
+	if c.development() {
+		opts = append(opts, zap.Development())
+	}
 
 	if c.Stacktrace {
-		opts = append(opts, zap.AddStacktrace(zapcore.WarnLevel))
+		opts = append(opts, zap.AddStacktrace(c.stacktraceLevel()))
 	}
 
-	if c.caller {
+	if c.caller || c.CallerAuto {
 		opts = append(opts, zap.AddCaller(), zap.AddCallerSkip(c.skip))
 	}
 
 	logger = zap.New(zapcore.NewTee(cos...), opts...)
 
-	if c.SentryConfig.DSN != "" {
-		// sentrycore配置
-		cfg := sentryCoreConfig{
-			Level:             zap.ErrorLevel,
-			Tags:              c.SentryConfig.Tags,
-			DisableStacktrace: !c.SentryConfig.AttachStacktrace,
+	if sCore := c.errorReporterCore(); sCore != nil {
+		sCore = c.maintain(OutputErrorReporter, c.filterOutput(OutputErrorReporter, sCore))
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, sCore)
+		}))
+	}
+
+	hooks := &entryHooks{}
+	if prev != nil {
+		hooks = prev.hooks
+	}
+	logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &hookCore{Core: core, hooks: hooks}
+	}))
+
+	if t := c.truncator(); t != nil {
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newTransformCore(core, t.truncateEntry, t.fields)
+		}))
+	}
+
+	// 截断和脱敏在增加字段的处理之内完成，元数据、全局字段、运行时统计等同样会被脱敏，
+	// 文件、告警、sentry、hook等收到的都是脱敏后的日志
+	if len(c.Redact) > 0 {
+		r, err := newRedactor(c.Redact)
+		if err != nil {
+			panic(err)
 		}
-		// 生成sentry客户端
-		sentryClient, err := sentry.NewClient(sentry.ClientOptions{
-			Dsn:              c.SentryConfig.DSN,
-			Debug:            c.SentryConfig.Debug,
-			AttachStacktrace: c.SentryConfig.AttachStacktrace,
-			Environment:      c.SentryConfig.Environment,
-		})
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newTransformCore(core, nil, r.redactFields)
+		}))
+	}
+
+	if fs := c.metadataFields(); len(fs) > 0 {
+		logger = logger.With(fs...)
+	}
+	if c.BuildInfo && !c.Deterministic {
+		if fs := buildFields(); len(fs) > 0 {
+			logger = logger.With(fs...)
+		}
+	}
+
+	if c.GoroutineID {
+		addID := addGoroutineID
+		if c.Deterministic {
+			addID = addFixedGoroutineID
+		}
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newTransformCore(core, addID, nil)
+		}))
+	}
+
+	globals := &globalFields{}
+	if prev != nil {
+		globals = prev.globals
+	} else {
+		globals.store(configFields(c.Fields))
+	}
+	logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &globalCore{Core: core, globals: globals}
+	}))
+
+	if s, err := c.runtimeSampler(); err != nil {
+		panic(err)
+	} else if s != nil {
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newTransformCore(core, s.addStats, nil)
+		}))
+	}
+
+	if rules := c.scrubRules(); len(rules) > 0 {
+		s, err := newScrubber(rules)
 		if err != nil {
-			fmt.Println(err)
+			panic(err)
 		}
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newTransformCore(core, s.scrubEntry, s.scrubFields)
+		}))
+	}
 
-		sCore := NewSentryCore(cfg, sentryClient)
+	trimmer := stackTrimmer{skip: c.StacktraceSkipFrames, max: c.StacktraceMaxFrames}
+	logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newTransformCore(core, trimmer.entry, expandErrors)
+	}))
+	// 错误码需要在展开错误链之前按原错误分类
+	logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newTransformCore(core, nil, classifyErrors)
+	}))
+
+	if c.CallerAuto {
+		finder := newCallerFinder(c.CallerSkipPackages)
 		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
-			return zapcore.NewTee(core, sCore)
+			return newTransformCore(core, finder.entry, nil)
+		}))
+	}
+
+	// 采样在写入各输出前完成，被丢弃的日志不再经过后续处理
+	if sc, err := c.sampling(); err != nil {
+		panic(err)
+	} else if sc != nil {
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &samplingCore{Core: core, cfg: sc}
+		}))
+	}
+
+	fatal := newFatalHooks()
+	if prev != nil {
+		fatal = prev.fatal
+	}
+	logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &fatalCore{Core: core, hooks: fatal}
+	}))
+
+	// 时间在最外层设置，所有输出看到的时间一致
+	if c.Deterministic {
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newTransformCore(core, c.clockEntry(), sortFields)
+		}))
+	} else if c.clock != nil {
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newTransformCore(core, c.clockEntry(), nil)
+		}))
+	}
+
+	// 级别规则在最外层，不满足规则的日志在进入其他处理之前丢弃
+	if rules != nil {
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &levelRouterCore{Core: core, rules: rules}
 		}))
 	}
 
-	return &Log{logger}
+	// 自定义的zap选项在本包的处理之外，其中对core的修改随Reload一起替换
+	if len(c.ZapOptions) > 0 {
+		logger = logger.WithOptions(c.ZapOptions...)
+	}
+
+	// 可替换的core在最外层，Reload时替换整条处理链
+	reload := newReloadRoot()
+	config := c.resolved()
+	outputs := c.outputs
+	logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		reload.store(core, config, outputs)
+		return &reloadCore{root: reload}
+	}))
+
+	log := &Log{L: logger, rotateHooks: c.rotateHooks, metrics: c.metrics, globals: globals, hooks: hooks, observed: observed, fatal: fatal, encoder: eo, reload: reload, recent: recent, maintenance: maintenance, console: console}
+	// 审计日志、SIGHUP监听、心跳和访问日志在进程内只创建一次，Reload时沿用
+	if prev != nil {
+		log.audit, log.heartbeat, log.access = prev.audit, prev.heartbeat, prev.access
+		if r, ok := log.access.rotator(); ok {
+			outputs.rotators = append(outputs.rotators, r)
+		}
+		return log
+	}
+	if c.Audit.Filename != "" {
+		if err := c.prepareLogFile(c.Audit.Filename, false); err != nil {
+			panic(err)
+		}
+		mode, _ := c.fileMode()
+		audit, err := newAuditLog(c.Audit, mode, c.loc)
+		if err != nil {
+			panic(err)
+		}
+		log.audit = audit
+	}
+	if c.RotateOnSighup {
+		log.RotateOnSignal(syscall.SIGHUP)
+	}
+	log.heartbeat = c.startHeartbeat(c.Heartbeat, logger)
+	log.access = c.accessLog()
+	if r, ok := log.access.rotator(); ok {
+		outputs.rotators = append(outputs.rotators, r)
+	}
+	return log
+}
+
+// divisionWriter 按Division返回切割文件的writer
+func (c *LogOptions) divisionWriter(filename string) io.Writer {
+	switch c.Division {
+	case TimeDivision:
+		return c.timeDivisionWriter(filename)
+	case SizeDivision:
+		return c.sizeDivisionWriter(filename)
+	case HybridDivision:
+		return c.hybridDivisionWriter(filename)
+	}
+	return nil
 }
 
 func (c *LogOptions) sizeDivisionWriter(filename string) io.Writer {
-	hook := &lumberjack.Logger{
-		Filename:   filename,
-		MaxSize:    c.MaxSize,
-		MaxBackups: c.MaxBackups,
-		MaxAge:     c.MaxSize,
-		Compress:   c.Compress,
+	// lumberjack只负责按大小切割，压缩和清理在切割回调中完成。
+	// lumberjack.Logger关闭后不会退出后台的goroutine，Reload时文件名和MaxSize不变则继续使用
+	hook := c.prevOutputs.lumberjack(filename, c.MaxSize)
+	if hook == nil {
+		hook = &lumberjack.Logger{
+			Filename: filename,
+			MaxSize:  c.MaxSize,
+		}
 	}
-	return hook
+	if c.outputs != nil {
+		c.outputs.lumberjacks = append(c.outputs.lumberjacks, hook)
+	}
+	w := newSizeWriter(hook, c.rotateHooks)
+	if c.RotatePattern != "" {
+		pattern, err := newFilePattern(c.RotatePattern, filename)
+		if err != nil {
+			panic(err)
+		}
+		w.pattern = pattern
+		w.loc = c.loc
+		w.clock = c.getClock()
+		if w.dirMode, err = c.dirMode(); err != nil {
+			panic(err)
+		}
+	}
+	glob, err := c.sizeBackupGlob(filename)
+	if err != nil {
+		panic(err)
+	}
+
+	globs := compressedGlobs(glob)
+	active := func() string { return filename }
+	keep, maxBackups, maxAge, clock := c.logFilenames(), c.MaxBackups, c.MaxAge, c.getClock()
+	c.rotateHooks.add(func(string, string) { pruneBackups(globs, active, keep, maxBackups, maxAge, clock.Now()) })
+	c.retention.add(active, globs...)
+	return w
 }
 
-func (c *LogOptions) timeDivisionWriter(filename string) io.Writer {
-	hook, err := rotatelogs.New(
-		filename+c.TimeUnit.Format(),
-		rotatelogs.WithMaxAge(time.Duration(int64(24*time.Hour)*int64(c.MaxAge))),
-		rotatelogs.WithRotationTime(c.TimeUnit.RotationGap()),
-	)
+// sizeBackupGlob 按大小切割出的备份文件的glob
+func (c *LogOptions) sizeBackupGlob(filename string) (string, error) {
+	if c.RotatePattern != "" {
+		pattern, err := newFilePattern(c.RotatePattern, filename)
+		if err != nil {
+			return "", err
+		}
+		return pattern.glob(), nil
+	}
+	return lumberjackBackupGlob(filename), nil
+}
+
+// logFilenames 配置的所有日志文件，其他日志文件的切割glob可能匹配到这些文件，清理时不能删除
+func (c *LogOptions) logFilenames() []string {
+	var names []string
+	for _, name := range []string{c.InfoFilename, c.ErrorFilename, c.AccessLog.Filename, c.Audit.Filename, c.SQLite.Path} {
+		if name != "" {
+			names = append(names, c.resolvePlaceholders(name))
+		}
+	}
+	return names
+}
+
+// timePattern 按时间切割的文件名，strftime格式
+func (c *LogOptions) timePattern(filename string) (string, error) {
+	unit := c.TimeUnit
+	if c.RotationCron != "" {
+		// cron最小粒度为分钟
+		unit = Minute
+	}
+	pattern := filename + unit.Format()
+	if c.DayDirectory {
+		pattern = dayDirPattern(filename, unit)
+	}
+	if c.RotatePattern != "" {
+		p, err := newFilePattern(c.RotatePattern, filename)
+		if err != nil {
+			return "", err
+		}
+		pattern = p.strftimePattern()
+	}
+	return pattern, nil
+}
+
+func (c *LogOptions) timeDivisionWriter(filename string, options ...rotatelogs.Option) io.Writer {
+	pattern, err := c.timePattern(filename)
+	if err != nil {
+		panic(err)
+	}
+	rotationTime := c.TimeUnit.RotationGap()
+	if c.RotationCron != "" {
+		clock, err := newCronClock(c.RotationCron, c.getClock(), c.loc)
+		if err != nil {
+			panic(err)
+		}
+		rotationTime = time.Minute
+		options = append(options, rotatelogs.WithClock(clock))
+	}
+	if c.RotationCron == "" {
+		options = append(options, rotatelogs.WithClock(locClock{clock: c.getClock(), loc: c.loc}))
+	}
+	if c.CurrentLink {
+		options = append(options, rotatelogs.WithLinkName(filename))
+	}
+	options = append([]rotatelogs.Option{
+		rotatelogs.WithMaxAge(time.Duration(int64(24*time.Hour) * int64(c.MaxAge))),
+		rotatelogs.WithRotationTime(rotationTime),
+		rotatelogs.WithHandler(c.rotateHooks),
+	}, options...)
+	hook, err := rotatelogs.New(pattern, options...)
 
 	if err != nil {
 		panic(err)
 	}
+	// rotatelogs只清理与pattern匹配的文件，压缩后带后缀的文件在切割回调中按MaxAge清理
+	globs := compressedGlobs(_strftimeVerb.ReplaceAllString(pattern, "*"))
+	keep, maxAge, clock := c.logFilenames(), c.MaxAge, c.getClock()
+	c.rotateHooks.add(func(string, string) { pruneBackups(globs, hook.CurrentFileName, keep, 0, maxAge, clock.Now()) })
+	if c.DayDirectory && c.RotatePattern == "" {
+		root, loc := filepath.Dir(filename), c.loc
+		c.rotateHooks.add(func(string, string) { pruneDayDirs(root, maxAge, hook.CurrentFileName, clock.Now(), loc) })
+	}
+	c.retention.add(hook.CurrentFileName, globs...)
 	return hook
 }
 
+// hybridDivisionWriter 按时间切割，单个文件超过MaxSize(MB)时提前切割，序号追加在文件名后
+func (c *LogOptions) hybridDivisionWriter(filename string) io.Writer {
+	return c.timeDivisionWriter(filename, rotatelogs.WithRotationSize(int64(c.MaxSize)*1024*1024))
+}
+
+// Core 返回日志的zapcore.Core，包含本包的全部处理，可以与自定义的core组合后创建新的zap.Logger
+func (log *Log) Core() zapcore.Core {
+	return log.L.Core()
+}
+
 func (log *Log) Info(msg string, args ...zap.Field) {
 	log.L.Info(msg, args...)
 }
@@ -322,24 +938,42 @@ func (log *Log) Fatal(msg string, args ...zap.Field) {
 	log.L.Fatal(msg, args...)
 }
 
+// Panic 输出日志后panic
+func (log *Log) Panic(msg string, args ...zap.Field) {
+	log.L.Panic(msg, args...)
+}
+
+// DPanic 输出日志，开发模式(Mode为 "development")下随后panic
+func (log *Log) DPanic(msg string, args ...zap.Field) {
+	log.L.DPanic(msg, args...)
+}
+
 func (log *Log) Infof(format string, args ...interface{}) {
-	logMsg := fmt.Sprintf(format, args...)
-	log.L.Info(logMsg)
+	if !log.L.Core().Enabled(zapcore.InfoLevel) {
+		return
+	}
+	log.L.Info(fmt.Sprintf(format, args...))
 }
 
 func (log *Log) Errorf(format string, args ...interface{}) {
-	logMsg := fmt.Sprintf(format, args...)
-	log.L.Error(logMsg)
+	if !log.L.Core().Enabled(zapcore.ErrorLevel) {
+		return
+	}
+	log.L.Error(fmt.Sprintf(format, args...))
 }
 
 func (log *Log) Warnf(format string, args ...interface{}) {
-	logMsg := fmt.Sprintf(format, args...)
-	log.L.Warn(logMsg)
+	if !log.L.Core().Enabled(zapcore.WarnLevel) {
+		return
+	}
+	log.L.Warn(fmt.Sprintf(format, args...))
 }
 
 func (log *Log) Debugf(format string, args ...interface{}) {
-	logMsg := fmt.Sprintf(format, args...)
-	log.L.Debug(logMsg)
+	if !log.L.Core().Enabled(zapcore.DebugLevel) {
+		return
+	}
+	log.L.Debug(fmt.Sprintf(format, args...))
 }
 
 func (log *Log) Fatalf(format string, args ...interface{}) {
@@ -347,10 +981,25 @@ func (log *Log) Fatalf(format string, args ...interface{}) {
 	log.L.Fatal(logMsg)
 }
 
+func (log *Log) Panicf(format string, args ...interface{}) {
+	logMsg := fmt.Sprintf(format, args...)
+	log.L.Panic(logMsg)
+}
+
+func (log *Log) DPanicf(format string, args ...interface{}) {
+	logMsg := fmt.Sprintf(format, args...)
+	log.L.DPanic(logMsg)
+}
+
 func With(k string, v interface{}) zap.Field {
 	return zap.Any(k, v)
 }
 
+// WithError 输出错误，通过InitLogger创建的Log会展开错误链：error为错误消息，
+// error_causes为被包装的下层错误，错误带有调用栈(如pkg/errors)时error_stacktrace为其调用栈
 func WithError(err error) zap.Field {
-	return zap.NamedError("error", err)
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.NamedError("error", &chainError{err: err})
 }
@@ -0,0 +1,83 @@
+// Package logruslog 提供把logrus日志转发到 logger.Log 的 logrus.Hook，
+// 用于逐步迁移仍在使用logrus的代码，所有日志统一经过logger的切割、告警和Sentry上报
+//
+//	logrus.AddHook(logruslog.NewHook(log))
+//	logrus.SetOutput(io.Discard)
+package logruslog
+
+import (
+	"sort"
+
+	"github.com/mae-pax/logger"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Hook 把logrus的日志级别、字段和消息转发到logger.Log
+type Hook struct {
+	core   zapcore.Core
+	levels []logrus.Level
+}
+
+// NewHook 创建Hook，levels为转发的logrus级别，为空时转发所有级别
+func NewHook(log *logger.Log, levels ...logrus.Level) *Hook {
+	if len(levels) == 0 {
+		levels = logrus.AllLevels
+	}
+	return &Hook{core: log.L.Core(), levels: levels}
+}
+
+// Levels 实现logrus.Hook
+func (h *Hook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire 实现logrus.Hook，直接写入core，Panic、Fatal级别的退出仍由logrus处理
+func (h *Hook) Fire(e *logrus.Entry) error {
+	ent := zapcore.Entry{
+		Level:   zapLevel(e.Level),
+		Time:    e.Time,
+		Message: e.Message,
+	}
+	if e.Caller != nil {
+		ent.Caller = zapcore.NewEntryCaller(e.Caller.PC, e.Caller.File, e.Caller.Line, true)
+	}
+	ce := h.core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]zap.Field, 0, len(keys))
+	for _, k := range keys {
+		if err, ok := e.Data[k].(error); ok {
+			fields = append(fields, zap.NamedError(k, err))
+			continue
+		}
+		fields = append(fields, zap.Any(k, e.Data[k]))
+	}
+	ce.Write(fields...)
+	return nil
+}
+
+func zapLevel(level logrus.Level) zapcore.Level {
+	switch level {
+	case logrus.PanicLevel:
+		return zapcore.PanicLevel
+	case logrus.FatalLevel:
+		return zapcore.FatalLevel
+	case logrus.ErrorLevel:
+		return zapcore.ErrorLevel
+	case logrus.WarnLevel:
+		return zapcore.WarnLevel
+	case logrus.InfoLevel:
+		return zapcore.InfoLevel
+	}
+	// logrus的Debug和Trace都对应Debug
+	return zapcore.DebugLevel
+}
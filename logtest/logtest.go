@@ -0,0 +1,61 @@
+// Package logtest 提供单元测试使用的 logger.Log
+//
+//	func TestHandler(t *testing.T) {
+//		log, logs := logtest.NewObserved(t)
+//		handle(log)
+//		logtest.AssertLogged(t, logs, zapcore.ErrorLevel, "query failed", "user_id")
+//	}
+package logtest
+
+import (
+	"testing"
+
+	"github.com/mae-pax/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// NewTestLogger 返回通过t.Log输出的Log，日志只在测试失败或 go test -v 时显示
+func NewTestLogger(t testing.TB) *logger.Log {
+	return &logger.Log{L: zaptest.NewLogger(t, zaptest.Level(zapcore.DebugLevel))}
+}
+
+// NewObserved 返回同时通过t.Log输出并记录在内存中的Log，以及记录的日志
+func NewObserved(t testing.TB) (*logger.Log, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := zaptest.NewLogger(t, zaptest.Level(zapcore.DebugLevel), zaptest.WrapOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(c, core)
+	})))
+	return &logger.Log{L: l}, logs
+}
+
+// Logged 返回logs中级别为level、消息为msg且包含所有keys字段的日志
+func Logged(logs *observer.ObservedLogs, level zapcore.Level, msg string, keys ...string) []observer.LoggedEntry {
+	var matched []observer.LoggedEntry
+	for _, e := range logs.FilterMessage(msg).All() {
+		if e.Level != level {
+			continue
+		}
+		fields := e.ContextMap()
+		ok := true
+		for _, key := range keys {
+			if _, ok = fields[key]; !ok {
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// AssertLogged 断言logs中有级别为level、消息为msg且包含所有keys字段的日志
+func AssertLogged(t testing.TB, logs *observer.ObservedLogs, level zapcore.Level, msg string, keys ...string) {
+	t.Helper()
+	if len(Logged(logs, level, msg, keys...)) == 0 {
+		t.Errorf("logtest: no %s entry %q with fields %v in %d logged entries", level, msg, keys, logs.Len())
+	}
+}
@@ -0,0 +1,206 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap/zapcore"
+)
+
+// 维护窗口内对输出的处理方式
+const (
+	// MaintenanceSuppress 不输出
+	MaintenanceSuppress = "suppress"
+	// MaintenanceDowngrade 降低一个级别后输出，如error作为warn，低于输出的最低级别时不输出
+	MaintenanceDowngrade = "downgrade"
+)
+
+// MaintenanceWindow 维护窗口，窗口内告警类输出(alert、email、incident、error_reporter)被抑制或降级，
+// 日志文件和控制台不受影响。窗口可以用Schedule+Duration表示周期性的窗口，也可以用From、To表示一次性的窗口
+type MaintenanceWindow struct {
+	// Schedule 窗口开始时间的cron表达式，如 "0 2 * * 6" 表示每周六2点，按TimeZone计算
+	Schedule string `toml:"schedule" yaml:"schedule" json:"schedule"`
+	// Duration 窗口持续的分钟数
+	Duration int `toml:"duration" yaml:"duration" json:"duration"`
+	// From、To 一次性窗口的起止时间，RFC3339格式
+	From string `toml:"from" yaml:"from" json:"from"`
+	To   string `toml:"to" yaml:"to" json:"to"`
+	// Outputs 受影响的输出，可选 "alert"、"email"、"incident"、"error_reporter"，为空时全部
+	Outputs []string `toml:"outputs" yaml:"outputs" json:"outputs"`
+	// Action 可选 "suppress"(默认)、"downgrade"
+	Action string `toml:"action" yaml:"action" json:"action"`
+}
+
+type maintenanceWindow struct {
+	schedule cron.Schedule
+	duration time.Duration
+	from, to time.Time
+	outputs  map[string]bool
+	action   string
+}
+
+func newMaintenanceWindow(w MaintenanceWindow, loc *time.Location) (*maintenanceWindow, error) {
+	mw := &maintenanceWindow{action: strings.ToLower(w.Action)}
+	switch mw.action {
+	case "":
+		mw.action = MaintenanceSuppress
+	case MaintenanceSuppress, MaintenanceDowngrade:
+	default:
+		return nil, fmt.Errorf("logger: unknown maintenance action %q", w.Action)
+	}
+	switch {
+	case w.Schedule != "":
+		schedule, err := cron.ParseStandard(w.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("logger: invalid maintenance schedule %q: %v", w.Schedule, err)
+		}
+		if w.Duration <= 0 {
+			return nil, fmt.Errorf("logger: maintenance schedule %q needs a positive duration", w.Schedule)
+		}
+		mw.schedule, mw.duration = schedule, time.Duration(w.Duration)*time.Minute
+	case w.From != "" && w.To != "":
+		var err error
+		if mw.from, err = time.ParseInLocation(time.RFC3339, w.From, loc); err != nil {
+			return nil, fmt.Errorf("logger: invalid maintenance from %q: %v", w.From, err)
+		}
+		if mw.to, err = time.ParseInLocation(time.RFC3339, w.To, loc); err != nil {
+			return nil, fmt.Errorf("logger: invalid maintenance to %q: %v", w.To, err)
+		}
+	default:
+		return nil, fmt.Errorf("logger: maintenance window needs schedule or from and to")
+	}
+	if len(w.Outputs) > 0 {
+		mw.outputs = make(map[string]bool, len(w.Outputs))
+		for _, o := range w.Outputs {
+			mw.outputs[o] = true
+		}
+	}
+	return mw, nil
+}
+
+// active 判断t是否在窗口内，周期性窗口在 (t-duration, t] 内有触发时间即为窗口内
+func (w *maintenanceWindow) active(t time.Time, output string) bool {
+	if w.outputs != nil && !w.outputs[output] {
+		return false
+	}
+	if w.schedule != nil {
+		return !w.schedule.Next(t.Add(-w.duration)).After(t)
+	}
+	return !t.Before(w.from) && t.Before(w.to)
+}
+
+// maintenance 配置的维护窗口以及通过Log.StartMaintenance开始的临时窗口
+type maintenance struct {
+	windows []*maintenanceWindow
+	loc     *time.Location
+	// clock 与日志时间戳使用同一时钟，StartMaintenance的窗口才能与ent.Time比较
+	clock Clock
+	// until 临时窗口的结束时间(UnixNano)，0表示没有临时窗口，Reload前后共用
+	until *int64
+}
+
+func (c *LogOptions) newMaintenance(prev *maintenance) (*maintenance, error) {
+	m := &maintenance{loc: c.loc, clock: c.entryClock(), until: new(int64)}
+	if prev != nil {
+		m.until = prev.until
+	}
+	for _, w := range c.Maintenance {
+		mw, err := newMaintenanceWindow(w, c.loc)
+		if err != nil {
+			return nil, err
+		}
+		m.windows = append(m.windows, mw)
+	}
+	return m, nil
+}
+
+// action 返回t时output的处理方式，不在任何窗口内时返回空字符串，多个窗口重叠时suppress优先
+func (m *maintenance) action(t time.Time, output string) string {
+	if until := atomic.LoadInt64(m.until); until != 0 && t.UnixNano() < until {
+		return MaintenanceSuppress
+	}
+	t = t.In(m.loc)
+	action := ""
+	for _, w := range m.windows {
+		if w.active(t, output) {
+			if w.action == MaintenanceSuppress {
+				return MaintenanceSuppress
+			}
+			action = w.action
+		}
+	}
+	return action
+}
+
+// maintain 为告警类输出的core加上维护窗口处理
+func (c *LogOptions) maintain(output string, core zapcore.Core) zapcore.Core {
+	if c.maintenance == nil {
+		return core
+	}
+	return &maintenanceCore{Core: core, m: c.maintenance, output: output}
+}
+
+// maintenanceCore 维护窗口内抑制或降级日志
+type maintenanceCore struct {
+	zapcore.Core
+	m      *maintenance
+	output string
+}
+
+func (c *maintenanceCore) With(fs []zapcore.Field) zapcore.Core {
+	return &maintenanceCore{Core: c.Core.With(fs), m: c.m, output: c.output}
+}
+
+func (c *maintenanceCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	level, ok := c.level(ent)
+	if ok && c.Enabled(level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *maintenanceCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	level, ok := c.level(ent)
+	if !ok {
+		return nil
+	}
+	ent.Level = level
+	inner := c.Core.Check(ent, nil)
+	if inner == nil {
+		return nil
+	}
+	inner.ErrorOutput = errorOutput{}
+	inner.Write(fs...)
+	return nil
+}
+
+// level 返回维护窗口处理后的级别，ok为false表示不输出
+func (c *maintenanceCore) level(ent zapcore.Entry) (zapcore.Level, bool) {
+	switch c.m.action(ent.Time, c.output) {
+	case MaintenanceSuppress:
+		return ent.Level, false
+	case MaintenanceDowngrade:
+		if ent.Level > zapcore.DebugLevel {
+			return ent.Level - 1, true
+		}
+	}
+	return ent.Level, true
+}
+
+// StartMaintenance 立即开始持续d的维护窗口，窗口内告警类输出不输出，用于计划内的发布等操作，
+// 开始时间取自SetClock设置的时钟
+func (log *Log) StartMaintenance(d time.Duration) {
+	if log.maintenance != nil {
+		atomic.StoreInt64(log.maintenance.until, log.maintenance.clock.Now().Add(d).UnixNano())
+	}
+}
+
+// EndMaintenance 提前结束StartMaintenance开始的维护窗口，不影响配置的窗口
+func (log *Log) EndMaintenance() {
+	if log.maintenance != nil {
+		atomic.StoreInt64(log.maintenance.until, 0)
+	}
+}
@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowActive(t *testing.T) {
+	loc := time.UTC
+	at := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04:05", s, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	// 每周六2点开始，持续30分钟；2024-06-01是周六
+	weekly := MaintenanceWindow{Schedule: "0 2 * * 6", Duration: 30}
+	once := MaintenanceWindow{From: "2024-06-03T10:00:00Z", To: "2024-06-03T11:00:00Z"}
+	tests := []struct {
+		name   string
+		w      MaintenanceWindow
+		t      time.Time
+		output string
+		want   bool
+	}{
+		{"before start", weekly, at("2024-06-01 01:59:59"), sinkAlert, false},
+		{"at start", weekly, at("2024-06-01 02:00:00"), sinkAlert, true},
+		{"inside", weekly, at("2024-06-01 02:15:00"), sinkAlert, true},
+		{"before end", weekly, at("2024-06-01 02:29:59"), sinkAlert, true},
+		{"at end", weekly, at("2024-06-01 02:30:00"), sinkAlert, false},
+		{"other day", weekly, at("2024-06-02 02:15:00"), sinkAlert, false},
+		{"next week", weekly, at("2024-06-08 02:10:00"), sinkAlert, true},
+		{"once before", once, at("2024-06-03 09:59:59"), sinkAlert, false},
+		{"once from", once, at("2024-06-03 10:00:00"), sinkAlert, true},
+		{"once to", once, at("2024-06-03 11:00:00"), sinkAlert, false},
+		{"output listed", MaintenanceWindow{From: once.From, To: once.To, Outputs: []string{sinkEmail}}, at("2024-06-03 10:30:00"), sinkEmail, true},
+		{"output not listed", MaintenanceWindow{From: once.From, To: once.To, Outputs: []string{sinkEmail}}, at("2024-06-03 10:30:00"), sinkAlert, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := newMaintenanceWindow(tt.w, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := w.active(tt.t, tt.output); got != tt.want {
+				t.Errorf("active(%s) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindowInvalid(t *testing.T) {
+	for _, w := range []MaintenanceWindow{
+		{},
+		{Schedule: "0 2 * * 6"},
+		{Schedule: "someday", Duration: 30},
+		{From: "2024-06-03T10:00:00Z"},
+		{From: "2024-06-03 10:00", To: "2024-06-03T11:00:00Z"},
+		{From: "2024-06-03T10:00:00Z", To: "2024-06-03T11:00:00Z", Action: "mute"},
+	} {
+		if _, err := newMaintenanceWindow(w, time.UTC); err == nil {
+			t.Errorf("%+v: expected an error", w)
+		}
+	}
+}
+
+func TestMaintenanceAction(t *testing.T) {
+	now := time.Date(2024, 6, 3, 10, 30, 0, 0, time.UTC)
+	downgrade := MaintenanceWindow{From: "2024-06-03T10:00:00Z", To: "2024-06-03T11:00:00Z", Action: MaintenanceDowngrade}
+	suppress := MaintenanceWindow{From: "2024-06-03T10:20:00Z", To: "2024-06-03T10:40:00Z"}
+	c := &LogOptions{Maintenance: []MaintenanceWindow{downgrade, suppress}, loc: time.UTC}
+	clock := &manualClock{now: now.Add(-time.Hour)}
+	c.SetClock(clock)
+	m, err := c.newMaintenance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{"outside", now.Add(-time.Hour), ""},
+		{"downgrade only", now.Add(-20 * time.Minute), MaintenanceDowngrade},
+		// 重叠时suppress优先
+		{"overlap", now, MaintenanceSuppress},
+		{"after suppress", now.Add(20 * time.Minute), MaintenanceDowngrade},
+	}
+	for _, tt := range tests {
+		if got := m.action(tt.t, sinkAlert); got != tt.want {
+			t.Errorf("%s: action = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// StartMaintenance的窗口从时钟的当前时间开始
+	log := &Log{maintenance: m}
+	log.StartMaintenance(10 * time.Minute)
+	start := clock.Now()
+	for _, tt := range []struct {
+		t    time.Time
+		want string
+	}{
+		{start, MaintenanceSuppress},
+		{start.Add(10*time.Minute - time.Second), MaintenanceSuppress},
+		{start.Add(10 * time.Minute), ""},
+	} {
+		if got := m.action(tt.t, sinkAlert); got != tt.want {
+			t.Errorf("StartMaintenance: action(%s) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+	log.EndMaintenance()
+	if got := m.action(start, sinkAlert); got != "" {
+		t.Errorf("after EndMaintenance: action = %q", got)
+	}
+}
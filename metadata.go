@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Metadata可选的字段
+const (
+	MetadataHostname     = "hostname"
+	MetadataPid          = "pid"
+	MetadataGoVersion    = "go_version"
+	MetadataPodName      = "pod_name"
+	MetadataPodNamespace = "pod_namespace"
+	MetadataNodeName     = "node_name"
+	MetadataContainerID  = "container_id"
+	// MetadataAll 启用以上所有字段
+	MetadataAll = "all"
+	// MetadataEnvPrefix 以 "env:" 开头时读取对应的环境变量，字段名为小写的变量名，如 "env:REGION"
+	MetadataEnvPrefix = "env:"
+)
+
+var _containerID = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// containerID 从cgroup中读取容器ID，不在容器中运行时返回空
+func containerID() string {
+	for _, path := range []string{"/proc/self/cgroup", "/proc/self/mountinfo"} {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if id := _containerID.Find(b); id != nil {
+			return string(id)
+		}
+	}
+	return ""
+}
+
+// metadataField 生成一个元数据字段，值为空时返回false
+func metadataField(name string) (zapcore.Field, bool, error) {
+	var value string
+	switch name {
+	case MetadataHostname:
+		// 容器中HOSTNAME通常为pod名，优先使用系统主机名
+		value, _ = os.Hostname()
+		if value == "" {
+			value = os.Getenv("HOSTNAME")
+		}
+	case MetadataPid:
+		return zap.Int(MetadataPid, os.Getpid()), true, nil
+	case MetadataGoVersion:
+		value = runtime.Version()
+	case MetadataPodName:
+		value = os.Getenv("POD_NAME")
+	case MetadataPodNamespace:
+		value = os.Getenv("POD_NAMESPACE")
+	case MetadataNodeName:
+		value = os.Getenv("NODE_NAME")
+	case MetadataContainerID:
+		value = containerID()
+	default:
+		if !strings.HasPrefix(name, MetadataEnvPrefix) {
+			return zap.Skip(), false, fmt.Errorf("logger: unknown metadata field %q", name)
+		}
+		env := strings.TrimPrefix(name, MetadataEnvPrefix)
+		return zap.String(strings.ToLower(env), os.Getenv(env)), os.Getenv(env) != "", nil
+	}
+	return zap.String(name, value), value != "", nil
+}
+
+// metadataFields 根据Metadata配置生成主机、进程及容器相关的字段
+func (c *LogOptions) metadataFields() []zapcore.Field {
+	var names []string
+	for _, name := range c.Metadata {
+		if name == MetadataAll {
+			names = append(names, MetadataHostname, MetadataPid, MetadataGoVersion,
+				MetadataPodName, MetadataPodNamespace, MetadataNodeName, MetadataContainerID)
+			continue
+		}
+		names = append(names, name)
+	}
+	var fs []zapcore.Field
+	for _, name := range names {
+		if f, ok := _deterministicMetadata[name]; ok && c.Deterministic {
+			fs = append(fs, f)
+			continue
+		}
+		f, ok, err := metadataField(name)
+		if err != nil {
+			panic(err)
+		}
+		if ok {
+			fs = append(fs, f)
+		}
+	}
+	return fs
+}
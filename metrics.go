@@ -0,0 +1,213 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap/zapcore"
+)
+
+// 输出名称，用作指标的sink标签
+const (
+	sinkConsole   = "console"
+	sinkInfoFile  = "info_file"
+	sinkErrorFile = "error_file"
+	sinkAlert     = "alert"
+	sinkEmail     = "email"
+	sinkIncident  = "incident"
+)
+
+// metrics 日志相关的prometheus指标，通过Log.Collector注册，同时记录各输出的状态供Log.Status使用
+type metrics struct {
+	entries *prometheus.CounterVec
+	bytes   *prometheus.CounterVec
+	dropped *prometheus.CounterVec
+	errors  *prometheus.CounterVec
+	events  *prometheus.CounterVec
+	levels  levelCounter
+	// batchEntries、batchBytes、flushSeconds 网络输出每批的条数、字节数和发送耗时
+	batchEntries *prometheus.HistogramVec
+	batchBytes   *prometheus.HistogramVec
+	flushSeconds *prometheus.HistogramVec
+
+	mu    sync.Mutex
+	sinks map[string]*sinkState
+	order []string
+}
+
+func newMetrics(namespace string) *metrics {
+	if namespace == "" {
+		namespace = "logger"
+	}
+	return &metrics{
+		entries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "entries_total",
+			Help:      "Number of log entries by level and logger name.",
+		}, []string{"level", "logger"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "bytes_written_total",
+			Help:      "Number of bytes written per sink.",
+		}, []string{"sink"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "dropped_entries_total",
+			Help:      "Number of entries dropped by rate limits or full queues per sink.",
+		}, []string{"sink"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sink_errors_total",
+			Help:      "Number of failed writes per sink.",
+		}, []string{"sink"}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reporter_events_total",
+			Help:      "Number of events sent to error reporters such as Sentry.",
+		}, []string{"reporter"}),
+		batchEntries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "batch_entries",
+			Help:      "Number of entries per batch sent to a remote sink.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+		}, []string{"sink"}),
+		batchBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "batch_bytes",
+			Help:      "Size in bytes of each batch sent to a remote sink.",
+			Buckets:   prometheus.ExponentialBuckets(256, 4, 8),
+		}, []string{"sink"}),
+		flushSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "batch_flush_seconds",
+			Help:      "Time taken to send a batch to a remote sink.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"sink"}),
+		sinks: make(map[string]*sinkState),
+	}
+}
+
+func (m *metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.entries.Describe(ch)
+	m.bytes.Describe(ch)
+	m.dropped.Describe(ch)
+	m.errors.Describe(ch)
+	m.events.Describe(ch)
+	m.batchEntries.Describe(ch)
+	m.batchBytes.Describe(ch)
+	m.flushSeconds.Describe(ch)
+}
+
+func (m *metrics) Collect(ch chan<- prometheus.Metric) {
+	m.entries.Collect(ch)
+	m.bytes.Collect(ch)
+	m.dropped.Collect(ch)
+	m.errors.Collect(ch)
+	m.events.Collect(ch)
+	m.batchEntries.Collect(ch)
+	m.batchBytes.Collect(ch)
+	m.flushSeconds.Collect(ch)
+}
+
+// 以下方法允许m为nil，便于单独使用的组件不配置指标
+
+// entry 作为zap.Hooks统计日志条数
+func (m *metrics) entry(ent zapcore.Entry) error {
+	if m != nil {
+		m.entries.WithLabelValues(ent.Level.String(), ent.LoggerName).Inc()
+		m.levels.inc(ent.Level)
+	}
+	return nil
+}
+
+func (m *metrics) written(sink string, n int) {
+	if m != nil {
+		m.bytes.WithLabelValues(sink).Add(float64(n))
+		st := m.sink(sink)
+		st.mu.Lock()
+		st.lastWrite = time.Now()
+		st.mu.Unlock()
+	}
+}
+
+func (m *metrics) drop(sink string) {
+	if m != nil {
+		m.dropped.WithLabelValues(sink).Inc()
+		st := m.sink(sink)
+		st.mu.Lock()
+		st.dropped++
+		st.mu.Unlock()
+	}
+}
+
+func (m *metrics) writeError(sink string, err error) {
+	if m != nil {
+		m.errors.WithLabelValues(sink).Inc()
+		st := m.sink(sink)
+		st.mu.Lock()
+		st.errors++
+		st.lastError = err.Error()
+		st.lastErrorTime = time.Now()
+		st.mu.Unlock()
+	}
+}
+
+// queue 登记输出的队列长度，同名输出的队列长度相加
+func (m *metrics) queue(sink string, depth func() int) {
+	if m != nil {
+		st := m.sink(sink)
+		st.mu.Lock()
+		st.queues = append(st.queues, depth)
+		st.mu.Unlock()
+	}
+}
+
+// batch 记录一次合并发送的条数、字节数和耗时
+func (m *metrics) batch(sink string, entries, size int, d time.Duration) {
+	if m != nil {
+		m.batchEntries.WithLabelValues(sink).Observe(float64(entries))
+		m.batchBytes.WithLabelValues(sink).Observe(float64(size))
+		m.flushSeconds.WithLabelValues(sink).Observe(d.Seconds())
+	}
+}
+
+func (m *metrics) sent(reporter string) {
+	if m != nil {
+		m.events.WithLabelValues(reporter).Inc()
+	}
+}
+
+// countingWriter 统计写入的字节数和失败次数
+type countingWriter struct {
+	io.Writer
+	sink    string
+	metrics *metrics
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := w.Writer.Write(p)
+	w.metrics.observe(w.sink, time.Since(start))
+	if err != nil {
+		w.metrics.writeError(w.sink, err)
+	} else {
+		w.metrics.written(w.sink, n)
+	}
+	return n, err
+}
+
+// countWrites 统计写入w的字节数，w为nil时返回nil
+func (c *LogOptions) countWrites(sink string, w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+	c.metrics.sink(sink)
+	return &countingWriter{Writer: w, sink: sink, metrics: c.metrics}
+}
+
+// Collector 返回日志的prometheus指标，需要由使用方注册，如 prometheus.MustRegister(log.Collector())
+func (log *Log) Collector() prometheus.Collector {
+	return log.metrics
+}
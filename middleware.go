@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	mrand "math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RequestIDHeader 请求ID使用的默认header
+const RequestIDHeader = "X-Request-ID"
+
+type middlewareOptions struct {
+	sampleRate float64
+	header     string
+	trustProxy bool
+	skip       func(r *http.Request) bool
+}
+
+// MiddlewareOption HTTPMiddleware的选项
+type MiddlewareOption func(*middlewareOptions)
+
+// WithSuccessSampling 设置2xx/3xx请求的采样率，取值0~1，默认1全部输出，4xx、5xx总是输出
+func WithSuccessSampling(rate float64) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.sampleRate = math.Max(0, math.Min(1, rate))
+	}
+}
+
+// WithRequestIDHeader 设置读取和返回请求ID的header，默认 X-Request-ID
+func WithRequestIDHeader(header string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.header = header
+	}
+}
+
+// WithTrustProxy 从 X-Forwarded-For、X-Real-IP 中取客户端IP，只在服务部署在可信代理之后时使用
+func WithTrustProxy() MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.trustProxy = true
+	}
+}
+
+// WithSkip 设置不输出访问日志的请求，如健康检查
+func WithSkip(skip func(r *http.Request) bool) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.skip = skip
+	}
+}
+
+// HTTPMiddleware 包装http.Handler，每个请求输出一条访问日志，包含method、path、status、latency、bytes、
+// remote_ip和request_id，请求ID及traceparent/B3链路信息同时保存在请求的context中(见RequestIDFrom、TraceFrom)，5xx使用Error级别，4xx使用Warn级别，其他使用Info级别。
+// 配置了AccessLog时改为写入访问日志文件，不做采样
+func HTTPMiddleware(log *Log, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	o := &middlewareOptions{sampleRate: 1, header: RequestIDHeader}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if o.skip != nil && o.skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			r = withTrace(withRequestID(w, r, o.header))
+
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			if log.access != nil {
+				// 写入失败记录在Status中
+				_ = log.access.write(newAccessRecord(r, rw, start, remoteIP(r, o.trustProxy)))
+				return
+			}
+
+			level := statusLevel(rw.status)
+			if level < zapcore.WarnLevel && o.sampleRate < 1 && mrand.Float64() >= o.sampleRate {
+				return
+			}
+			ce := log.Ctx(r.Context()).L.Check(level, "http request")
+			if ce == nil {
+				return
+			}
+			ce.Write(
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", rw.status),
+				zap.Duration("latency", time.Since(start)),
+				zap.Int64("bytes", rw.bytes),
+				zap.String("remote_ip", remoteIP(r, o.trustProxy)),
+			)
+		})
+	}
+}
+
+func statusLevel(status int) zapcore.Level {
+	switch {
+	case status >= 500:
+		return zapcore.ErrorLevel
+	case status >= 400:
+		return zapcore.WarnLevel
+	}
+	return zapcore.InfoLevel
+}
+
+// NewRequestID 生成16字节随机数的十六进制请求ID
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func remoteIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			if i := strings.IndexByte(xff, ','); i >= 0 {
+				xff = xff[:i]
+			}
+			return strings.TrimSpace(xff)
+		}
+		if ip := r.Header.Get("X-Real-IP"); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// responseWriter 记录响应的状态码和字节数
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("logger: response writer does not support hijacking")
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/strftime"
+)
+
+// RotatePattern 中可用的占位符，{hostname} {pid} {service} {env} 也可用于InfoFilename、ErrorFilename及Audit.Filename
+const (
+	PatternFilename = "{filename}"
+	PatternHostname = "{hostname}"
+	PatternPid      = "{pid}"
+	PatternSeq      = "{seq}"
+	PatternService  = "{service}"
+	PatternEnv      = "{env}"
+)
+
+var _strftimeVerb = regexp.MustCompile(`%[%+A-Za-z]`)
+
+// resolvePlaceholders 替换s中的 {hostname} {pid} {service} {env}，多个实例共用一个目录时文件名互不冲突，
+// {service} 依次取Fields中的service、环境变量SERVICE_NAME、可执行文件名，
+// {env} 依次取Fields中的env、SentryConfig.Environment、环境变量APP_ENV
+func (c *LogOptions) resolvePlaceholders(s string) string {
+	if !strings.Contains(s, "{") {
+		return s
+	}
+	hostname, _ := os.Hostname()
+	return strings.NewReplacer(
+		PatternHostname, hostname,
+		PatternPid, strconv.Itoa(os.Getpid()),
+		PatternService, firstNonEmpty(c.fieldString("service"), os.Getenv("SERVICE_NAME"), filepath.Base(os.Args[0])),
+		PatternEnv, firstNonEmpty(c.fieldString("env"), c.SentryConfig.Environment, os.Getenv("APP_ENV")),
+	).Replace(s)
+}
+
+func (c *LogOptions) fieldString(key string) string {
+	if v, ok := c.Fields[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// filePattern 切割后的文件命名规则，支持strftime格式及 {filename} {hostname} {pid} {seq} 占位符
+type filePattern struct {
+	resolved string // 已替换{filename}、{hostname}、{pid}，保留{seq}
+	layout   *strftime.Strftime
+	seq      bool
+}
+
+func newFilePattern(pattern, filename string) (*filePattern, error) {
+	hostname, _ := os.Hostname()
+	resolved := strings.NewReplacer(
+		PatternFilename, filename,
+		PatternHostname, hostname,
+		PatternPid, strconv.Itoa(os.Getpid()),
+	).Replace(pattern)
+	// 用不含%的占位字符代替{seq}，格式化后再替换为序号
+	layout, err := strftime.New(strings.Replace(resolved, PatternSeq, "\x00", -1))
+	if err != nil {
+		return nil, err
+	}
+	return &filePattern{
+		resolved: resolved,
+		layout:   layout,
+		seq:      strings.Contains(resolved, PatternSeq),
+	}, nil
+}
+
+// strftimePattern 返回交给rotatelogs的格式，rotatelogs自行在文件名后追加序号，因此去掉{seq}
+func (p *filePattern) strftimePattern() string {
+	return strings.Replace(p.resolved, PatternSeq, "", -1)
+}
+
+// glob 匹配所有按该规则生成的文件
+func (p *filePattern) glob() string {
+	return _strftimeVerb.ReplaceAllString(strings.Replace(p.resolved, PatternSeq, "*", -1), "*")
+}
+
+// name 生成t时刻不与已有文件冲突的文件名，有{seq}时在已有最大序号上递增，否则冲突时追加.N
+func (p *filePattern) name(t time.Time) string {
+	formatted := p.layout.FormatString(t)
+	start := 1
+	if p.seq {
+		start = p.maxSeq(formatted) + 1
+	}
+	for seq := start; ; seq++ {
+		var name string
+		switch {
+		case p.seq:
+			name = strings.Replace(formatted, "\x00", strconv.Itoa(seq), -1)
+		case seq == 1:
+			name = formatted
+		default:
+			name = formatted + "." + strconv.Itoa(seq-1)
+		}
+		if !fileExists(name) && !fileExists(name+_compressSuffix[CompressionGzip]) && !fileExists(name+_compressSuffix[CompressionZstd]) {
+			return name
+		}
+	}
+}
+
+// maxSeq 返回formatted对应的已有文件中最大的序号
+func (p *filePattern) maxSeq(formatted string) int {
+	parts := strings.SplitN(formatted, "\x00", 2)
+	matches, _ := filepath.Glob(strings.Replace(formatted, "\x00", "*", -1) + "*")
+	max := 0
+	for _, m := range matches {
+		rest := strings.TrimPrefix(m, parts[0])
+		end := 0
+		for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+			end++
+		}
+		if n, err := strconv.Atoi(rest[:end]); err == nil && n > max {
+			max = n
+		}
+	}
+	return max
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,8 @@
+package logger
+
+import "go.uber.org/zap/zaptest/observer"
+
+// ObservedLogs 返回测试模式下记录的日志，未开启TestMode时返回nil
+func (log *Log) ObservedLogs() *observer.ObservedLogs {
+	return log.observed
+}
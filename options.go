@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Option New的配置项，配置不合法时返回错误
+type Option func(*LogOptions) error
+
+// WithEncoding 设置编码格式，如 "console"、"json"
+func WithEncoding(encoding string) Option {
+	return func(c *LogOptions) error {
+		if _, ok := _encoderNameToConstructor[encoding]; !ok {
+			return fmt.Errorf("logger: unknown encoding %q", encoding)
+		}
+		c.Encoding = encoding
+		return nil
+	}
+}
+
+// WithLevel 设置输出的最低级别，如 "debug"、"info"、"warn"
+func WithLevel(level string) Option {
+	return func(c *LogOptions) error {
+		var l zapcore.Level
+		if err := unmarshalLevel(&l, level); err != nil {
+			return fmt.Errorf("logger: invalid level %q: %v", level, err)
+		}
+		c.Level = int8(l)
+		return nil
+	}
+}
+
+// WithInfoFile 设置日志文件
+func WithInfoFile(path string) Option {
+	return func(c *LogOptions) error {
+		c.InfoFilename = path
+		return nil
+	}
+}
+
+// WithErrorFile 设置warn及以上级别的日志文件，同时开启LevelSeparate
+func WithErrorFile(path string) Option {
+	return func(c *LogOptions) error {
+		c.LevelSeparate = true
+		c.ErrorFilename = path
+		return nil
+	}
+}
+
+// WithDivision 设置切割方式，可选 "time"、"size"、"hybrid"
+func WithDivision(division string) Option {
+	return func(c *LogOptions) error {
+		switch division {
+		case TimeDivision, SizeDivision, HybridDivision:
+			c.Division = division
+			return nil
+		}
+		return fmt.Errorf("logger: unknown division %q", division)
+	}
+}
+
+// WithTimeUnit 设置按时间切割的间隔
+func WithTimeUnit(t TimeUnit) Option {
+	return func(c *LogOptions) error {
+		c.TimeUnit = t
+		return nil
+	}
+}
+
+// WithCompression 设置切割文件的压缩算法和级别
+func WithCompression(algorithm string, level int) Option {
+	return func(c *LogOptions) error {
+		switch algorithm {
+		case CompressionGzip, CompressionZstd, CompressionNone:
+			c.Compression = algorithm
+			c.CompressionLevel = level
+			return nil
+		}
+		return fmt.Errorf("logger: unknown compression %q", algorithm)
+	}
+}
+
+// WithCompressionWorkers 设置同时压缩的文件数
+func WithCompressionWorkers(n int) Option {
+	return func(c *LogOptions) error {
+		if n < 0 {
+			return fmt.Errorf("logger: compression workers must not be negative")
+		}
+		c.CompressionWorkers = n
+		return nil
+	}
+}
+
+// WithTimeZone 设置时区
+func WithTimeZone(name string) Option {
+	return func(c *LogOptions) error {
+		if _, err := time.LoadLocation(name); err != nil {
+			return fmt.Errorf("logger: invalid time zone %q: %v", name, err)
+		}
+		c.TimeZone = name
+		return nil
+	}
+}
+
+// WithCaller 输出调用位置，skip为额外跳过的调用层数
+func WithCaller(skip int) Option {
+	return func(c *LogOptions) error {
+		c.caller = true
+		c.skip = skip
+		return nil
+	}
+}
+
+// WithStacktrace 为level及以上级别的日志输出调用栈
+func WithStacktrace(level string) Option {
+	return func(c *LogOptions) error {
+		var l zapcore.Level
+		if err := unmarshalLevel(&l, level); err != nil {
+			return fmt.Errorf("logger: invalid stacktrace level %q: %v", level, err)
+		}
+		c.Stacktrace = true
+		c.StacktraceLevel = level
+		return nil
+	}
+}
+
+// WithSentry 设置sentry上报
+func WithSentry(cfg SentryLoggerConfig) Option {
+	return func(c *LogOptions) error {
+		c.SentryConfig = cfg
+		return nil
+	}
+}
+
+// WithMode 设置运行模式，可选 "production"、"development"
+func WithMode(mode string) Option {
+	return func(c *LogOptions) error {
+		switch strings.ToLower(mode) {
+		case ModeProduction, ModeDevelopment:
+		default:
+			return fmt.Errorf("logger: unknown mode %q", mode)
+		}
+		c.Mode = mode
+		return nil
+	}
+}
+
+// WithoutConsole 不输出到控制台
+func WithoutConsole() Option {
+	return func(c *LogOptions) error {
+		c.CloseDisplay = 1
+		return nil
+	}
+}
+
+// WithZapOptions 追加额外的zap选项，见LogOptions.ZapOptions
+func WithZapOptions(opts ...zap.Option) Option {
+	return func(c *LogOptions) error {
+		c.ZapOptions = append(c.ZapOptions, opts...)
+		return nil
+	}
+}
@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// RecoverAndLog 恢复panic并输出带堆栈的Error日志(同时上报sentry)，需要直接defer调用：
+//
+//	go func() {
+//		defer logger.RecoverAndLog(log, false)
+//		...
+//	}()
+//
+// repanic为true时输出日志并等待上报完成后继续panic
+func RecoverAndLog(log *Log, repanic bool) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	fields := []zap.Field{zap.String("panic", fmt.Sprint(r)), zap.Stack("stacktrace")}
+	if err, ok := r.(error); ok {
+		fields = append(fields, zap.Error(err))
+	}
+	log.L.Error("panic recovered", fields...)
+	if repanic {
+		log.L.Sync()
+		panic(r)
+	}
+}
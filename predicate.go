@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// EntryFilter 按字段条件过滤一个输出的日志，表达式格式为 `字段 运算符 值`，多个条件用 && 连接，如
+//
+//	http_path == "/healthz"
+//	tenant != "prod" && level < error
+//
+// 运算符支持 ==、!=、=~(正则匹配)、!~、>、>=、<、<=，值可以是带引号的字符串、数字、true/false，
+// 字段名 level、msg、logger 分别表示日志级别、消息和日志名称
+type EntryFilter struct {
+	// Drop 匹配任一表达式的日志不输出
+	Drop []string `toml:"drop" yaml:"drop" json:"drop"`
+	// Keep 只输出匹配任一表达式的日志，为空时不限制
+	Keep []string `toml:"keep" yaml:"keep" json:"keep"`
+}
+
+var _condition = regexp.MustCompile(`^\s*([\w.\-]+)\s*(==|!=|=~|!~|>=|<=|>|<)\s*(.*?)\s*$`)
+
+type condition struct {
+	field string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+// predicate 由 && 连接的条件
+type predicate []condition
+
+func parsePredicate(expr string) (predicate, error) {
+	var p predicate
+	for _, part := range strings.Split(expr, "&&") {
+		m := _condition.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("logger: invalid filter expression %q", expr)
+		}
+		cond := condition{field: m[1], op: m[2], value: m[3]}
+		if strings.HasPrefix(cond.value, `"`) {
+			v, err := strconv.Unquote(cond.value)
+			if err != nil {
+				return nil, fmt.Errorf("logger: invalid filter expression %q: %v", expr, err)
+			}
+			cond.value = v
+		}
+		if cond.op == "=~" || cond.op == "!~" {
+			re, err := regexp.Compile(cond.value)
+			if err != nil {
+				return nil, fmt.Errorf("logger: invalid filter expression %q: %v", expr, err)
+			}
+			cond.re = re
+		}
+		p = append(p, cond)
+	}
+	return p, nil
+}
+
+func (c condition) match(ent zapcore.Entry, fields map[string]interface{}) bool {
+	var actual string
+	switch c.field {
+	case "level":
+		var want zapcore.Level
+		if err := unmarshalLevel(&want, c.value); err == nil {
+			return compareOrdered(int(ent.Level), int(want), c.op)
+		}
+		actual = ent.Level.String()
+	case "msg":
+		actual = ent.Message
+	case "logger":
+		actual = ent.LoggerName
+	default:
+		v, ok := fields[c.field]
+		if !ok {
+			// 字段不存在时只有不等条件成立
+			return c.op == "!=" || c.op == "!~"
+		}
+		actual = fmt.Sprint(v)
+	}
+
+	switch c.op {
+	case "=~":
+		return c.re.MatchString(actual)
+	case "!~":
+		return !c.re.MatchString(actual)
+	}
+	a, errA := strconv.ParseFloat(actual, 64)
+	b, errB := strconv.ParseFloat(c.value, 64)
+	if errA == nil && errB == nil {
+		switch c.op {
+		case "==":
+			return a == b
+		case "!=":
+			return a != b
+		case ">":
+			return a > b
+		case ">=":
+			return a >= b
+		case "<":
+			return a < b
+		case "<=":
+			return a <= b
+		}
+	}
+	switch c.op {
+	case "==":
+		return actual == c.value
+	case "!=":
+		return actual != c.value
+	}
+	return compareOrdered(strings.Compare(actual, c.value), 0, c.op)
+}
+
+func compareOrdered(a, b int, op string) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return false
+}
+
+func (p predicate) match(ent zapcore.Entry, fields map[string]interface{}) bool {
+	for _, c := range p {
+		if !c.match(ent, fields) {
+			return false
+		}
+	}
+	return true
+}
+
+type entryFilter struct {
+	drop []predicate
+	keep []predicate
+}
+
+func newEntryFilter(f EntryFilter) (*entryFilter, error) {
+	ef := &entryFilter{}
+	for _, expr := range f.Drop {
+		p, err := parsePredicate(expr)
+		if err != nil {
+			return nil, err
+		}
+		ef.drop = append(ef.drop, p)
+	}
+	for _, expr := range f.Keep {
+		p, err := parsePredicate(expr)
+		if err != nil {
+			return nil, err
+		}
+		ef.keep = append(ef.keep, p)
+	}
+	return ef, nil
+}
+
+func (f *entryFilter) allow(ent zapcore.Entry, fields map[string]interface{}) bool {
+	for _, p := range f.drop {
+		if p.match(ent, fields) {
+			return false
+		}
+	}
+	if len(f.keep) == 0 {
+		return true
+	}
+	for _, p := range f.keep {
+		if p.match(ent, fields) {
+			return true
+		}
+	}
+	return false
+}
+
+// predicateCore 按EntryFilter决定日志是否交给内部core
+type predicateCore struct {
+	zapcore.Core
+	filter *entryFilter
+	fields map[string]interface{}
+}
+
+func (c *predicateCore) With(fs []zapcore.Field) zapcore.Core {
+	return &predicateCore{Core: c.Core.With(fs), filter: c.filter, fields: mergeFields(c.fields, fs)}
+}
+
+func (c *predicateCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *predicateCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	fields := c.fields
+	if len(fs) > 0 {
+		fields = mergeFields(c.fields, fs)
+	}
+	if !c.filter.allow(ent, fields) {
+		return nil
+	}
+	inner := c.Core.Check(ent, nil)
+	if inner == nil {
+		return nil
+	}
+	inner.ErrorOutput = errorOutput{}
+	inner.Write(fs...)
+	return nil
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestPredicate(t *testing.T) {
+	ent := zapcore.Entry{Level: zapcore.WarnLevel, Message: "request done", LoggerName: "http"}
+	fields := map[string]interface{}{
+		"http_path": "/healthz",
+		"status":    503,
+		"latency":   1.5,
+		"tenant":    "prod",
+		"cached":    true,
+	}
+	for _, tc := range []struct {
+		expr string
+		want bool
+	}{
+		{`http_path == "/healthz"`, true},
+		{`http_path != "/healthz"`, false},
+		{`http_path =~ "^/health"`, true},
+		{`http_path !~ "^/health"`, false},
+		{`status >= 500`, true},
+		{`status < 500`, false},
+		{`status == 503.0`, true},
+		{`latency > 1`, true},
+		{`latency <= 1`, false},
+		{`cached == true`, true},
+		{`tenant == prod`, true},
+		{`tenant > "dev"`, true},
+		{`level == warn`, true},
+		{`level < error`, true},
+		{`level >= error`, false},
+		{`msg =~ "done$"`, true},
+		{`logger == "http"`, true},
+		// 字段不存在时只有不等条件成立
+		{`missing == "x"`, false},
+		{`missing != "x"`, true},
+		{`missing !~ "x"`, true},
+		{`status >= 500 && tenant == "prod"`, true},
+		{`status >= 500 && tenant == "dev"`, false},
+	} {
+		p, err := parsePredicate(tc.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tc.expr, err)
+			continue
+		}
+		if got := p.match(ent, fields); got != tc.want {
+			t.Errorf("%s: match = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestPredicateInvalid(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`status`,
+		`== 500`,
+		`http_path == "/healthz`,
+		`http_path =~ "("`,
+		`status >= 500 &&`,
+	} {
+		if _, err := parsePredicate(expr); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
+
+func TestEntryFilter(t *testing.T) {
+	f, err := newEntryFilter(EntryFilter{
+		Drop: []string{`http_path == "/healthz"`},
+		Keep: []string{`level >= warn`, `tenant == "vip"`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		level  zapcore.Level
+		fields map[string]interface{}
+		want   bool
+	}{
+		{zapcore.ErrorLevel, map[string]interface{}{"http_path": "/healthz"}, false},
+		{zapcore.ErrorLevel, map[string]interface{}{"http_path": "/api"}, true},
+		{zapcore.InfoLevel, map[string]interface{}{"http_path": "/api"}, false},
+		{zapcore.InfoLevel, map[string]interface{}{"tenant": "vip"}, true},
+	} {
+		if got := f.allow(zapcore.Entry{Level: tc.level}, tc.fields); got != tc.want {
+			t.Errorf("%s %v: allow = %v, want %v", tc.level, tc.fields, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// ProfileEnv 选择配置文件中profile的环境变量，优先级低于显式传入的profile，高于配置文件中的profile
+const ProfileEnv = "LOG_PROFILE"
+
+// activeProfile 按 显式传入 > 环境变量 > 配置文件 的顺序选择profile
+func activeProfile(profile, fileProfile string) string {
+	if profile != "" {
+		return profile
+	}
+	if env := os.Getenv(ProfileEnv); env != "" {
+		return env
+	}
+	return fileProfile
+}
+
+func unknownProfile(profile string) error {
+	return fmt.Errorf("logger: unknown profile %q", profile)
+}
+
+// NewFromTomlProfile 读取toml配置，profiles下与profile同名的配置覆盖顶层配置，profile为空时依次使用ProfileEnv和配置中的profile
+//
+//	level = 0
+//	profile = "dev"
+//
+//	[profiles.dev]
+//	encoding = "console"
+//	level = -1
+//
+//	[profiles.prod]
+//	encoding = "json"
+//	level = 1
+func NewFromTomlProfile(confPath, profile string) *LogOptions {
+	file, err := ioutil.ReadFile(confPath)
+	if err != nil {
+		panic(err)
+	}
+	c, err := decodeConfig(file, FormatToml, profile)
+	if err != nil {
+		panic(err)
+	}
+	c.loadEnv()
+	return c
+}
+
+// NewFromYamlProfile 读取yaml配置，profiles下与profile同名的配置覆盖顶层配置，profile为空时依次使用ProfileEnv和配置中的profile
+//
+//	level: 0
+//	profile: dev
+//	profiles:
+//	  dev:
+//	    encoding: console
+//	    level: -1
+//	  prod:
+//	    encoding: json
+//	    level: 1
+//	    sentry_config:
+//	      dsn: "..."
+func NewFromYamlProfile(confPath, profile string) *LogOptions {
+	return newFromFile(confPath, FormatYaml, profile)
+}
+
+// NewFromJsonProfile 读取json配置，profiles下与profile同名的配置覆盖顶层配置，profile为空时依次使用ProfileEnv和配置中的profile
+func NewFromJsonProfile(confPath, profile string) *LogOptions {
+	return newFromFile(confPath, FormatJson, profile)
+}
+
+// newFromFile 读取yaml或json配置，出错时输出错误并返回已解析的部分
+func newFromFile(confPath, format, profile string) *LogOptions {
+	file, err := ioutil.ReadFile(confPath)
+	if err != nil {
+		fmt.Printf("yamlFile.Get err   #%v ", err)
+		return nil
+	}
+	c, err := decodeConfig(file, format, profile)
+	if err != nil {
+		fmt.Printf("error: %v", err)
+		return c
+	}
+	c.loadEnv()
+	return c
+}
+
+// loadEnv 应用环境变量，环境变量不合法时保留配置文件中的值
+func (c *LogOptions) loadEnv() {
+	if err := c.ApplyEnv(); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// applyProfile 用profiles下与profile同名的配置覆盖c
+func (c *LogOptions) applyProfile(b []byte, format, profile string) error {
+	if c.Profile = activeProfile(profile, c.Profile); c.Profile == "" {
+		return nil
+	}
+	ok, err := c.overlay(b, format, "profiles", c.Profile)
+	if err == nil && !ok {
+		err = unknownProfile(c.Profile)
+	}
+	return err
+}
+
+// overlay 用b中key下名为name的配置覆盖c中对应的配置，返回是否存在该配置
+func (c *LogOptions) overlay(b []byte, format, key, name string) (bool, error) {
+	switch format {
+	case FormatToml:
+		md, sections, err := tomlSections(b, key)
+		if err != nil {
+			return false, err
+		}
+		p, ok := sections[name]
+		if !ok {
+			return false, nil
+		}
+		return true, md.PrimitiveDecode(p, c)
+	case FormatYaml:
+		sections, err := yamlSections(b, key)
+		if err != nil {
+			return false, err
+		}
+		p, ok := sections[name]
+		if !ok {
+			return false, nil
+		}
+		section, err := yaml.Marshal(p)
+		if err != nil {
+			return false, err
+		}
+		return true, yaml.Unmarshal(section, c)
+	case FormatJson:
+		sections, err := jsonSections(b, key)
+		if err != nil {
+			return false, err
+		}
+		p, ok := sections[name]
+		if !ok {
+			return false, nil
+		}
+		return true, json.Unmarshal(p, c)
+	}
+	return false, fmt.Errorf("logger: unknown config format %q", format)
+}
+
+// sectionNames 返回b中key下所有配置的名称
+func sectionNames(b []byte, format, key string) ([]string, error) {
+	var names []string
+	switch format {
+	case FormatToml:
+		_, sections, err := tomlSections(b, key)
+		if err != nil {
+			return nil, err
+		}
+		for name := range sections {
+			names = append(names, name)
+		}
+	case FormatYaml:
+		sections, err := yamlSections(b, key)
+		if err != nil {
+			return nil, err
+		}
+		for name := range sections {
+			names = append(names, fmt.Sprint(name))
+		}
+	case FormatJson:
+		sections, err := jsonSections(b, key)
+		if err != nil {
+			return nil, err
+		}
+		for name := range sections {
+			names = append(names, name)
+		}
+	default:
+		return nil, fmt.Errorf("logger: unknown config format %q", format)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func tomlSections(b []byte, key string) (toml.MetaData, map[string]toml.Primitive, error) {
+	var top map[string]toml.Primitive
+	md, err := toml.Decode(string(b), &top)
+	if err != nil {
+		return md, nil, err
+	}
+	var sections map[string]toml.Primitive
+	if p, ok := top[key]; ok {
+		err = md.PrimitiveDecode(p, &sections)
+	}
+	return md, sections, err
+}
+
+func yamlSections(b []byte, key string) (map[interface{}]interface{}, error) {
+	var top map[string]interface{}
+	if err := yaml.Unmarshal(b, &top); err != nil {
+		return nil, err
+	}
+	sections, _ := top[key].(map[interface{}]interface{})
+	return sections, nil
+}
+
+func jsonSections(b []byte, key string) (map[string]json.RawMessage, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(b, &top); err != nil {
+		return nil, err
+	}
+	var sections map[string]json.RawMessage
+	if p, ok := top[key]; ok {
+		if err := json.Unmarshal(p, &sections); err != nil {
+			return nil, err
+		}
+	}
+	return sections, nil
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// FieldProvider 从ctx中取出需要附加到日志的字段，如语言、功能开关、分片等请求范围的数据
+type FieldProvider func(ctx context.Context) []zap.Field
+
+var (
+	_providersMu sync.RWMutex
+	_providers   []FieldProvider
+)
+
+// RegisterFieldProvider 注册FieldProvider，通过Log.Ctx输出的日志会带上所有provider返回的字段，
+// 框架可以在这里统一注入请求范围的数据，不需要每个调用方手动添加
+func RegisterFieldProvider(p FieldProvider) {
+	_providersMu.Lock()
+	defer _providersMu.Unlock()
+	_providers = append(_providers, p)
+}
+
+// contextFields 返回ctx中的请求ID、链路信息、worker标签和所有FieldProvider的字段
+func contextFields(ctx context.Context) []zap.Field {
+	var fields []zap.Field
+	if id, ok := RequestIDFrom(ctx); ok {
+		fields = append(fields, zap.String("request_id", id))
+	}
+	if tc, ok := TraceFrom(ctx); ok {
+		fields = append(fields, zap.String("trace_id", tc.TraceID), zap.String("span_id", tc.SpanID))
+	}
+	if label, ok := WorkerFrom(ctx); ok {
+		fields = append(fields, zap.String("worker", label))
+	}
+	_providersMu.RLock()
+	providers := _providers
+	_providersMu.RUnlock()
+	for _, p := range providers {
+		fields = append(fields, p(ctx)...)
+	}
+	return fields
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter 按key限制每个时间窗口内允许通过的次数，并统计被丢弃的次数
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	entries map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		entries: make(map[string]*rateWindow),
+	}
+}
+
+// allow 判断key在当前窗口内是否允许通过，通过时返回此前被丢弃的次数并清零
+func (r *rateLimiter) allow(key string, now time.Time) (bool, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.entries[key]
+	if !ok {
+		if len(r.entries) >= 1024 {
+			r.sweep(now)
+		}
+		w = &rateWindow{start: now}
+		r.entries[key] = w
+	}
+	if now.Sub(w.start) >= r.window {
+		w.start = now
+		w.count = 0
+	}
+	if w.count >= r.limit {
+		w.suppressed++
+		return false, 0
+	}
+	w.count++
+	suppressed := w.suppressed
+	w.suppressed = 0
+	return true, suppressed
+}
+
+// sweep 清理已过期且没有待上报丢弃次数的key
+func (r *rateLimiter) sweep(now time.Time) {
+	for key, w := range r.entries {
+		if now.Sub(w.start) >= r.window && w.suppressed == 0 {
+			delete(r.entries, key)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 脱敏方式
+const (
+	// RedactFull 整体替换为 "******"
+	RedactFull = "full"
+	// RedactPartial 只保留最后4个字符，如 "************1234"
+	RedactPartial = "partial"
+	// RedactHash 替换为sha256摘要的前16位，相同的值脱敏后仍然相同，便于关联排查
+	RedactHash = "hash"
+)
+
+const _redactMask = "******"
+
+// RedactRule 对指定字段进行脱敏，在写入文件、上报sentry等之前完成，敏感数据不会落盘或发送到第三方
+type RedactRule struct {
+	// Fields 需要脱敏的字段名，不区分大小写，如 ["password", "token", "ssn", "card_number"]
+	Fields []string `toml:"fields" yaml:"fields" json:"fields"`
+	// Strategy 脱敏方式，可选 "full"、"partial"、"hash"，默认 "full"
+	Strategy string `toml:"strategy" yaml:"strategy" json:"strategy"`
+	// Salt hash方式使用的盐
+	Salt string `toml:"salt" yaml:"salt" json:"salt"`
+}
+
+// redactor 字段名到脱敏规则的映射
+type redactor map[string]RedactRule
+
+func newRedactor(rules []RedactRule) (redactor, error) {
+	r := make(redactor)
+	for _, rule := range rules {
+		switch rule.Strategy {
+		case "":
+			rule.Strategy = RedactFull
+		case RedactFull, RedactPartial, RedactHash:
+		default:
+			return nil, fmt.Errorf("logger: unknown redact strategy %q", rule.Strategy)
+		}
+		for _, name := range rule.Fields {
+			r[strings.ToLower(name)] = rule
+		}
+	}
+	return r, nil
+}
+
+func (r redactor) mask(rule RedactRule, s string) string {
+	switch rule.Strategy {
+	case RedactPartial:
+		if len(s) <= 4 {
+			return _redactMask
+		}
+		return strings.Repeat("*", len(s)-4) + s[len(s)-4:]
+	case RedactHash:
+		sum := sha256.Sum256([]byte(rule.Salt + s))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	default:
+		return _redactMask
+	}
+}
+
+// redactFields 返回脱敏后的fields，没有需要脱敏的字段时返回原slice
+func (r redactor) redactFields(fs []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fs {
+		redacted, ok := r.redactField(f)
+		if !ok {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, i, len(fs))
+			copy(out, fs[:i])
+		}
+		out = append(out, redacted)
+	}
+	if out == nil {
+		return fs
+	}
+	return out
+}
+
+func (r redactor) redactField(f zapcore.Field) (zapcore.Field, bool) {
+	rule, ok := r[strings.ToLower(f.Key)]
+	if !ok {
+		// zap.Any传入的map逐层检查其中的key
+		if m, isMap := f.Interface.(map[string]interface{}); isMap && f.Type == zapcore.ReflectType {
+			if redacted, changed := r.redactMap(m); changed {
+				return zap.Any(f.Key, redacted), true
+			}
+		}
+		return f, false
+	}
+	value, ok := fieldString(f)
+	if !ok {
+		return f, false
+	}
+	return zap.String(f.Key, r.mask(rule, value)), true
+}
+
+func (r redactor) redactMap(m map[string]interface{}) (map[string]interface{}, bool) {
+	var out map[string]interface{}
+	for k, v := range m {
+		nv, changed := v, false
+		if rule, ok := r[strings.ToLower(k)]; ok {
+			nv, changed = r.mask(rule, fmt.Sprint(v)), true
+		} else if sub, isMap := v.(map[string]interface{}); isMap {
+			nv, changed = r.redactMap(sub)
+		}
+		if !changed {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]interface{}, len(m))
+			for k2, v2 := range m {
+				out[k2] = v2
+			}
+		}
+		out[k] = nv
+	}
+	return out, out != nil
+}
+
+// fieldString 将常见类型的字段转换为字符串，对象、数组等复杂类型返回false
+func fieldString(f zapcore.Field) (string, bool) {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String, true
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		return string(f.Interface.([]byte)), true
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return fmt.Sprint(f.Integer), true
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return fmt.Sprint(uint64(f.Integer)), true
+	case zapcore.Float64Type:
+		return fmt.Sprint(math.Float64frombits(uint64(f.Integer))), true
+	case zapcore.Float32Type:
+		return fmt.Sprint(math.Float32frombits(uint32(f.Integer))), true
+	case zapcore.DurationType:
+		return time.Duration(f.Integer).String(), true
+	case zapcore.StringerType, zapcore.ReflectType, zapcore.ErrorType:
+		if f.Interface == nil {
+			return "", false
+		}
+		return fmt.Sprint(f.Interface), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestRedact(t *testing.T) {
+	c := New(WithoutConsole())
+	c.TestMode = true
+	c.Redact = []RedactRule{
+		{Fields: []string{"password"}},
+		{Fields: []string{"Card_Number"}, Strategy: RedactPartial},
+		{Fields: []string{"email"}, Strategy: RedactHash, Salt: "s"},
+	}
+	log := c.InitLoggerWith(EncoderOptions{})
+	log.Info("login",
+		zap.String("user", "alice"),
+		zap.String("password", "hunter2"),
+		zap.String("card_number", "4111111111111234"),
+		zap.String("email", "alice@example.com"),
+		zap.Any("extra", map[string]interface{}{"nested": map[string]interface{}{"PASSWORD": "x"}}),
+	)
+	log.Info("again", zap.String("email", "alice@example.com"))
+
+	entries := log.ObservedLogs().All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["user"] != "alice" {
+		t.Errorf("user = %v, want unchanged", fields["user"])
+	}
+	if fields["password"] != _redactMask {
+		t.Errorf("password = %v, want %s", fields["password"], _redactMask)
+	}
+	if fields["card_number"] != "************1234" {
+		t.Errorf("card_number = %v", fields["card_number"])
+	}
+	email, _ := fields["email"].(string)
+	if !strings.HasPrefix(email, "sha256:") || strings.Contains(email, "alice") {
+		t.Errorf("email = %v, want sha256 digest", email)
+	}
+	// hash方式相同的值脱敏后仍然相同
+	if again := entries[1].ContextMap()["email"]; again != email {
+		t.Errorf("email hashed to %v and %v", email, again)
+	}
+	nested := fields["extra"].(map[string]interface{})["nested"].(map[string]interface{})
+	if nested["PASSWORD"] != _redactMask {
+		t.Errorf("nested password = %v", nested["PASSWORD"])
+	}
+}
+
+func TestRedactGlobalFields(t *testing.T) {
+	c := New(WithoutConsole())
+	c.TestMode = true
+	c.Redact = []RedactRule{{Fields: []string{"password", "token"}}}
+	c.Fields = map[string]interface{}{"password": "hunter2", "service": "api"}
+	log := c.InitLoggerWith(EncoderOptions{})
+	log.Info("configured")
+	log.SetGlobalFields(zap.String("token", "secret-token"))
+	log.L.With(zap.String("password", "derived")).Info("derived")
+
+	// 配置和SetGlobalFields设置的全局字段同样被脱敏
+	entries := log.ObservedLogs().All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	configured, derived := entries[0].ContextMap(), entries[1].ContextMap()
+	if configured["password"] != _redactMask || configured["service"] != "api" {
+		t.Errorf("configured fields = %v", configured)
+	}
+	if derived["token"] != _redactMask || derived["password"] != _redactMask {
+		t.Errorf("derived fields = %v", derived)
+	}
+}
+
+func TestRedactUnknownStrategy(t *testing.T) {
+	if _, err := newRedactor([]RedactRule{{Fields: []string{"token"}, Strategy: "drop"}}); err == nil {
+		t.Fatal("unknown strategy should fail")
+	}
+}
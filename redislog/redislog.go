@@ -0,0 +1,202 @@
+// Package redislog 提供通过 logger.Log 输出 go-redis 命令日志的 redis.Hook
+package redislog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/mae-pax/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted 替换被隐藏参数的占位符
+const Redacted = "[REDACTED]"
+
+// Hook 输出redis命令、耗时和错误的redis.Hook，出错的命令使用Error级别，
+// 超过慢命令阈值的命令使用Warn级别，其他命令使用Hook的级别
+type Hook struct {
+	log     *logger.Log
+	level   zapcore.Level
+	slow    time.Duration
+	args    bool
+	maxArgs int
+	redact  map[string]bool
+}
+
+// Option NewHook的选项
+type Option func(*Hook)
+
+// WithLevel 设置普通命令的日志级别，默认Debug
+func WithLevel(level zapcore.Level) Option {
+	return func(h *Hook) {
+		h.level = level
+	}
+}
+
+// WithSlowThreshold 设置慢命令阈值，耗时超过阈值的命令使用Warn级别输出，默认100ms，0表示不区分慢命令
+func WithSlowThreshold(d time.Duration) Option {
+	return func(h *Hook) {
+		h.slow = d
+	}
+}
+
+// WithArgs 输出命令参数，默认只输出命令名称，maxArgs限制输出的参数个数，0表示不限制
+func WithArgs(maxArgs int) Option {
+	return func(h *Hook) {
+		h.args = true
+		h.maxArgs = maxArgs
+	}
+}
+
+// WithRedactCommands 设置需要隐藏参数的命令，这些命令只输出第一个参数(通常是key)，
+// 其余参数替换为 [REDACTED]，AUTH和HELLO的参数总是全部隐藏
+func WithRedactCommands(cmds ...string) Option {
+	return func(h *Hook) {
+		for _, cmd := range cmds {
+			h.redact[strings.ToLower(cmd)] = true
+		}
+	}
+}
+
+// NewHook 创建Hook，使用 client.AddHook(redislog.NewHook(log)) 注册
+func NewHook(log *logger.Log, opts ...Option) *Hook {
+	h := &Hook{
+		log:    log,
+		level:  zapcore.DebugLevel,
+		slow:   100 * time.Millisecond,
+		redact: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// DialHook 输出建立连接失败的错误
+func (h *Hook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			h.log.Ctx(ctx).L.Error("redis dial failed",
+				zap.String("network", network),
+				zap.String("addr", addr),
+				zap.Error(err),
+			)
+		}
+		return conn, err
+	}
+}
+
+// ProcessHook 输出单个命令
+func (h *Hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		latency := time.Since(start)
+
+		ce := h.log.Ctx(ctx).L.Check(h.entryLevel(latency, cmdErr(cmd)), "redis command")
+		if ce == nil {
+			return err
+		}
+		fields := []zap.Field{
+			zap.String("cmd", cmd.Name()),
+			zap.Duration("latency", latency),
+		}
+		if h.args {
+			fields = append(fields, zap.String("args", h.formatArgs(cmd)))
+		}
+		if err := cmdErr(cmd); err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+		ce.Write(fields...)
+		return err
+	}
+}
+
+// ProcessPipelineHook 一个pipeline输出一条日志，包含命令个数、命令名称和第一个错误
+func (h *Hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		latency := time.Since(start)
+
+		var firstErr error
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = cmd.Name()
+			if firstErr == nil {
+				firstErr = cmdErr(cmd)
+			}
+		}
+		ce := h.log.Ctx(ctx).L.Check(h.entryLevel(latency, firstErr), "redis pipeline")
+		if ce == nil {
+			return err
+		}
+		fields := []zap.Field{
+			zap.Int("cmds", len(cmds)),
+			zap.Strings("names", names),
+			zap.Duration("latency", latency),
+		}
+		if firstErr != nil {
+			fields = append(fields, zap.Error(firstErr))
+		}
+		ce.Write(fields...)
+		return err
+	}
+}
+
+func (h *Hook) entryLevel(latency time.Duration, err error) zapcore.Level {
+	switch {
+	case err != nil:
+		return zapcore.ErrorLevel
+	case h.slow > 0 && latency >= h.slow:
+		return zapcore.WarnLevel
+	}
+	return h.level
+}
+
+// cmdErr 返回命令的错误，key不存在(redis.Nil)不算错误
+func cmdErr(cmd redis.Cmder) error {
+	err := cmd.Err()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
+
+func (h *Hook) formatArgs(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	name := cmd.Name()
+	keep := len(args)
+	switch {
+	case name == "auth" || name == "hello":
+		keep = 0
+	case h.redact[name]:
+		keep = 1
+	}
+	var b strings.Builder
+	for i, arg := range args {
+		if h.maxArgs > 0 && i >= h.maxArgs {
+			fmt.Fprintf(&b, " ...(%d more)", len(args)-i)
+			break
+		}
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if i >= keep {
+			b.WriteString(Redacted)
+			continue
+		}
+		fmt.Fprint(&b, arg)
+	}
+	return b.String()
+}
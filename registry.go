@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLoggerName 配置中没有loggers时顶层配置创建的Log的名称
+const DefaultLoggerName = "default"
+
+// DefaultRegistry Get使用的Registry
+var DefaultRegistry = NewRegistry()
+
+// Registry 按名称管理多个Log，如access、app、audit
+type Registry struct {
+	mu   sync.RWMutex
+	logs map[string]*Log
+}
+
+func NewRegistry() *Registry {
+	return &Registry{logs: make(map[string]*Log)}
+}
+
+// Register 以name注册log，已存在时替换
+func (r *Registry) Register(name string, log *Log) {
+	r.mu.Lock()
+	r.logs[name] = log
+	r.mu.Unlock()
+}
+
+// Get 返回name对应的Log，不存在时返回nil
+func (r *Registry) Get(name string) *Log {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.logs[name]
+}
+
+// Names 返回已注册的所有名称
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.logs))
+	for name := range r.logs {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Load 为配置中loggers下的每个配置创建Log并注册，每个配置以顶层配置为基础，只需写出不同的部分，
+// profile及环境变量对所有Log生效，没有loggers时以顶层配置创建名为DefaultLoggerName的Log
+//
+//	encoding: json
+//	loggers:
+//	  app:
+//	    info_filename: ./logs/app.log
+//	  access:
+//	    info_filename: ./logs/access.log
+//	    close_display: 1
+//	  audit:
+//	    audit:
+//	      filename: ./logs/audit.log
+func (r *Registry) Load(b []byte, format string, eo EncoderOptions) error {
+	b, format, err := nativeFormat(b, format)
+	if err != nil {
+		return err
+	}
+	names, err := sectionNames(b, format, "loggers")
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		c, err := NewFromBytes(b, format)
+		if err != nil {
+			return err
+		}
+		r.Register(DefaultLoggerName, c.InitLoggerWith(eo))
+		return nil
+	}
+
+	opts := make([]*LogOptions, len(names))
+	files := make(map[string]string)
+	for i, name := range names {
+		c, err := decodeConfig(b, format, "")
+		if err == nil {
+			_, err = c.overlay(b, format, "loggers", name)
+		}
+		if err == nil {
+			err = c.ApplyEnv()
+		}
+		if err != nil {
+			return fmt.Errorf("logger: logger %q: %v", name, err)
+		}
+		// 多个Log写同一个文件时切割会互相覆盖
+		for _, filename := range []string{c.InfoFilename, c.ErrorFilename, c.Audit.Filename} {
+			if filename == "" {
+				continue
+			}
+			if other, ok := files[filename]; ok {
+				return fmt.Errorf("logger: loggers %q and %q both write %s", other, name, filename)
+			}
+			files[filename] = name
+		}
+		opts[i] = c
+	}
+	for i, name := range names {
+		r.Register(name, opts[i].InitLoggerWith(eo))
+	}
+	return nil
+}
+
+// LoadFile 读取配置文件并调用Load，格式由扩展名决定，如 .yaml、.toml、.json、.hcl、.ini
+func (r *Registry) LoadFile(confPath string, eo EncoderOptions) error {
+	b, err := ioutil.ReadFile(confPath)
+	if err != nil {
+		return err
+	}
+	return r.Load(b, strings.TrimPrefix(filepath.Ext(confPath), "."), eo)
+}
+
+// Get 返回DefaultRegistry中name对应的Log，不存在时返回nil
+func Get(name string) *Log {
+	return DefaultRegistry.Get(name)
+}
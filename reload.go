@@ -0,0 +1,174 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// reloadState 某一次创建的core、生效的配置及其版本
+type reloadState struct {
+	gen     uint64
+	core    zapcore.Core
+	config  *LogOptions
+	outputs *logOutputs
+}
+
+// logOutputs 某一次创建Log时打开的文件、数据库、暂存目录和后台goroutine，Reload替换core后关闭上一次的输出。
+// 配置不变而继续使用的数据库和暂存目录不关闭，访问日志、审计日志等进程内只打开一次的输出不在其中
+type logOutputs struct {
+	rotators    []rotator
+	lumberjacks []*lumberjack.Logger
+	sqlite      *sqliteDB
+	databases   []*dbSink
+	spools      map[string]*spool
+	// closers 按注册的相反顺序调用，先停止缓冲、合并发送等上层，再关闭文件
+	closers []func()
+}
+
+func newLogOutputs() *logOutputs {
+	return &logOutputs{spools: make(map[string]*spool)}
+}
+
+// lumberjack 返回可以继续使用的lumberjack.Logger，没有时返回nil
+func (o *logOutputs) lumberjack(filename string, maxSize int) *lumberjack.Logger {
+	if o == nil {
+		return nil
+	}
+	for _, l := range o.lumberjacks {
+		if l.Filename == filename && l.MaxSize == maxSize {
+			return l
+		}
+	}
+	return nil
+}
+
+// close 关闭不再使用的输出，next为替换后使用的输出
+func (o *logOutputs) close(next *logOutputs) {
+	for i := len(o.closers) - 1; i >= 0; i-- {
+		o.closers[i]()
+	}
+	if o.sqlite != nil && o.sqlite != next.sqlite {
+		o.sqlite.close()
+	}
+	for _, s := range o.databases {
+		reused := false
+		for _, n := range next.databases {
+			reused = reused || n == s
+		}
+		if !reused {
+			s.close()
+		}
+	}
+	for name, s := range o.spools {
+		if next.spools[name] != s {
+			s.stop()
+		}
+	}
+}
+
+// closeOnReload 注册Reload替换后关闭本次创建的输出的函数
+func (c *LogOptions) closeOnReload(fn func()) {
+	if c.outputs != nil {
+		c.outputs.closers = append(c.outputs.closers, fn)
+	}
+}
+
+// reloadRoot 保存Log当前使用的core，Reload时整体替换
+type reloadRoot struct {
+	mu  sync.Mutex
+	cur atomic.Value // *reloadState
+}
+
+func newReloadRoot() *reloadRoot {
+	return &reloadRoot{}
+}
+
+func (r *reloadRoot) load() *reloadState {
+	return r.cur.Load().(*reloadState)
+}
+
+// store 替换core、配置及输出，返回之前的状态
+func (r *reloadRoot) store(core zapcore.Core, config *LogOptions, outputs *logOutputs) *reloadState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, _ := r.cur.Load().(*reloadState)
+	if old == nil {
+		r.cur.Store(&reloadState{core: core, config: config, outputs: outputs})
+		return nil
+	}
+	r.cur.Store(&reloadState{gen: old.gen + 1, core: core, config: config, outputs: outputs})
+	return old
+}
+
+// reloadCore 把日志交给reloadRoot当前的core，With派生的core缓存附加字段后的core，版本变化后重新派生
+type reloadCore struct {
+	root   *reloadRoot
+	fields []zapcore.Field
+	cache  atomic.Value // *reloadState
+}
+
+func (c *reloadCore) current() zapcore.Core {
+	s := c.root.load()
+	if len(c.fields) == 0 {
+		return s.core
+	}
+	if cached, _ := c.cache.Load().(*reloadState); cached != nil && cached.gen == s.gen {
+		return cached.core
+	}
+	cached := &reloadState{gen: s.gen, core: s.core.With(c.fields)}
+	c.cache.Store(cached)
+	return cached.core
+}
+
+func (c *reloadCore) Enabled(lvl zapcore.Level) bool {
+	return c.current().Enabled(lvl)
+}
+
+func (c *reloadCore) With(fs []zapcore.Field) zapcore.Core {
+	fields := make([]zapcore.Field, 0, len(c.fields)+len(fs))
+	fields = append(append(fields, c.fields...), fs...)
+	return &reloadCore{root: c.root, fields: fields}
+}
+
+func (c *reloadCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.current().Check(ent, ce)
+}
+
+func (c *reloadCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	return c.current().Write(ent, fs)
+}
+
+func (c *reloadCore) Sync() error {
+	return c.current().Sync()
+}
+
+// Reload 按c重新创建所有输出并原子替换，已经通过With、Ctx等派生的Log在下一条日志时切换到新的输出，
+// 替换后关闭原有的文件、数据库和后台发送队列，Rotate切割新的文件。
+// SetGlobalFields、AddHook、OnFatal和SetExitFunc的设置保留，
+// 调用位置、Collector、Audit、访问日志和SIGHUP切割等仍使用首次创建Log时的配置，c不合法时返回错误并保留原有输出
+func (log *Log) Reload(c *LogOptions) (err error) {
+	if log.reload == nil {
+		return errors.New("logger: log is not reloadable")
+	}
+	cur := log.reload.load()
+	defer func() {
+		if r := recover(); r != nil {
+			// 关闭创建了一半的输出，继续使用的输出保留
+			if c.outputs != nil && c.outputs != cur.outputs {
+				c.outputs.close(cur.outputs)
+			}
+			err = fmt.Errorf("logger: reload: %v", r)
+		}
+	}()
+	nl := c.initLogger(log.encoder, log)
+	s := nl.reload.load()
+	old := log.reload.store(s.core, s.config, s.outputs)
+	err = old.core.Sync()
+	old.outputs.close(s.outputs)
+	return err
+}
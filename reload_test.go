@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// openFiles 返回本进程打开的dir中的文件数，不支持/proc时跳过测试
+func openFiles(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("/proc/self/fd is not available")
+	}
+	n := 0
+	for _, e := range entries {
+		target, err := os.Readlink(filepath.Join("/proc/self/fd", e.Name()))
+		if err == nil && strings.HasPrefix(target, dir) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestReloadClosesReplacedFiles(t *testing.T) {
+	dir := t.TempDir()
+	options := func(name string) *LogOptions {
+		c := New(WithoutConsole(), WithInfoFile(filepath.Join(dir, name)), WithDivision(SizeDivision))
+		c.FileBufferSize = 4
+		return c
+	}
+	log := options("app.log").InitLoggerWith(EncoderOptions{})
+	log.Info("first")
+	log.L.Sync()
+	for i := 0; i < 10; i++ {
+		name := "app.log"
+		if i%2 == 0 {
+			name = "other.log"
+		}
+		if err := log.Reload(options(name)); err != nil {
+			t.Fatal(err)
+		}
+		log.Info("reloaded")
+		log.L.Sync()
+	}
+	// 只有当前的日志文件保持打开
+	if n := openFiles(t, dir); n != 1 {
+		t.Fatalf("%d files open in %s after reloads, want 1", n, dir)
+	}
+}
+
+func TestReloadRotatesNewFile(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	log := New(WithoutConsole(), WithInfoFile(first), WithDivision(SizeDivision)).InitLoggerWith(EncoderOptions{})
+	log.Info("first")
+	if err := log.Reload(New(WithoutConsole(), WithInfoFile(second), WithDivision(SizeDivision))); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("second")
+	if err := log.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	for filename, want := range map[string]int{first: 0, second: 1} {
+		backups, _ := filepath.Glob(lumberjackBackupGlob(filename))
+		if len(backups) != want {
+			t.Errorf("%s has %d backups, want %d", filename, len(backups), want)
+		}
+	}
+}
+
+func TestReloadReplaysSpoolOnce(t *testing.T) {
+	captureErrors(t)
+	var available, delivered int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&available) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		atomic.AddInt32(&delivered, 1)
+	}))
+	defer srv.Close()
+
+	spoolDir := t.TempDir()
+	options := func() *LogOptions {
+		c := New(WithoutConsole())
+		c.Alerts = []AlertConfig{{Type: AlertWebhook, URL: srv.URL}}
+		c.Spool = SpoolConfig{Dir: spoolDir, ReplayRate: 100}
+		return c
+	}
+	log := options().InitLoggerWith(EncoderOptions{})
+	log.Warn("disk full")
+	log.L.Sync()
+	// 每次Reload都继续使用同一个暂存，不会启动新的重放
+	for i := 0; i < 5; i++ {
+		if err := log.Reload(options()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	atomic.StoreInt32(&available, 1)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&delivered) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("spooled alert was not replayed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if n := atomic.LoadInt32(&delivered); n != 1 {
+		t.Fatalf("spooled alert delivered %d times, want 1", n)
+	}
+}
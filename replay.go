@@ -0,0 +1,359 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ReplayOptions 重放日志文件的设置，key和时间格式需要与写入文件时的配置一致
+type ReplayOptions struct {
+	// Format 文件格式 "json"、"console"，为空时按每行是否以 { 开头判断
+	Format string
+	// Encoder 写入文件时配置的key，零值使用默认的 time、level、logger、file、msg、stacktrace
+	Encoder EncoderConfig
+	// TimeLayout 写入文件时的时间格式，同EncoderOptions.TimeLayout，为空时依次尝试ISO8601、RFC3339等常见格式
+	TimeLayout string
+	// Location 不带时区的时间按此时区解析，默认time.Local
+	Location *time.Location
+	// Since、Until 只重放这个时间范围内的日志，零值不限制
+	Since time.Time
+	Until time.Time
+	// Rate 每秒最多重放的条数，0不限制
+	Rate int
+}
+
+// replayKeys 写入文件时使用的key，为空表示文件中没有该项
+type replayKeys struct {
+	time, level, name, caller, message, stack string
+}
+
+func (o ReplayOptions) keys() replayKeys {
+	ec := zapcore.EncoderConfig{
+		TimeKey:       "time",
+		LevelKey:      "level",
+		NameKey:       "logger",
+		CallerKey:     "file",
+		MessageKey:    "msg",
+		StacktraceKey: "stacktrace",
+	}
+	o.Encoder.apply(&ec)
+	return replayKeys{
+		time:    ec.TimeKey,
+		level:   ec.LevelKey,
+		name:    ec.NameKey,
+		caller:  ec.CallerKey,
+		message: ec.MessageKey,
+		stack:   ec.StacktraceKey,
+	}
+}
+
+// _replayMarker 加在重放的fatal日志上，fatalCore据此不执行OnFatal回调和退出，不会被编码输出
+var _replayMarker = zapcore.Field{Key: "logger.replay", Type: zapcore.SkipType}
+
+func isReplay(fs []zapcore.Field) bool {
+	for _, f := range fs {
+		if f.Type == zapcore.SkipType && f.Key == _replayMarker.Key {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplayFile 同Replay，按后缀自动解压切割后压缩的 .gz、.zst 文件
+func (log *Log) ReplayFile(filename string, opts ReplayOptions) (int, error) {
+	f, err := openLogFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return log.Replay(f, opts)
+}
+
+// Replay 读取以json或console格式写入的日志，保留原来的时间、级别、调用位置和字段，
+// 重新写入log的各个输出，用于把归档的日志导入新的日志存储，返回重放的条数。
+// 无法解析的行视为上一条日志的堆栈，开头无法解析的行被跳过
+func (log *Log) Replay(r io.Reader, opts ReplayOptions) (int, error) {
+	p := &replayParser{opts: opts, keys: opts.keys()}
+	if p.opts.Location == nil {
+		p.opts.Location = time.Local
+	}
+	var interval time.Duration
+	if opts.Rate > 0 {
+		interval = time.Second / time.Duration(opts.Rate)
+	}
+
+	core := log.L.Core()
+	count := 0
+	var last time.Time
+	emit := func(e *replayEntry) {
+		if e == nil || !opts.Since.IsZero() && e.ent.Time.Before(opts.Since) ||
+			!opts.Until.IsZero() && e.ent.Time.After(opts.Until) {
+			return
+		}
+		if interval > 0 {
+			if wait := interval - time.Since(last); wait > 0 {
+				time.Sleep(wait)
+			}
+			last = time.Now()
+		}
+		fs := e.fields
+		if e.ent.Level >= zapcore.FatalLevel {
+			fs = append(fs, _replayMarker)
+		}
+		if ce := core.Check(e.ent, nil); ce != nil {
+			ce.Write(fs...)
+		}
+		count++
+	}
+
+	var pending *replayEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		e, ok := p.parse(line)
+		if !ok {
+			// console格式的堆栈写在日志之后的多行中
+			if pending != nil {
+				if pending.ent.Stack != "" {
+					pending.ent.Stack += "\n"
+				}
+				pending.ent.Stack += line
+			}
+			continue
+		}
+		emit(pending)
+		pending = e
+	}
+	emit(pending)
+	if err := scanner.Err(); err != nil {
+		return count, fmt.Errorf("logger: replay: %v", err)
+	}
+	return count, nil
+}
+
+type replayEntry struct {
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+type replayParser struct {
+	opts ReplayOptions
+	keys replayKeys
+}
+
+func (p *replayParser) parse(line string) (*replayEntry, bool) {
+	switch p.opts.Format {
+	case "json":
+		return p.parseJSON(line)
+	case "console":
+		return p.parseConsole(line)
+	}
+	if strings.HasPrefix(line, "{") {
+		return p.parseJSON(line)
+	}
+	return p.parseConsole(line)
+}
+
+// parseJSON 解析一行json日志，key按原来的顺序转换为字段
+func (p *replayParser) parseJSON(line string) (*replayEntry, bool) {
+	e := &replayEntry{ent: zapcore.Entry{Level: zapcore.InfoLevel}}
+	ok := true
+	err := jsonObjectEach([]byte(line), func(key string, raw json.RawMessage) {
+		var s string
+		isString := json.Unmarshal(raw, &s) == nil
+		switch {
+		case key == p.keys.time && key != "":
+			t, err := p.parseTime(raw)
+			if err != nil {
+				ok = false
+			}
+			e.ent.Time = t
+		case key == p.keys.level && key != "" && isString:
+			if unmarshalLevel(&e.ent.Level, s) != nil {
+				ok = false
+			}
+		case key == p.keys.name && key != "" && isString:
+			e.ent.LoggerName = s
+		case key == p.keys.caller && key != "" && isString:
+			e.ent.Caller = parseCaller(s)
+		case key == p.keys.message && key != "" && isString:
+			e.ent.Message = s
+		case key == p.keys.stack && key != "" && isString:
+			e.ent.Stack = s
+		default:
+			e.fields = append(e.fields, rawField(key, raw))
+		}
+	})
+	if err != nil || !ok {
+		return nil, false
+	}
+	return e, true
+}
+
+var (
+	_ansiColor   = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	_callerValue = regexp.MustCompile(`^\S+\.go:\d+$`)
+)
+
+// parseConsole 解析一行console日志：时间、级别、名称、调用位置、消息、{字段}，以tab分隔
+func (p *replayParser) parseConsole(line string) (*replayEntry, bool) {
+	parts := strings.Split(line, "\t")
+	e := &replayEntry{ent: zapcore.Entry{Level: zapcore.InfoLevel}}
+	if p.keys.time != "" {
+		t, err := p.parseTime(json.RawMessage(strconv.Quote(parts[0])))
+		if err != nil {
+			return nil, false
+		}
+		e.ent.Time = t
+		parts = parts[1:]
+	}
+	if p.keys.level != "" {
+		if len(parts) == 0 || unmarshalLevel(&e.ent.Level, _ansiColor.ReplaceAllString(parts[0], "")) != nil {
+			return nil, false
+		}
+		parts = parts[1:]
+	}
+	if n := len(parts); n > 0 && strings.HasPrefix(parts[n-1], "{") {
+		var fields []zapcore.Field
+		err := jsonObjectEach([]byte(parts[n-1]), func(key string, raw json.RawMessage) {
+			fields = append(fields, rawField(key, raw))
+		})
+		if err == nil {
+			e.fields = fields
+			parts = parts[:n-1]
+		}
+	}
+	// 名称和调用位置都是可选的，通过调用位置的 file.go:line 格式区分
+	switch {
+	case len(parts) > 2 && _callerValue.MatchString(parts[1]):
+		e.ent.LoggerName, e.ent.Caller = parts[0], parseCaller(parts[1])
+		parts = parts[2:]
+	case len(parts) > 1 && _callerValue.MatchString(parts[0]):
+		e.ent.Caller = parseCaller(parts[0])
+		parts = parts[1:]
+	}
+	e.ent.Message = strings.Join(parts, "\t")
+	return e, true
+}
+
+// parseTime 按TimeLayout解析时间，raw为json字符串或数字
+func (p *replayParser) parseTime(raw json.RawMessage) (time.Time, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return p.epoch(string(raw))
+	}
+
+	var layouts []string
+	switch p.opts.TimeLayout {
+	case TimeLayoutEpoch, TimeLayoutEpochMillis, TimeLayoutEpochNanos:
+		// console格式中的数字时间
+		return p.epoch(s)
+	case "", TimeLayoutISO8601, TimeLayoutRFC3339, TimeLayoutRFC3339Nano:
+	default:
+		layouts = append(layouts, p.opts.TimeLayout)
+	}
+	layouts = append(layouts, "2006-01-02T15:04:05.000Z0700", time.RFC3339Nano, "2006-01-02 15:04:05")
+	var err error
+	for _, layout := range layouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, s, p.opts.Location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// epoch 解析数字格式的时间，单位由TimeLayout决定，默认为秒
+func (p *replayParser) epoch(s string) (time.Time, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch p.opts.TimeLayout {
+	case TimeLayoutEpochMillis:
+		return time.Unix(0, int64(f*float64(time.Millisecond))), nil
+	case TimeLayoutEpochNanos:
+		return time.Unix(0, int64(f)), nil
+	}
+	return time.Unix(0, int64(f*float64(time.Second))), nil
+}
+
+// parseCaller 解析 file:line 格式的调用位置
+func parseCaller(s string) zapcore.EntryCaller {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return zapcore.EntryCaller{}
+	}
+	line, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return zapcore.EntryCaller{}
+	}
+	return zapcore.EntryCaller{Defined: true, File: s[:i], Line: line}
+}
+
+// rawField 把json值转换为字段，字符串、数字、布尔使用对应类型以便脱敏等处理生效，对象和数组原样输出
+func rawField(key string, raw json.RawMessage) zapcore.Field {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return zap.Reflect(key, raw)
+	}
+	switch v := v.(type) {
+	case string:
+		return zap.String(key, v)
+	case bool:
+		return zap.Bool(key, v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return zap.Int64(key, i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return zap.Float64(key, f)
+		}
+	case nil:
+		return zap.Reflect(key, nil)
+	}
+	return zap.Reflect(key, raw)
+}
+
+// jsonObjectEach 按出现的顺序遍历json对象的key和值
+func jsonObjectEach(b []byte, fn func(key string, raw json.RawMessage)) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return fmt.Errorf("logger: not a json object")
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := t.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		fn(key, raw)
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("logger: unexpected data after json object")
+	}
+	return nil
+}
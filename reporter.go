@@ -0,0 +1,326 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 错误上报平台
+const (
+	ReporterSentry  = "sentry"
+	ReporterRollbar = "rollbar"
+	ReporterBugsnag = "bugsnag"
+)
+
+// ErrorReporter 错误上报平台的统一接口，sentry、rollbar、bugsnag以及自定义的上报方式都通过它接入InitLogger。
+// 实现了zapcore.LevelEnabler时由Enabled决定上报哪些级别，否则上报error及以上级别
+type ErrorReporter interface {
+	// Capture 上报一条日志，fields包含With添加的字段和本次日志的字段，不应阻塞
+	Capture(ent zapcore.Entry, fields []zapcore.Field)
+	// Flush 等待已上报的事件发送完成，超时返回false
+	Flush(timeout time.Duration) bool
+}
+
+// ErrorReporterFactory 根据配置创建ErrorReporter，未配置时返回nil
+type ErrorReporterFactory func(c *LogOptions) (ErrorReporter, error)
+
+var (
+	_reportersMu sync.RWMutex
+	_reporters   = map[string]ErrorReporterFactory{
+		ReporterSentry:  newSentryReporter,
+		ReporterRollbar: newRollbarReporter,
+		ReporterBugsnag: newBugsnagReporter,
+	}
+)
+
+// RegisterErrorReporter 注册错误上报平台，之后可以通过LogOptions.ErrorReporter按名称选择，
+// 同名时覆盖已有的注册
+func RegisterErrorReporter(name string, factory ErrorReporterFactory) {
+	_reportersMu.Lock()
+	_reporters[name] = factory
+	_reportersMu.Unlock()
+}
+
+// AddErrorReporter 添加一个ErrorReporter实例，与ErrorReporter选择的平台同时生效，适用于测试或自定义上报
+func (c *LogOptions) AddErrorReporter(r ErrorReporter) *LogOptions {
+	c.reporters = append(c.reporters, r)
+	return c
+}
+
+// reporterCore 实现zapcore.Core，将日志交给ErrorReporter上报
+type reporterCore struct {
+	zapcore.LevelEnabler
+	reporter     ErrorReporter
+	fields       []zapcore.Field
+	flushTimeout time.Duration
+}
+
+func newReporterCore(r ErrorReporter) *reporterCore {
+	level, ok := r.(zapcore.LevelEnabler)
+	if !ok {
+		level = zapcore.ErrorLevel
+	}
+	return &reporterCore{
+		LevelEnabler: level,
+		reporter:     r,
+		flushTimeout: 3 * time.Second,
+	}
+}
+
+func (c *reporterCore) With(fs []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fs...)
+	return &clone
+}
+
+func (c *reporterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *reporterCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	c.reporter.Capture(ent, append(c.fields[:len(c.fields):len(c.fields)], fs...))
+	// fatal、panic之后进程可能立即退出，等待发送完成
+	if ent.Level > zapcore.ErrorLevel {
+		c.reporter.Flush(c.flushTimeout)
+	}
+	return nil
+}
+
+func (c *reporterCore) Sync() error {
+	c.reporter.Flush(c.flushTimeout)
+	return nil
+}
+
+// reporterLevel 解析上报的最低级别，默认 "error"
+func reporterLevel(s string) (zapcore.Level, error) {
+	level := zapcore.ErrorLevel
+	if s == "" {
+		return level, nil
+	}
+	err := unmarshalLevel(&level, s)
+	return level, err
+}
+
+// httpQueue 由单个goroutine依次发送上报请求
+type httpQueue struct {
+	name    string
+	metrics *metrics
+	spool   *spool
+	client  *http.Client
+	reqs    chan *http.Request
+
+	// mu 保护closed，关闭reqs后不能再加入
+	mu     sync.RWMutex
+	closed bool
+
+	// progressMu 保护enqueued、sent、progress，wait等待调用前放入队列的请求发送完成，
+	// progress 每发送完一个请求时关闭并替换
+	progressMu     sync.Mutex
+	enqueued, sent uint64
+	progress       chan struct{}
+}
+
+// newHTTPQueue auth为请求的地址和认证头，请求进入暂存时不保存，见spoolAuth；auth为nil时不暂存
+func newHTTPQueue(name string, size int, c *LogOptions, auth spoolAuth) *httpQueue {
+	q := &httpQueue{
+		name:     name,
+		metrics:  c.metrics,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		reqs:     make(chan *http.Request, size),
+		progress: make(chan struct{}),
+	}
+	if auth != nil {
+		q.spool = c.newSpool(name, q.do, auth)
+	}
+	c.metrics.queue(name, q.depth)
+	c.closeOnReload(q.close)
+	go q.run()
+	return q
+}
+
+// close Reload后不再使用时调用，等待队列中的请求发送完成，最多等待5秒，之后加入的请求被丢弃
+func (q *httpQueue) close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.reqs)
+	}
+	q.mu.Unlock()
+	q.wait(5 * time.Second)
+}
+
+// send 队列已满时丢弃请求，不阻塞日志写入
+func (q *httpQueue) send(req *http.Request) {
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		q.metrics.drop(q.name)
+		return
+	}
+	select {
+	case q.reqs <- req:
+		q.mu.RUnlock()
+		q.progressMu.Lock()
+		q.enqueued++
+		q.progressMu.Unlock()
+	default:
+		q.mu.RUnlock()
+		q.metrics.drop(q.name)
+		handleError(fmt.Errorf("logger: %s queue is full, event dropped", q.name))
+	}
+}
+
+func (q *httpQueue) run() {
+	for req := range q.reqs {
+		// 暂存中还有未重放的请求时，新的请求也进入暂存，保证发送顺序
+		if q.spool != nil && q.spool.pending() {
+			q.spool.push(req)
+		} else if retry, _ := q.do(req); retry && q.spool != nil {
+			q.spool.push(req)
+		}
+		q.progressMu.Lock()
+		q.sent++
+		close(q.progress)
+		q.progress = make(chan struct{})
+		q.progressMu.Unlock()
+	}
+}
+
+// depth 队列中及暂存中等待发送的请求数
+func (q *httpQueue) depth() int {
+	n := len(q.reqs)
+	if q.spool != nil {
+		n += q.spool.len()
+	}
+	return n
+}
+
+func (q *httpQueue) do(req *http.Request) (bool, error) {
+	start := time.Now()
+	retry, err := deliver(q.client, req)
+	q.metrics.observe(q.name, time.Since(start))
+	if err != nil {
+		q.metrics.writeError(q.name, err)
+		handleError(fmt.Errorf("logger: send %s event failed: %v", q.name, err))
+	} else {
+		q.metrics.sent(q.name)
+		q.metrics.written(q.name, int(req.ContentLength))
+	}
+	return retry, err
+}
+
+// wait 等待此前放入队列的请求发送完成，超时返回false
+func (q *httpQueue) wait(timeout time.Duration) bool {
+	q.progressMu.Lock()
+	target := q.enqueued
+	q.progressMu.Unlock()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		q.progressMu.Lock()
+		if q.sent >= target {
+			q.progressMu.Unlock()
+			return true
+		}
+		progress := q.progress
+		q.progressMu.Unlock()
+		select {
+		case <-progress:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// stackFrame zap调用栈中的一帧
+type stackFrame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// parseStack 解析zap生成的调用栈，格式为每帧两行 "function\n\tfile:line"，调用顺序由内到外
+func parseStack(stack string) []stackFrame {
+	lines := strings.Split(stack, "\n")
+	var frames []stackFrame
+	for i := 0; i+1 < len(lines); i += 2 {
+		frame := stackFrame{Function: lines[i]}
+		loc := strings.TrimSpace(lines[i+1])
+		if j := strings.LastIndexByte(loc, ':'); j >= 0 {
+			frame.File = loc[:j]
+			frame.Line, _ = strconv.Atoi(loc[j+1:])
+		} else {
+			frame.File = loc
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// errorReporterCore 根据ErrorReporter选择错误上报平台(默认sentry)，与AddErrorReporter添加的实例一起生成core
+func (c *LogOptions) errorReporterCore() zapcore.Core {
+	name := c.ErrorReporter
+	if name == "" {
+		name = ReporterSentry
+	}
+	_reportersMu.RLock()
+	factory, ok := _reporters[name]
+	_reportersMu.RUnlock()
+
+	reporters := c.reporters
+	if !ok {
+		fmt.Printf("logger: unknown error reporter %q\n", name)
+	} else if r, err := factory(c); err != nil {
+		fmt.Println(err)
+	} else if r != nil {
+		reporters = append([]ErrorReporter{r}, reporters...)
+	}
+
+	var cores []zapcore.Core
+	for _, r := range reporters {
+		cores = append(cores, newReporterCore(r))
+	}
+	switch len(cores) {
+	case 0:
+		return nil
+	case 1:
+		return cores[0]
+	default:
+		return zapcore.NewTee(cores...)
+	}
+}
+
+// sentryReporter 将sentry core适配为ErrorReporter，breadcrumb、路由等仍由sentry core处理
+type sentryReporter struct {
+	core zapcore.Core
+}
+
+func newSentryReporter(c *LogOptions) (ErrorReporter, error) {
+	sCore := c.sentryCore()
+	if sCore == nil {
+		return nil, nil
+	}
+	return sentryReporter{core: sCore}, nil
+}
+
+func (r sentryReporter) Enabled(lvl zapcore.Level) bool {
+	return r.core.Enabled(lvl)
+}
+
+func (r sentryReporter) Capture(ent zapcore.Entry, fields []zapcore.Field) {
+	_ = r.core.Write(ent, fields)
+}
+
+func (r sentryReporter) Flush(timeout time.Duration) bool {
+	_ = r.core.Sync()
+	return true
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPQueueWaitWhileSending(t *testing.T) {
+	captureErrors(t)
+	arrived, release := make(chan struct{}, 1), make(chan struct{})
+	var received int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+		<-release
+		atomic.AddInt64(&received, 1)
+	}))
+	defer srv.Close()
+	var once sync.Once
+	unblock := func() { once.Do(func() { close(release) }) }
+	// 失败时也要放行请求，否则srv.Close一直等待
+	defer unblock()
+	q := newHTTPQueue("test", 1000, New(), nil)
+
+	newRequest := func() *http.Request {
+		req, err := http.NewRequest(http.MethodPost, srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+	q.send(newRequest())
+	<-arrived
+	// 请求在发送中，超时的wait不能留下goroutine
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		if q.wait(time.Millisecond) {
+			t.Fatal("wait should time out while the server is blocked")
+		}
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("goroutines grew from %d to %d after timed out waits", before, after)
+	}
+	unblock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				q.send(newRequest())
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				q.wait(time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+	if !q.wait(5 * time.Second) {
+		t.Fatal("wait timed out after the server was released")
+	}
+	if n := atomic.LoadInt64(&received); n != 81 {
+		t.Fatalf("server received %d requests, want 81", n)
+	}
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID 在ctx中保存请求ID，通过Log.Ctx输出的日志会带上request_id字段
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom 返回ctx中的请求ID
+func RequestIDFrom(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// RequestIDMiddleware 读取或生成请求ID，写入响应header并保存在请求的context中，不输出访问日志，
+// 只支持WithRequestIDHeader选项，HTTPMiddleware已包含该功能
+func RequestIDMiddleware(opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	o := &middlewareOptions{header: RequestIDHeader}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, withRequestID(w, r, o.header))
+		})
+	}
+}
+
+// withRequestID 从header读取请求ID，没有时生成并写入请求header，同时写入响应header和请求的context
+func withRequestID(w http.ResponseWriter, r *http.Request, header string) *http.Request {
+	id := r.Header.Get(header)
+	if id == "" {
+		id = NewRequestID()
+		r.Header.Set(header, id)
+	}
+	w.Header().Set(header, id)
+	return r.WithContext(WithRequestID(r.Context(), id))
+}
+
+// RequestIDTransport 把请求context中的请求ID写入出站请求的header，已有该header时不覆盖，
+// 用于调用下游服务时透传请求ID
+//
+//	client := &http.Client{Transport: &logger.RequestIDTransport{}}
+//	req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, url, nil)
+type RequestIDTransport struct {
+	// Base 实际发送请求的RoundTripper，为空时使用http.DefaultTransport
+	Base http.RoundTripper
+	// Header 默认 X-Request-ID
+	Header string
+}
+
+func (t *RequestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	header := t.Header
+	if header == "" {
+		header = RequestIDHeader
+	}
+	if id, ok := RequestIDFrom(req.Context()); ok && req.Header.Get(header) == "" {
+		// RoundTripper不能修改传入的请求
+		req = req.Clone(req.Context())
+		req.Header.Set(header, id)
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
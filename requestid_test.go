@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []MiddlewareOption
+		header   string
+		incoming string
+		generate bool
+	}{
+		{name: "reuse", header: RequestIDHeader, incoming: "req-1"},
+		{name: "generate", header: RequestIDHeader, generate: true},
+		{name: "custom header", opts: []MiddlewareOption{WithRequestIDHeader("X-Correlation-ID")}, header: "X-Correlation-ID", incoming: "corr-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := RequestIDMiddleware(tt.opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, ok := RequestIDFrom(r.Context())
+				if !ok {
+					t.Error("no request id in context")
+				}
+				if r.Header.Get(tt.header) != id {
+					t.Errorf("request header %q, context %q", r.Header.Get(tt.header), id)
+				}
+				got = id
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(tt.header, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if tt.generate {
+				if len(got) != 32 {
+					t.Errorf("generated id %q", got)
+				}
+			} else if got != tt.incoming {
+				t.Errorf("id = %q, want %q", got, tt.incoming)
+			}
+			if rec.Header().Get(tt.header) != got {
+				t.Errorf("response header %q, want %q", rec.Header().Get(tt.header), got)
+			}
+		})
+	}
+}
+
+func TestRequestIDTransport(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctxID    string
+		existing string
+		header   string
+		want     string
+	}{
+		{name: "propagate", ctxID: "req-1", want: "req-1"},
+		{name: "keep existing", ctxID: "req-1", existing: "req-0", want: "req-0"},
+		{name: "no id", want: ""},
+		{name: "custom header", ctxID: "req-1", header: "X-Correlation-ID", want: "req-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			if header == "" {
+				header = RequestIDHeader
+			}
+			var got string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get(header)
+			}))
+			defer srv.Close()
+			req := httptest.NewRequest(http.MethodGet, srv.URL, nil)
+			req.RequestURI = ""
+			if tt.ctxID != "" {
+				req = req.WithContext(WithRequestID(req.Context(), tt.ctxID))
+			}
+			if tt.existing != "" {
+				req.Header.Set(header, tt.existing)
+			}
+			client := &http.Client{Transport: &RequestIDTransport{Header: tt.header}}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if got != tt.want {
+				t.Errorf("%s = %q, want %q", header, got, tt.want)
+			}
+			// 不修改调用方的请求
+			if tt.existing == "" && req.Header.Get(header) != "" {
+				t.Errorf("caller's request modified")
+			}
+		})
+	}
+}
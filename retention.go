@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// retention 按总大小清理切割出的日志文件，超过上限时从最旧的文件开始删除
+type retention struct {
+	mu      sync.Mutex
+	limit   int64
+	sources []retentionSource
+	// keep 配置的日志文件，不会被删除
+	keep []string
+}
+
+// retentionSource 一个日志文件的所有切割文件，current返回正在写入的文件，不会被删除
+type retentionSource struct {
+	globs   []string
+	current func() string
+}
+
+func newRetention(limitMB int, keep []string) *retention {
+	return &retention{limit: int64(limitMB) * 1024 * 1024, keep: keep}
+}
+
+func (r *retention) add(current func() string, globs ...string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.sources = append(r.sources, retentionSource{globs: globs, current: current})
+	r.mu.Unlock()
+}
+
+// prune 返回删除的文件
+func (r *retention) prune() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var (
+		backups []LogFile
+		total   int64
+	)
+	seen := keepSet(r.keep)
+	for _, s := range r.sources {
+		seen[s.current()] = true
+	}
+	for _, s := range r.sources {
+		for _, b := range backupFiles(s.globs, seen) {
+			backups = append(backups, b)
+			total += b.Size
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ModTime.Before(backups[j].ModTime)
+	})
+	var removed []string
+	for _, b := range backups {
+		if total <= r.limit {
+			break
+		}
+		if err := os.Remove(b.Path); err == nil {
+			total -= b.Size
+			removed = append(removed, b.Path)
+		}
+	}
+	return removed
+}
+
+// pruneBackups 删除超过maxAge天或超出maxBackups个数的切割文件，active返回正在写入的文件，
+// 它和keep中配置的日志文件不会被删除，MaxAge按now计算，返回删除的文件
+func pruneBackups(globs []string, active func() string, keep []string, maxBackups, maxAge int, now time.Time) []string {
+	if maxBackups <= 0 && maxAge <= 0 {
+		return nil
+	}
+
+	seen := keepSet(keep)
+	seen[active()] = true
+	backups := backupFiles(globs, seen)
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ModTime.After(backups[j].ModTime)
+	})
+
+	cutoff := now.Add(-time.Duration(maxAge) * 24 * time.Hour)
+	var removed []string
+	for i, b := range backups {
+		if (maxBackups > 0 && i >= maxBackups) || (maxAge > 0 && b.ModTime.Before(cutoff)) {
+			if err := os.Remove(b.Path); err == nil {
+				removed = append(removed, b.Path)
+			}
+		}
+	}
+	return removed
+}
+
+func keepSet(keep []string) map[string]bool {
+	seen := make(map[string]bool, len(keep)+1)
+	for _, name := range keep {
+		seen[name] = true
+	}
+	return seen
+}
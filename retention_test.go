@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPruneBackupsKeepsConfiguredFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-10 * 24 * time.Hour)
+	active := filepath.Join(dir, "app.log")
+	errFile := filepath.Join(dir, "app-error.log")
+	backup := filepath.Join(dir, "app-1.log")
+	for _, name := range []string{active, errFile, backup} {
+		writeFile(t, name, "x", old)
+	}
+
+	// 过宽的glob同时匹配到其他日志文件，它们在keep中，不能删除
+	removed := pruneBackups([]string{filepath.Join(dir, "app*")}, func() string { return active }, []string{errFile}, 0, 1, now)
+	if len(removed) != 1 || removed[0] != backup {
+		t.Fatalf("removed %v, want only %s", removed, backup)
+	}
+	for _, name := range []string{active, errFile} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("%s removed: %v", name, err)
+		}
+	}
+}
+
+func TestPruneUsesClock(t *testing.T) {
+	dir := t.TempDir()
+	info := filepath.Join(dir, "app.log")
+	c := New(WithInfoFile(info), WithDivision(SizeDivision))
+	c.MaxAge = 7
+	now := time.Now()
+	writeFile(t, info, "x", now)
+	backup := filepath.Join(dir, "app-2024-01-02T03-04-05.678.log")
+	writeFile(t, backup, "x", now.Add(-24*time.Hour))
+
+	removed, err := c.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("removed %v with the system clock", removed)
+	}
+
+	// 时钟前进到MaxAge之后，备份文件过期
+	c.SetClock(FixedClock(now.Add(8 * 24 * time.Hour)))
+	removed, err = c.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != backup {
+		t.Fatalf("removed %v, want %s", removed, backup)
+	}
+}
+
+func TestPruneSkipsOtherLogFiles(t *testing.T) {
+	dir := t.TempDir()
+	info := filepath.Join(dir, "app.log")
+	access := filepath.Join(dir, "app.log.access")
+	c := New(WithInfoFile(info), WithDivision(TimeDivision), WithTimeUnit(Day))
+	c.AccessLog.Filename = access
+	c.MaxAge = 1
+
+	now := time.Now()
+	// 访问日志与按时间切割的glob app.log.* 匹配，并且已超过MaxAge
+	writeFile(t, access, "x", now.Add(-72*time.Hour))
+	writeFile(t, info+".2024-01-01", "x", now.Add(-48*time.Hour))
+	writeFile(t, info+".2024-01-02", "x", now.Add(-12*time.Hour))
+	writeFile(t, info+".2024-01-03", "x", now)
+
+	files, err := c.LogFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, f := range files {
+		if f.Path == access {
+			count++
+		}
+		if f.Current && f.Path != info+".2024-01-03" && f.Path != access {
+			t.Errorf("%s reported as current", f.Path)
+		}
+	}
+	if count != 1 {
+		t.Errorf("%s listed %d times, want once", access, count)
+	}
+
+	removed, err := c.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != info+".2024-01-01" {
+		t.Fatalf("removed %v, want %s", removed, info+".2024-01-01")
+	}
+	if _, err := os.Stat(access); err != nil {
+		t.Fatalf("%s removed: %v", access, err)
+	}
+}
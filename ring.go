@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ringBuffer 保存最近的n条日志，用于在告警、崩溃时附带上下文
+// 每个槽位的[]byte在覆盖时复用，写满后不再分配内存
+type ringBuffer struct {
+	mu    sync.Mutex
+	lines [][]byte
+	next  int
+	full  bool
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{lines: make([][]byte, size)}
+}
+
+func (r *ringBuffer) add(line string) {
+	r.mu.Lock()
+	r.lines[r.next] = append(r.lines[r.next][:0], line...)
+	r.advance()
+	r.mu.Unlock()
+}
+
+// addEntry 将日志编码后直接复制到槽位中，不生成中间字符串
+func (r *ringBuffer) addEntry(enc zapcore.Encoder, ent zapcore.Entry, fs []zapcore.Field) {
+	buf, err := enc.EncodeEntry(ent, fs)
+	if err != nil {
+		r.add(ent.Message)
+		return
+	}
+	line := bytes.TrimSuffix(buf.Bytes(), []byte(zapcore.DefaultLineEnding))
+	r.mu.Lock()
+	r.lines[r.next] = append(r.lines[r.next][:0], line...)
+	r.advance()
+	r.mu.Unlock()
+	buf.Free()
+}
+
+func (r *ringBuffer) advance() {
+	r.next = (r.next + 1) % len(r.lines)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// snapshot 按时间顺序返回保存的日志
+func (r *ringBuffer) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var lines []string
+	if r.full {
+		for _, l := range r.lines[r.next:] {
+			lines = append(lines, string(l))
+		}
+	}
+	for _, l := range r.lines[:r.next] {
+		lines = append(lines, string(l))
+	}
+	return lines
+}
+
+// newLineEncoder 创建把日志编码为单行文本的编码器，时间使用encodeTime
+func newLineEncoder(encodeTime zapcore.TimeEncoder) zapcore.Encoder {
+	return newConsoleEncoder(zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "file",
+		MessageKey:     "msg",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    levelEncoder(zapcore.CapitalLevelEncoder, true),
+		EncodeTime:     encodeTime,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	})
+}
+
+// encodeLine 将日志编码为单行文本，不含末尾换行
+func encodeLine(enc zapcore.Encoder, ent zapcore.Entry, fs []zapcore.Field) string {
+	buf, err := enc.EncodeEntry(ent, fs)
+	if err != nil {
+		return ent.Message
+	}
+	line := strings.TrimSuffix(buf.String(), zapcore.DefaultLineEnding)
+	buf.Free()
+	return line
+}
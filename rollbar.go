@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const _rollbarEndpoint = "https://api.rollbar.com/api/1/item/"
+
+// RollbarConfig 通过Rollbar API上报错误日志
+type RollbarConfig struct {
+	// Token Rollbar项目的post_server_item access token
+	Token       string `toml:"token" yaml:"token" json:"token"`
+	Environment string `toml:"environment" yaml:"environment" json:"environment"`
+	// CodeVersion 为空时根据编译信息自动生成，见buildRelease
+	CodeVersion string `toml:"code_version" yaml:"code_version" json:"code_version"`
+	// Level 上报的最低级别，默认 "error"
+	Level    string `toml:"level" yaml:"level" json:"level"`
+	Endpoint string `toml:"endpoint" yaml:"endpoint" json:"endpoint"`
+}
+
+type rollbarReporter struct {
+	cfg   RollbarConfig
+	level zapcore.Level
+	host  string
+	queue *httpQueue
+}
+
+func newRollbarReporter(c *LogOptions) (ErrorReporter, error) {
+	cfg := c.RollbarConfig
+	if cfg.Token == "" {
+		return nil, nil
+	}
+	level, err := reporterLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = _rollbarEndpoint
+	}
+	if cfg.CodeVersion == "" {
+		cfg.CodeVersion = buildRelease()
+	}
+	host, _ := os.Hostname()
+	auth := func() (string, http.Header) {
+		h := make(http.Header)
+		h.Set("X-Rollbar-Access-Token", cfg.Token)
+		return cfg.Endpoint, h
+	}
+	return &rollbarReporter{cfg: cfg, level: level, host: host, queue: newHTTPQueue(ReporterRollbar, 100, c, auth)}, nil
+}
+
+// rollbarLevel 将zap的Level转换为rollbar的level
+func rollbarLevel(lvl zapcore.Level) string {
+	switch {
+	case lvl < zapcore.InfoLevel:
+		return "debug"
+	case lvl == zapcore.InfoLevel:
+		return "info"
+	case lvl == zapcore.WarnLevel:
+		return "warning"
+	case lvl == zapcore.ErrorLevel:
+		return "error"
+	default:
+		return "critical"
+	}
+}
+
+func (r *rollbarReporter) Enabled(lvl zapcore.Level) bool {
+	return lvl >= r.level
+}
+
+func (r *rollbarReporter) Capture(ent zapcore.Entry, fs []zapcore.Field) {
+	fields := mergeFields(nil, fs)
+	// 有调用栈时以trace上报，rollbar会按调用栈分组，否则以message上报
+	var body map[string]interface{}
+	if frames := parseStack(ent.Stack); len(frames) > 0 {
+		rframes := make([]map[string]interface{}, 0, len(frames))
+		// rollbar要求调用顺序由外到内
+		for i := len(frames) - 1; i >= 0; i-- {
+			rframes = append(rframes, map[string]interface{}{
+				"filename": frames[i].File,
+				"lineno":   frames[i].Line,
+				"method":   frames[i].Function,
+			})
+		}
+		body = map[string]interface{}{
+			"trace": map[string]interface{}{
+				"frames":    rframes,
+				"exception": map[string]interface{}{"class": ent.Level.CapitalString(), "message": ent.Message},
+			},
+		}
+	} else {
+		body = map[string]interface{}{
+			"message": map[string]interface{}{"body": ent.Message},
+		}
+	}
+
+	data := map[string]interface{}{
+		"environment":  r.cfg.Environment,
+		"level":        rollbarLevel(ent.Level),
+		"timestamp":    ent.Time.Unix(),
+		"platform":     "go",
+		"language":     "go",
+		"code_version": r.cfg.CodeVersion,
+		"body":         body,
+		"server":       map[string]interface{}{"host": r.host},
+		"custom":       fields,
+		"notifier":     map[string]interface{}{"name": "github.com/mae-pax/logger"},
+	}
+	if ent.LoggerName != "" {
+		data["context"] = ent.LoggerName
+	}
+	payload, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		handleError(fmt.Errorf("logger: encode rollbar item failed: %v", err))
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, r.cfg.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Rollbar-Access-Token", r.cfg.Token)
+	r.queue.send(req)
+}
+
+func (r *rollbarReporter) Flush(timeout time.Duration) bool {
+	return r.queue.wait(timeout)
+}
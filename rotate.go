@@ -0,0 +1,254 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// rotator lumberjack.Logger 和 rotatelogs.RotateLogs 都实现了该接口
+type rotator interface {
+	Rotate() error
+}
+
+// Rotate 立即切割所有日志文件
+func (log *Log) Rotate() error {
+	if log.reload == nil {
+		return nil
+	}
+	var firstErr error
+	for _, r := range log.reload.load().outputs.rotators {
+		if err := r.Rotate(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// RotatePath RegisterRotateHandler使用的默认路径
+const RotatePath = "/logz/rotate"
+
+// RotateHandler 收到POST请求时立即切割所有日志文件，供logadmin等运维工具调用
+func (log *Log) RotateHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := log.Rotate(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// RegisterRotateHandler 在mux的 /logz/rotate 上注册RotateHandler，应只对内部开放
+func (log *Log) RegisterRotateHandler(mux *http.ServeMux) {
+	mux.Handle(RotatePath, log.RotateHandler())
+}
+
+// RotateOnSignal 收到指定信号(默认SIGHUP)时切割日志文件，返回的函数用于停止监听
+func (log *Log) RotateOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := log.Rotate(); err != nil {
+					handleError(fmt.Errorf("logger: rotate failed: %v", err))
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+// rotateHooks 日志切割后的回调注册表
+type rotateHooks struct {
+	mu         sync.RWMutex
+	fns        []func(oldPath, newPath string)
+	command    []string
+	compressor *compressor
+	fileMode   os.FileMode // rotatelogs新建的文件固定为0644，非0时在切换文件后修改权限
+}
+
+func newRotateHooks(command string) *rotateHooks {
+	return &rotateHooks{command: strings.Fields(command)}
+}
+
+func (h *rotateHooks) add(fn func(oldPath, newPath string)) {
+	h.mu.Lock()
+	h.fns = append(h.fns, fn)
+	h.mu.Unlock()
+}
+
+// fire 开启压缩时先在后台压缩切割出的文件，完成后以压缩文件路径执行回调
+func (h *rotateHooks) fire(oldPath, newPath string) {
+	if h.compressor != nil && oldPath != "" {
+		h.compressor.submit(oldPath, func(compressed string) {
+			h.run(compressed, newPath)
+		})
+		return
+	}
+	h.run(oldPath, newPath)
+}
+
+// run 依次执行回调，最后执行外部命令，命令参数中的{old}、{new}替换为切割前后的文件路径
+func (h *rotateHooks) run(oldPath, newPath string) {
+	h.mu.RLock()
+	fns := h.fns
+	h.mu.RUnlock()
+	for _, fn := range fns {
+		fn(oldPath, newPath)
+	}
+
+	if len(h.command) == 0 {
+		return
+	}
+	replacer := strings.NewReplacer("{old}", oldPath, "{new}", newPath)
+	args := make([]string, len(h.command))
+	for i, arg := range h.command {
+		args[i] = replacer.Replace(arg)
+	}
+	if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+		handleError(fmt.Errorf("logger: rotate command %q failed: %v: %s", args, err, out))
+	}
+}
+
+// Handle 实现rotatelogs.Handler，rotatelogs首次打开文件时PreviousFile为空，忽略
+func (h *rotateHooks) Handle(e rotatelogs.Event) {
+	ev, ok := e.(*rotatelogs.FileRotatedEvent)
+	if !ok {
+		return
+	}
+	if h.fileMode != 0 {
+		_ = os.Chmod(ev.CurrentFile(), h.fileMode)
+	}
+	if ev.PreviousFile() != "" {
+		h.fire(ev.PreviousFile(), ev.CurrentFile())
+	}
+}
+
+// OnRotate 注册日志切割后的回调，oldPath为切割出的旧文件，newPath为当前写入的文件
+func (log *Log) OnRotate(fn func(oldPath, newPath string)) {
+	if log.rotateHooks != nil {
+		log.rotateHooks.add(fn)
+	}
+}
+
+// sizeWriter 包装lumberjack.Logger，按与lumberjack相同的规则统计文件大小，
+// 以便在发生切割时触发回调
+type sizeWriter struct {
+	*lumberjack.Logger
+	mu    sync.Mutex
+	size  int64
+	hooks *rotateHooks
+
+	// 设置了RotatePattern时按规则重命名备份文件，dirMode为创建备份目录的权限
+	pattern *filePattern
+	loc     *time.Location
+	clock   Clock
+	dirMode os.FileMode
+}
+
+func newSizeWriter(l *lumberjack.Logger, hooks *rotateHooks) *sizeWriter {
+	w := &sizeWriter{Logger: l, hooks: hooks}
+	if info, err := os.Stat(l.Filename); err == nil {
+		w.size = info.Size()
+	}
+	return w
+}
+
+func (w *sizeWriter) max() int64 {
+	if w.MaxSize == 0 {
+		return 100 * 1024 * 1024
+	}
+	return int64(w.MaxSize) * 1024 * 1024
+}
+
+func (w *sizeWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	rotated := w.size > 0 && w.size+int64(len(p)) > w.max()
+	n, err := w.Logger.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if rotated {
+		w.size = 0
+		w.afterRotate()
+	}
+	w.size += int64(n)
+	return n, nil
+}
+
+func (w *sizeWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.Logger.Rotate(); err != nil {
+		return err
+	}
+	w.size = 0
+	w.afterRotate()
+	return nil
+}
+
+// afterRotate 必须在持有锁时调用，按RotatePattern重命名备份文件后异步执行回调、压缩和清理
+func (w *sizeWriter) afterRotate() {
+	backup := w.lastBackup()
+	if w.pattern == nil || backup == "" {
+		go w.hooks.fire(backup, w.Filename)
+		return
+	}
+
+	name := w.pattern.name(w.clock.Now().In(w.loc))
+	if err := os.MkdirAll(filepath.Dir(name), w.dirMode); err == nil {
+		if err := os.Rename(backup, name); err == nil {
+			backup = name
+		}
+	}
+	go w.hooks.fire(backup, w.Filename)
+}
+
+// _lumberjackTimeGlob 匹配lumberjack备份文件名中的时间 2006-01-02T15-04-05.000
+const _lumberjackTimeGlob = "[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]T[0-9][0-9]-[0-9][0-9]-[0-9][0-9].[0-9][0-9][0-9]"
+
+// lumberjackBackupGlob 只匹配lumberjack为filename切割出的备份文件 name-2006-01-02T15-04-05.000.ext，
+// 不会匹配同一目录下的 name-error.ext 等其他日志文件
+func lumberjackBackupGlob(filename string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "-" + _lumberjackTimeGlob + ext
+}
+
+// lastBackup 查找lumberjack最新的备份文件，时间格式固定，按文件名排序即按时间排序
+func (w *sizeWriter) lastBackup() string {
+	matches, _ := filepath.Glob(lumberjackBackupGlob(w.Filename))
+	if len(matches) == 0 {
+		return ""
+	}
+	sort.Strings(matches)
+	return matches[len(matches)-1]
+}
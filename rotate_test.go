@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeFile 创建内容为data、修改时间为modTime的文件
+func writeFile(t *testing.T, path, data string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLumberjackBackupGlob(t *testing.T) {
+	dir := t.TempDir()
+	glob := lumberjackBackupGlob(filepath.Join(dir, "app.log"))
+	for name, want := range map[string]bool{
+		"app-2024-01-02T03-04-05.678.log":       true,
+		"app-error.log":                         false,
+		"app-error-2024-01-02T03-04-05.678.log": false,
+		"app.log":                               false,
+		"app-2024-01-02.log":                    false,
+	} {
+		got, err := filepath.Match(glob, filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("match %s = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestRotateCompressesOwnBackup(t *testing.T) {
+	dir := t.TempDir()
+	info, errFile := filepath.Join(dir, "app.log"), filepath.Join(dir, "app-error.log")
+	c := New(WithoutConsole(), WithInfoFile(info), WithErrorFile(errFile), WithDivision(SizeDivision),
+		WithCompression(CompressionGzip, 0))
+	c.LevelSeparate = true
+	log := c.InitLoggerWith(EncoderOptions{})
+	log.Info("info")
+	log.Error("error")
+	if err := log.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		compressed, _ := filepath.Glob(lumberjackBackupGlob(info) + ".gz")
+		if len(compressed) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("backup of %s was not compressed", info)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := os.Stat(errFile); err != nil {
+		t.Fatalf("%s should not be touched by rotation of %s: %v", errFile, info, err)
+	}
+	if _, err := os.Stat(errFile + ".gz"); err == nil {
+		t.Fatalf("%s was compressed as a backup of %s", errFile, info)
+	}
+}
+
+func TestRotatePatternUsesDirMode(t *testing.T) {
+	dir := t.TempDir()
+	info := filepath.Join(dir, "app.log")
+	c := New(WithoutConsole(), WithInfoFile(info), WithDivision(SizeDivision))
+	c.SetRotatePattern(filepath.Join(dir, "archive", "%Y%m%d", "app.%H%M%S.log"))
+	c.SetFileMode("0700", "")
+	log := c.InitLoggerWith(EncoderOptions{})
+	log.Info("info")
+	if err := log.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "archive", "*", "app.*.log"))
+	if len(backups) != 1 {
+		t.Fatalf("found backups %v, want 1", backups)
+	}
+	for _, d := range []string{filepath.Dir(backups[0]), filepath.Join(dir, "archive")} {
+		st, err := os.Stat(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := st.Mode().Perm(); mode != 0700 {
+			t.Errorf("%s created with mode %o, want 700", d, mode)
+		}
+	}
+}
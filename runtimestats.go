@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RuntimeStatsConfig 在日志中附加运行时状态，便于只根据日志排查问题
+type RuntimeStatsConfig struct {
+	// Interval 采样间隔(秒)，间隔内的日志使用同一次采样结果，0不附加
+	Interval int `toml:"interval" yaml:"interval" json:"interval"`
+	// Level 附加到该级别及以上的日志，默认 "warn"
+	Level string `toml:"level" yaml:"level" json:"level"`
+}
+
+// runtimeStats 运行时状态的快照
+type runtimeStats struct {
+	goroutines   int
+	heapInuse    uint64
+	heapAlloc    uint64
+	numGC        uint32
+	lastPause    time.Duration
+	totalPause   time.Duration
+	lastGC       time.Time
+	gcCPUPercent float64
+}
+
+func readRuntimeStats() runtimeStats {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	s := runtimeStats{
+		goroutines:   runtime.NumGoroutine(),
+		heapInuse:    ms.HeapInuse,
+		heapAlloc:    ms.HeapAlloc,
+		numGC:        ms.NumGC,
+		totalPause:   time.Duration(ms.PauseTotalNs),
+		gcCPUPercent: ms.GCCPUFraction * 100,
+	}
+	if ms.NumGC > 0 {
+		s.lastPause = time.Duration(ms.PauseNs[(ms.NumGC+255)%256])
+		s.lastGC = time.Unix(0, int64(ms.LastGC))
+	}
+	return s
+}
+
+func (s runtimeStats) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("goroutines", s.goroutines)
+	enc.AddUint64("heap_inuse", s.heapInuse)
+	enc.AddUint64("heap_alloc", s.heapAlloc)
+	enc.AddUint32("num_gc", s.numGC)
+	enc.AddDuration("gc_pause_last", s.lastPause)
+	enc.AddDuration("gc_pause_total", s.totalPause)
+	enc.AddFloat64("gc_cpu_percent", s.gcCPUPercent)
+	if !s.lastGC.IsZero() {
+		enc.AddTime("last_gc", s.lastGC)
+	}
+	return nil
+}
+
+// WithRuntimeStats 返回当前goroutine数量、堆内存、GC停顿等运行时状态的runtime字段，
+// 每次调用都会执行runtime.ReadMemStats，不宜在频繁输出的日志中使用
+func WithRuntimeStats() zap.Field {
+	return zap.Object("runtime", readRuntimeStats())
+}
+
+// runtimeSampler 按间隔采样运行时状态，附加到级别不低于level的日志
+type runtimeSampler struct {
+	level    zapcore.Level
+	interval time.Duration
+
+	mu    sync.Mutex
+	at    time.Time
+	field zap.Field
+}
+
+func (c *LogOptions) runtimeSampler() (*runtimeSampler, error) {
+	cfg := c.RuntimeStats
+	if cfg.Interval <= 0 {
+		return nil, nil
+	}
+	s := &runtimeSampler{level: zapcore.WarnLevel, interval: time.Duration(cfg.Interval) * time.Second}
+	if cfg.Level != "" {
+		if err := unmarshalLevel(&s.level, cfg.Level); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// load 返回最近一次采样的字段，超过间隔时重新采样
+func (s *runtimeSampler) load() zap.Field {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := time.Now(); now.Sub(s.at) >= s.interval {
+		s.at = now
+		s.field = WithRuntimeStats()
+	}
+	return s.field
+}
+
+func (s *runtimeSampler) addStats(ent *zapcore.Entry, fs []zapcore.Field) []zapcore.Field {
+	if ent.Level < s.level {
+		return fs
+	}
+	return append(fs[:len(fs):len(fs)], s.load())
+}
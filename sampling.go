@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingConfig 日志采样，Rules匹配的日志按规则采样，其余日志按Initial、Thereafter全局采样，
+// dpanic及以上级别的日志总是输出
+type SamplingConfig struct {
+	// Initial、Thereafter 全局采样：每秒内级别和消息都相同的日志先输出Initial条，之后每Thereafter条输出1条，
+	// Thereafter为0时之后的都不输出，Initial为0时不做全局采样
+	Initial    int `toml:"initial" yaml:"initial" json:"initial"`
+	Thereafter int `toml:"thereafter" yaml:"thereafter" json:"thereafter"`
+	// Rules 按顺序使用第一条匹配的规则，匹配的日志不再参与全局采样
+	Rules []SamplingRule `toml:"rules" yaml:"rules" json:"rules"`
+}
+
+// SamplingRule 按消息或字段匹配的采样规则，如
+//
+//	{Match: `msg == "health check"`, Rate: 1000}   // 每1000条输出1条
+//	{Match: `module == "payment" && level >= error`} // 总是输出
+type SamplingRule struct {
+	// Match 匹配条件，表达式同EntryFilter
+	Match string `toml:"match" yaml:"match" json:"match"`
+	// Rate 每Rate条匹配的日志输出1条，0或1表示全部输出
+	Rate int `toml:"rate" yaml:"rate" json:"rate"`
+}
+
+type samplingRule struct {
+	match predicate
+	rate  uint64
+	count uint64
+}
+
+// keep 按匹配次数决定是否输出，第1、Rate+1、2*Rate+1...条输出
+func (r *samplingRule) keep() bool {
+	if r.rate <= 1 {
+		return true
+	}
+	return (atomic.AddUint64(&r.count, 1)-1)%r.rate == 0
+}
+
+// sampler 全局采样的计数，每秒清零
+type sampler struct {
+	initial, thereafter uint64
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[samplerKey]uint64
+}
+
+type samplerKey struct {
+	level zapcore.Level
+	msg   string
+}
+
+func (s *sampler) keep(ent zapcore.Entry) bool {
+	s.mu.Lock()
+	if ent.Time.Sub(s.start) >= time.Second || ent.Time.Before(s.start) {
+		s.start = ent.Time
+		s.counts = make(map[samplerKey]uint64)
+	}
+	key := samplerKey{level: ent.Level, msg: ent.Message}
+	s.counts[key]++
+	n := s.counts[key]
+	s.mu.Unlock()
+	if n <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
+
+// sampling 按Sampling配置生成采样规则，未配置时返回nil
+func (c *LogOptions) sampling() (*samplingCoreConfig, error) {
+	cfg := c.Sampling
+	if cfg.Initial <= 0 && len(cfg.Rules) == 0 {
+		return nil, nil
+	}
+	sc := &samplingCoreConfig{}
+	if cfg.Initial > 0 {
+		sc.global = &sampler{initial: uint64(cfg.Initial), thereafter: uint64(cfg.Thereafter)}
+	}
+	for _, rule := range cfg.Rules {
+		p, err := parsePredicate(rule.Match)
+		if err != nil {
+			return nil, err
+		}
+		rate := rule.Rate
+		if rate < 0 {
+			rate = 0
+		}
+		sc.rules = append(sc.rules, &samplingRule{match: p, rate: uint64(rate)})
+	}
+	return sc, nil
+}
+
+type samplingCoreConfig struct {
+	rules  []*samplingRule
+	global *sampler
+}
+
+// samplingCore 按采样结果决定日志是否交给内部core
+type samplingCore struct {
+	zapcore.Core
+	cfg    *samplingCoreConfig
+	fields map[string]interface{}
+}
+
+func (c *samplingCore) With(fs []zapcore.Field) zapcore.Core {
+	clone := &samplingCore{Core: c.Core.With(fs), cfg: c.cfg, fields: c.fields}
+	// 只有按字段匹配规则时才需要保存字段
+	if len(c.cfg.rules) > 0 {
+		clone.fields = mergeFields(c.fields, fs)
+	}
+	return clone
+}
+
+func (c *samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *samplingCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	if !c.keep(ent, fs) {
+		return nil
+	}
+	inner := c.Core.Check(ent, nil)
+	if inner == nil {
+		return nil
+	}
+	inner.ErrorOutput = errorOutput{}
+	inner.Write(fs...)
+	return nil
+}
+
+func (c *samplingCore) keep(ent zapcore.Entry, fs []zapcore.Field) bool {
+	if ent.Level >= zapcore.DPanicLevel {
+		return true
+	}
+	if len(c.cfg.rules) > 0 {
+		fields := c.fields
+		if len(fs) > 0 {
+			fields = mergeFields(c.fields, fs)
+		}
+		for _, rule := range c.cfg.rules {
+			if rule.match.match(ent, fields) {
+				return rule.keep()
+			}
+		}
+	}
+	return c.cfg.global == nil || c.cfg.global.keep(ent)
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSamplingCounts(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		cfg      SamplingConfig
+		level    zapcore.Level
+		msg      string
+		fields   []zapcore.Field
+		interval time.Duration
+		n        int
+		want     int
+	}{
+		{name: "initial only", cfg: SamplingConfig{Initial: 3}, msg: "a", n: 10, want: 3},
+		// 前3条，之后第5、10条
+		{name: "thereafter", cfg: SamplingConfig{Initial: 3, Thereafter: 5}, msg: "a", n: 13, want: 5},
+		// 每秒重新计数
+		{name: "per second", cfg: SamplingConfig{Initial: 2}, msg: "a", interval: 500 * time.Millisecond, n: 10, want: 10},
+		{name: "dpanic always", cfg: SamplingConfig{Initial: 1}, level: zapcore.DPanicLevel, msg: "a", n: 5, want: 5},
+		// 第1、4、7、10条
+		{name: "rule rate", cfg: SamplingConfig{Rules: []SamplingRule{{Match: `msg == "health"`, Rate: 3}}}, msg: "health", n: 10, want: 4},
+		{name: "rule rate 1", cfg: SamplingConfig{Rules: []SamplingRule{{Match: `msg == "health"`, Rate: 1}}}, msg: "health", n: 10, want: 10},
+		{name: "rule by field", cfg: SamplingConfig{Rules: []SamplingRule{{Match: `module == "payment"`, Rate: 5}}},
+			msg: "a", fields: []zapcore.Field{zap.String("module", "payment")}, n: 10, want: 2},
+		// 匹配规则的日志不参与全局采样
+		{name: "rule before global", cfg: SamplingConfig{Initial: 1, Rules: []SamplingRule{{Match: `msg == "a"`}}}, msg: "a", n: 10, want: 10},
+		{name: "unmatched uses global", cfg: SamplingConfig{Initial: 1, Rules: []SamplingRule{{Match: `msg == "b"`, Rate: 2}}}, msg: "a", n: 10, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &LogOptions{Sampling: tt.cfg}
+			sc, err := c.sampling()
+			if err != nil {
+				t.Fatal(err)
+			}
+			obs, logs := observer.New(zapcore.DebugLevel)
+			core := zapcore.Core(&samplingCore{Core: obs, cfg: sc})
+			for i := 0; i < tt.n; i++ {
+				ent := zapcore.Entry{Level: tt.level, Message: tt.msg, Time: now.Add(time.Duration(i) * tt.interval)}
+				if ce := core.Check(ent, nil); ce != nil {
+					ce.Write(tt.fields...)
+				}
+			}
+			if got := logs.Len(); got != tt.want {
+				t.Errorf("kept %d of %d, want %d", got, tt.n, tt.want)
+			}
+		})
+	}
+}
+
+func TestSamplerKeys(t *testing.T) {
+	s := &sampler{initial: 1}
+	now := time.Now()
+	// 级别和消息都相同的日志一起计数
+	tests := []struct {
+		level zapcore.Level
+		msg   string
+		want  bool
+	}{
+		{zapcore.InfoLevel, "a", true},
+		{zapcore.InfoLevel, "b", true},
+		{zapcore.InfoLevel, "a", false},
+		{zapcore.WarnLevel, "a", true},
+		{zapcore.WarnLevel, "a", false},
+		{zapcore.InfoLevel, "b", false},
+	}
+	for i, tt := range tests {
+		if got := s.keep(zapcore.Entry{Level: tt.level, Message: tt.msg, Time: now}); got != tt.want {
+			t.Errorf("%d: keep(%s %q) = %v, want %v", i, tt.level, tt.msg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const _scrubPlaceholder = "[REDACTED]"
+
+// ScrubRule 将日志消息和字符串字段中匹配Pattern的内容替换为Replacement
+type ScrubRule struct {
+	Name    string `toml:"name" yaml:"name" json:"name"`
+	Pattern string `toml:"pattern" yaml:"pattern" json:"pattern"`
+	// Replacement 替换内容，支持 $1 等分组引用，默认 "[REDACTED]"
+	Replacement string `toml:"replacement" yaml:"replacement" json:"replacement"`
+}
+
+// DefaultScrubRules 常见敏感信息的规则，ScrubDefaults为true时启用
+var DefaultScrubRules = []ScrubRule{
+	{Name: "credit_card", Pattern: `\b(?:\d[ -]?){12,18}\d\b`},
+	{Name: "email", Pattern: `[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`},
+	{Name: "bearer_token", Pattern: `(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`, Replacement: "${1}" + _scrubPlaceholder},
+	{Name: "aws_access_key", Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "aws_secret_key", Pattern: `(?i)(aws_secret_access_key\s*[=:]\s*)[a-z0-9/+=]{40}`, Replacement: "${1}" + _scrubPlaceholder},
+	{Name: "jwt", Pattern: `\beyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+`},
+	{Name: "private_key", Pattern: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+}
+
+type scrubRegexp struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// scrubber 依次应用所有规则
+type scrubber []scrubRegexp
+
+func newScrubber(rules []ScrubRule) (scrubber, error) {
+	s := make(scrubber, 0, len(rules))
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("logger: invalid scrub rule %q: %v", rule.Name, err)
+		}
+		replacement := rule.Replacement
+		if replacement == "" {
+			replacement = _scrubPlaceholder
+		}
+		s = append(s, scrubRegexp{re: re, replacement: replacement})
+	}
+	return s, nil
+}
+
+func (s scrubber) scrub(str string) string {
+	for _, r := range s {
+		str = r.re.ReplaceAllString(str, r.replacement)
+	}
+	return str
+}
+
+func (s scrubber) scrubEntry(ent *zapcore.Entry, fs []zapcore.Field) []zapcore.Field {
+	ent.Message = s.scrub(ent.Message)
+	return fs
+}
+
+// scrubFields 处理字符串、error、Stringer类型的字段，返回新的slice，没有变化时返回原slice
+func (s scrubber) scrubFields(fs []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fs {
+		var value string
+		switch f.Type {
+		case zapcore.StringType:
+			value = f.String
+		case zapcore.ByteStringType:
+			value = string(f.Interface.([]byte))
+		case zapcore.ErrorType, zapcore.StringerType:
+			if f.Interface == nil {
+				continue
+			}
+			value = fmt.Sprint(f.Interface)
+		default:
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		scrubbed := s.scrub(value)
+		if scrubbed == value {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, i, len(fs))
+			copy(out, fs[:i])
+		}
+		out = append(out, zap.String(f.Key, scrubbed))
+	}
+	if out == nil {
+		return fs
+	}
+	return out
+}
+
+// scrubRules ScrubDefaults与Scrub合并后的规则
+func (c *LogOptions) scrubRules() []ScrubRule {
+	if !c.ScrubDefaults {
+		return c.Scrub
+	}
+	return append(append([]ScrubRule(nil), DefaultScrubRules...), c.Scrub...)
+}
@@ -1,29 +1,28 @@
 package logger
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 // 将zap的Level转换为sentry的Level
+// sentryLevel 按数值映射级别，Trace等低于Info的自定义级别作为debug
 func sentryLevel(lvl zapcore.Level) sentry.Level {
-	switch lvl {
-	case zapcore.DebugLevel:
+	switch {
+	case lvl < zapcore.InfoLevel:
 		return sentry.LevelDebug
-	case zapcore.InfoLevel:
+	case lvl == zapcore.InfoLevel:
 		return sentry.LevelInfo
-	case zapcore.WarnLevel:
+	case lvl == zapcore.WarnLevel:
 		return sentry.LevelWarning
-	case zapcore.ErrorLevel:
+	case lvl == zapcore.ErrorLevel:
 		return sentry.LevelError
-	case zapcore.DPanicLevel:
-		return sentry.LevelFatal
-	case zapcore.PanicLevel:
-		return sentry.LevelFatal
-	case zapcore.FatalLevel:
-		return sentry.LevelFatal
 	default:
 		return sentry.LevelFatal
 	}
@@ -35,6 +34,155 @@ type SentryLoggerConfig struct {
 	AttachStacktrace bool
 	Environment      string
 	Tags             map[string]string
+	// Release 为空时根据编译信息自动生成，见buildRelease
+	Release    string  `toml:"release" yaml:"release" json:"release"`
+	Dist       string  `toml:"dist" yaml:"dist" json:"dist"`
+	ServerName string  `toml:"server_name" yaml:"server_name" json:"server_name"`
+	SampleRate float64 `toml:"sample_rate" yaml:"sample_rate" json:"sample_rate"`
+	// Level 上报sentry的最低级别，如 "warn"，默认 "error"
+	Level string `toml:"level" yaml:"level" json:"level"`
+	// LevelMapping zap级别到sentry级别的映射，如 {"warn": "error"}，未配置的级别使用sentryLevel的默认映射
+	LevelMapping map[string]string `toml:"level_mapping" yaml:"level_mapping" json:"level_mapping"`
+	// FatalField 带有该字段(且值不为false)的日志以fatal级别上报
+	FatalField string `toml:"fatal_field" yaml:"fatal_field" json:"fatal_field"`
+	// BreadcrumbLevel 低于Level但不低于该级别的日志记录为breadcrumb，随下一次上报的事件一起发送，为空时不记录
+	BreadcrumbLevel string `toml:"breadcrumb_level" yaml:"breadcrumb_level" json:"breadcrumb_level"`
+	// MaxBreadcrumbs 每个scope保留的breadcrumb数量上限，默认30
+	MaxBreadcrumbs int `toml:"max_breadcrumbs" yaml:"max_breadcrumbs" json:"max_breadcrumbs"`
+	// FingerprintFields 按这些字段的值设置事件指纹，使同类错误按字段而不是消息文本分组
+	FingerprintFields []string `toml:"fingerprint_fields" yaml:"fingerprint_fields" json:"fingerprint_fields"`
+	// RateLimit 每个指纹(未设置指纹时为消息文本)每分钟最多上报的事件数，0不限制，
+	// 被丢弃的事件数记录在下一次上报事件的sentry_suppressed字段中
+	RateLimit int `toml:"rate_limit" yaml:"rate_limit" json:"rate_limit"`
+	// Filter 按字段条件决定是否上报，表达式同EntryFilter，如 Keep: ["severity =~ \"^(critical|fatal)$\""]，
+	// 只影响事件，breadcrumb不受限制
+	Filter EntryFilter `toml:"filter" yaml:"filter" json:"filter"`
+	// IgnoreErrors 日志中的错误(zap.Error等)匹配时不上报，可以是 "context.Canceled"、"io.EOF" 等常见错误名称，
+	// 错误类型如 "*net.OpError"，或完整的错误信息，匹配时会检查整个错误链
+	IgnoreErrors []string `toml:"ignore_errors" yaml:"ignore_errors" json:"ignore_errors"`
+	// HTTPProxy、HTTPSProxy 上报使用的代理地址
+	HTTPProxy  string `toml:"http_proxy" yaml:"http_proxy" json:"http_proxy"`
+	HTTPSProxy string `toml:"https_proxy" yaml:"https_proxy" json:"https_proxy"`
+	// Timeout 单次上报请求的超时时间(秒)，默认30
+	Timeout int `toml:"timeout" yaml:"timeout" json:"timeout"`
+	// BufferSize 异步上报的事件队列长度，默认30
+	BufferSize int `toml:"buffer_size" yaml:"buffer_size" json:"buffer_size"`
+	// FlushTimeout 上报fatal事件及Sync时等待发送完成的时间(秒)，默认3
+	FlushTimeout int `toml:"flush_timeout" yaml:"flush_timeout" json:"flush_timeout"`
+	// SyncTransport 同步上报，适用于命令行、定时任务等短生命周期的进程，避免进程退出时丢失事件
+	SyncTransport bool `toml:"sync_transport" yaml:"sync_transport" json:"sync_transport"`
+	// BeforeSend 事件发送前调用，可修改事件(脱敏、调整tag等)，返回nil则丢弃该事件，
+	// 同 sentry.ClientOptions.BeforeSend，只能通过代码设置
+	BeforeSend func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event `toml:"-" yaml:"-" json:"-"`
+}
+
+// parseSentryLevel 解析sentry级别，兼容zap的 "warn"、"dpanic"、"panic" 写法
+func parseSentryLevel(s string) (sentry.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return sentry.LevelDebug, nil
+	case "info":
+		return sentry.LevelInfo, nil
+	case "warn", "warning":
+		return sentry.LevelWarning, nil
+	case "error":
+		return sentry.LevelError, nil
+	case "fatal", "dpanic", "panic":
+		return sentry.LevelFatal, nil
+	}
+	return "", fmt.Errorf("logger: unknown sentry level %q", s)
+}
+
+// clientOptions 根据配置生成sentry.ClientOptions
+func (s SentryLoggerConfig) clientOptions() sentry.ClientOptions {
+	release := s.Release
+	if release == "" {
+		release = buildRelease()
+	}
+	return sentry.ClientOptions{
+		Transport:        s.transport(),
+		HTTPProxy:        s.HTTPProxy,
+		HTTPSProxy:       s.HTTPSProxy,
+		Dsn:              s.DSN,
+		Debug:            s.Debug,
+		AttachStacktrace: s.AttachStacktrace,
+		Environment:      s.Environment,
+		Release:          release,
+		Dist:             s.Dist,
+		ServerName:       s.ServerName,
+		SampleRate:       s.SampleRate,
+		BeforeSend:       s.BeforeSend,
+	}
+}
+
+// transport 根据配置生成sentry.Transport，超时、队列长度都未设置且异步上报时返回nil，使用sentry默认的transport
+func (s SentryLoggerConfig) transport() sentry.Transport {
+	timeout := time.Duration(s.Timeout) * time.Second
+	if s.SyncTransport {
+		t := sentry.NewHTTPSyncTransport()
+		if timeout > 0 {
+			t.Timeout = timeout
+		}
+		return t
+	}
+	if timeout == 0 && s.BufferSize == 0 {
+		return nil
+	}
+	t := sentry.NewHTTPTransport()
+	if timeout > 0 {
+		t.Timeout = timeout
+	}
+	if s.BufferSize > 0 {
+		t.BufferSize = s.BufferSize
+	}
+	return t
+}
+
+// coreConfig 根据配置生成sentryCoreConfig
+func (s SentryLoggerConfig) coreConfig() (sentryCoreConfig, error) {
+	cfg := sentryCoreConfig{
+		Level:             zapcore.ErrorLevel,
+		Tags:              s.Tags,
+		DisableStacktrace: !s.AttachStacktrace,
+		FatalField:        s.FatalField,
+		MaxBreadcrumbs:    s.MaxBreadcrumbs,
+		FingerprintFields: s.FingerprintFields,
+		FlushTimeout:      time.Duration(s.FlushTimeout) * time.Second,
+	}
+	if s.RateLimit > 0 {
+		cfg.limiter = newRateLimiter(s.RateLimit, time.Minute)
+	}
+	filter, err := newSentryFilter(s.Filter, s.IgnoreErrors)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.filter = filter
+	if s.Level != "" {
+		if err := unmarshalLevel(&cfg.Level, s.Level); err != nil {
+			return cfg, err
+		}
+	}
+	if s.BreadcrumbLevel != "" {
+		if err := unmarshalLevel(&cfg.BreadcrumbLevel, s.BreadcrumbLevel); err != nil {
+			return cfg, err
+		}
+		cfg.Breadcrumbs = cfg.BreadcrumbLevel < cfg.Level
+	}
+	if len(s.LevelMapping) > 0 {
+		cfg.LevelMapping = make(map[zapcore.Level]sentry.Level, len(s.LevelMapping))
+		for from, to := range s.LevelMapping {
+			var lvl zapcore.Level
+			if err := unmarshalLevel(&lvl, from); err != nil {
+				return cfg, err
+			}
+			sLvl, err := parseSentryLevel(to)
+			if err != nil {
+				return cfg, err
+			}
+			cfg.LevelMapping[lvl] = sLvl
+		}
+	}
+	return cfg, nil
 }
 
 // SentryCoreConfig 定义 Sentry Core 的配置参数.
@@ -44,6 +192,14 @@ type sentryCoreConfig struct {
 	Level             zapcore.Level
 	FlushTimeout      time.Duration
 	Hub               *sentry.Hub
+	LevelMapping      map[zapcore.Level]sentry.Level
+	FatalField        string
+	Breadcrumbs       bool
+	BreadcrumbLevel   zapcore.Level
+	MaxBreadcrumbs    int
+	FingerprintFields []string
+	limiter           *rateLimiter
+	filter            *sentryFilter
 }
 
 // sentryCore sentrycore的Core结构体，用于实现Core接口
@@ -53,6 +209,71 @@ type core struct {
 	zapcore.LevelEnabler                        // LevelEnabler接口
 	flushTimeout         time.Duration          // sentry上报的flush时间
 	fields               map[string]interface{} // 保存Fields
+	fingerprint          []string               // WithFingerprint设置的事件指纹
+	user                 *sentry.User           // WithUser设置的用户
+	request              *sentry.Request        // WithHTTPRequest设置的请求
+	errs                 []error                // 字段中的错误，用于IgnoreErrors
+}
+
+const _fingerprintKey = "sentry.fingerprint"
+
+// WithFingerprint 设置sentry事件的指纹，指纹相同的事件归为同一个issue，
+// 该字段只对sentry生效，不会输出到日志文件
+func WithFingerprint(parts ...string) zap.Field {
+	return zap.Field{Key: _fingerprintKey, Type: zapcore.SkipType, Interface: parts}
+}
+
+// userField WithUser生成的字段，sentry core将其转换为事件的User
+type userField struct {
+	ID    string
+	Email string
+	IP    string
+}
+
+func (u userField) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if u.ID != "" {
+		enc.AddString("id", u.ID)
+	}
+	if u.Email != "" {
+		enc.AddString("email", u.Email)
+	}
+	if u.IP != "" {
+		enc.AddString("ip_address", u.IP)
+	}
+	return nil
+}
+
+// WithUser 记录受影响的用户，sentry事件中显示为User
+func WithUser(id, email, ip string) zap.Field {
+	return zap.Object("user", userField{ID: id, Email: email, IP: ip})
+}
+
+// requestField WithHTTPRequest生成的字段，sentry core将其转换为事件的Request
+type requestField struct {
+	r *http.Request
+}
+
+func (f requestField) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("method", f.r.Method)
+	enc.AddString("url", f.r.URL.String())
+	enc.AddString("remote_addr", f.r.RemoteAddr)
+	if ua := f.r.UserAgent(); ua != "" {
+		enc.AddString("user_agent", ua)
+	}
+	return nil
+}
+
+// WithHTTPRequest 记录触发日志的http请求，sentry事件中显示为Request
+func WithHTTPRequest(r *http.Request) zap.Field {
+	return zap.Object("http_request", requestField{r: r})
+}
+
+func fingerprintOf(f zapcore.Field) ([]string, bool) {
+	if f.Type != zapcore.SkipType || f.Key != _fingerprintKey {
+		return nil, false
+	}
+	parts, ok := f.Interface.([]string)
+	return parts, ok
 }
 
 // With接口方法的实际实现，对传入fields进行设置日志打印时的打印解析方式并添加到已有的fields中
@@ -64,8 +285,25 @@ func (c *core) with(fs []zapcore.Field) *core {
 	}
 
 	// Add fields to an in-memory encoder.
+	fingerprint, user, request, errs := c.fingerprint, c.user, c.request, c.errs
 	enc := zapcore.NewMapObjectEncoder()
 	for _, f := range fs {
+		if parts, ok := fingerprintOf(f); ok {
+			fingerprint = parts
+			continue
+		}
+		switch v := f.Interface.(type) {
+		case userField:
+			user = &sentry.User{ID: v.ID, Email: v.Email, IPAddress: v.IP}
+			continue
+		case requestField:
+			request = sentry.NewRequest(v.r)
+			continue
+		}
+		// WithError展开后的字段为字符串类型，Interface中保留了原错误
+		if err, ok := f.Interface.(error); ok && (f.Type == zapcore.ErrorType || f.Type == zapcore.StringType) {
+			errs = append(errs[:len(errs):len(errs)], err)
+		}
 		f.AddTo(enc)
 	}
 
@@ -78,6 +316,11 @@ func (c *core) with(fs []zapcore.Field) *core {
 		client:       c.client,
 		cfg:          c.cfg,
 		fields:       m,
+		fingerprint:  fingerprint,
+		user:         user,
+		request:      request,
+		errs:         errs,
+		flushTimeout: c.flushTimeout,
 		LevelEnabler: c.LevelEnabler,
 	}
 }
@@ -99,13 +342,54 @@ func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.Check
 func (c *core) Write(ent zapcore.Entry, fs []zapcore.Field) error {
 	clone := c.with(fs)
 
+	hub := c.cfg.Hub
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	// 低于上报级别的日志只记录为breadcrumb
+	if ent.Level < c.cfg.Level {
+		hub.Scope().AddBreadcrumb(&sentry.Breadcrumb{
+			Category:  ent.LoggerName,
+			Data:      clone.fields,
+			Level:     sentryLevel(ent.Level),
+			Message:   ent.Message,
+			Timestamp: ent.Time,
+			Type:      "default",
+		}, c.maxBreadcrumbs())
+		return nil
+	}
+
+	if !c.cfg.filter.allow(ent, clone.fields, clone.errs) {
+		return nil
+	}
+
 	event := sentry.NewEvent()
 	event.Message = ent.Message
 	event.Timestamp = ent.Time
-	event.Level = sentryLevel(ent.Level)
+	event.Level = c.level(ent.Level, clone.fields)
 	event.Platform = "demo"
 	event.Extra = clone.fields
 	event.Tags = c.cfg.Tags
+	event.Fingerprint = clone.eventFingerprint()
+	if c.cfg.limiter != nil {
+		key := ent.Message
+		if len(event.Fingerprint) > 0 {
+			key = strings.Join(event.Fingerprint, "\x00")
+		}
+		// 使用日志时间限流，设置了Clock时限流窗口同样受其控制
+		ok, suppressed := c.cfg.limiter.allow(key, ent.Time)
+		if !ok {
+			return nil
+		}
+		if suppressed > 0 {
+			event.Extra["sentry_suppressed"] = suppressed
+		}
+	}
+	if clone.user != nil {
+		event.User = *clone.user
+	}
+	event.Request = clone.request
 
 	if !c.cfg.DisableStacktrace {
 		trace := sentry.NewStacktrace()
@@ -120,24 +404,127 @@ func (c *core) Write(ent zapcore.Entry, fs []zapcore.Field) error {
 		}
 	}
 
-	hub := c.cfg.Hub
-	if hub == nil {
-		hub = sentry.CurrentHub()
-	}
 	_ = c.client.CaptureEvent(event, nil, hub.Scope())
 
-	if ent.Level > zapcore.ErrorLevel {
+	if ent.Level > zapcore.ErrorLevel || event.Level == sentry.LevelFatal {
 		c.client.Flush(c.flushTimeout)
 	}
 	return nil
 }
 
+// eventFingerprint WithFingerprint优先，其次按FingerprintFields中字段的值生成指纹，
+// 再次按error_code(见WithCode)分组，都没有时返回nil，由sentry按默认规则分组
+func (c *core) eventFingerprint() []string {
+	if len(c.fingerprint) > 0 {
+		return c.fingerprint
+	}
+	var parts []string
+	for _, key := range c.cfg.FingerprintFields {
+		if v, ok := c.fields[key]; ok {
+			parts = append(parts, fmt.Sprint(v))
+		}
+	}
+	if len(parts) == 0 {
+		if code, ok := c.fields[ErrorCodeKey]; ok {
+			parts = []string{ErrorCodeKey, fmt.Sprint(code)}
+		}
+	}
+	return parts
+}
+
+func (c *core) maxBreadcrumbs() int {
+	if c.cfg.MaxBreadcrumbs > 0 {
+		return c.cfg.MaxBreadcrumbs
+	}
+	return 30
+}
+
+// level 计算上报sentry的级别，带有FatalField字段的日志提升为fatal
+func (c *core) level(lvl zapcore.Level, fields map[string]interface{}) sentry.Level {
+	if c.cfg.FatalField != "" {
+		if v, ok := fields[c.cfg.FatalField]; ok && v != false {
+			return sentry.LevelFatal
+		}
+	}
+	if l, ok := c.cfg.LevelMapping[lvl]; ok {
+		return l
+	}
+	return sentryLevel(lvl)
+}
+
 // Sync 实现Core接口的Sync方法
 func (c *core) Sync() error {
 	c.client.Flush(c.flushTimeout)
 	return nil
 }
 
+// countSent 在BeforeSend之后统计实际发送的事件数
+func (s SentryLoggerConfig) countSent(m *metrics) SentryLoggerConfig {
+	if m == nil {
+		return s
+	}
+	before := s.BeforeSend
+	s.BeforeSend = func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		if before != nil {
+			if event = before(event, hint); event == nil {
+				return nil
+			}
+		}
+		m.sent(ReporterSentry)
+		return event
+	}
+	return s
+}
+
+// newCore 根据配置生成sentry core
+func (s SentryLoggerConfig) newCore() (*core, error) {
+	// sentrycore配置
+	cfg, err := s.coreConfig()
+	if err != nil {
+		return nil, err
+	}
+	// 生成sentry客户端
+	sentryClient, err := sentry.NewClient(s.clientOptions())
+	if err != nil {
+		return nil, err
+	}
+	return NewSentryCore(cfg, sentryClient), nil
+}
+
+// sentryCore 根据SentryConfig和SentryRoutes生成sentry core，都未配置时返回nil
+func (c *LogOptions) sentryCore() zapcore.Core {
+	var fallback zapcore.Core
+	if c.SentryConfig.DSN != "" {
+		sCore, err := c.SentryConfig.countSent(c.metrics).newCore()
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			fallback = sCore
+		}
+	}
+	if len(c.SentryRoutes) == 0 {
+		return fallback
+	}
+
+	router := &sentryRouter{fallback: fallback, env: c.SentryConfig.Environment}
+	for _, route := range c.SentryRoutes {
+		if route.Config.Environment == "" {
+			route.Config.Environment = c.SentryConfig.Environment
+		}
+		sCore, err := route.Config.countSent(c.metrics).newCore()
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		router.routes = append(router.routes, route)
+		router.cores = append(router.cores, sCore)
+	}
+	if len(router.cores) == 0 {
+		return fallback
+	}
+	return router
+}
+
 // NewSentryCore 生成Core对象
 func NewSentryCore(cfg sentryCoreConfig, sentryClient *sentry.Client) *core {
 
@@ -152,6 +539,9 @@ func NewSentryCore(cfg sentryCoreConfig, sentryClient *sentry.Client) *core {
 	if cfg.FlushTimeout > 0 {
 		core.flushTimeout = cfg.FlushTimeout
 	}
+	if cfg.Breadcrumbs {
+		core.LevelEnabler = cfg.BreadcrumbLevel
+	}
 
 	return &core
 }
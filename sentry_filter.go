@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// _knownErrors IgnoreErrors中可以按名称引用的常见错误，使用errors.Is匹配
+var _knownErrors = map[string]error{
+	"context.Canceled":         context.Canceled,
+	"context.DeadlineExceeded": context.DeadlineExceeded,
+	"io.EOF":                   io.EOF,
+	"io.ErrUnexpectedEOF":      io.ErrUnexpectedEOF,
+	"io.ErrClosedPipe":         io.ErrClosedPipe,
+	"net.ErrClosed":            net.ErrClosed,
+	"http.ErrAbortHandler":     http.ErrAbortHandler,
+	"http.ErrServerClosed":     http.ErrServerClosed,
+	"os.ErrDeadlineExceeded":   os.ErrDeadlineExceeded,
+}
+
+// sentryFilter 在sentry core中决定日志是否上报为事件
+type sentryFilter struct {
+	entries *entryFilter
+	ignore  []string
+}
+
+func newSentryFilter(f EntryFilter, ignore []string) (*sentryFilter, error) {
+	if len(f.Drop) == 0 && len(f.Keep) == 0 && len(ignore) == 0 {
+		return nil, nil
+	}
+	sf := &sentryFilter{ignore: ignore}
+	if len(f.Drop) > 0 || len(f.Keep) > 0 {
+		ef, err := newEntryFilter(f)
+		if err != nil {
+			return nil, err
+		}
+		sf.entries = ef
+	}
+	return sf, nil
+}
+
+// allow 日志中的任一错误匹配IgnoreErrors，或者不满足Filter时不上报
+func (f *sentryFilter) allow(ent zapcore.Entry, fields map[string]interface{}, errs []error) bool {
+	if f == nil {
+		return true
+	}
+	for _, err := range errs {
+		for _, name := range f.ignore {
+			if matchError(err, name) {
+				return false
+			}
+		}
+	}
+	return f.entries == nil || f.entries.allow(ent, fields)
+}
+
+// matchError name为_knownErrors中的名称时使用errors.Is匹配，
+// 否则与错误链中每个错误的类型(如 "*net.OpError")或错误信息比较
+func matchError(err error, name string) bool {
+	if target, ok := _knownErrors[name]; ok {
+		return errors.Is(err, target)
+	}
+	return walkErrors(err, func(e error) bool {
+		return fmt.Sprintf("%T", e) == name || e.Error() == name
+	})
+}
@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"fmt"
+	"path"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SentryRoute 将匹配的日志上报到单独的sentry项目，各匹配条件为空时不限制
+type SentryRoute struct {
+	// LoggerName 匹配日志名称(zap.Logger.Named)，支持 path.Match 通配符，如 "payment.*"
+	LoggerName string `toml:"logger_name" yaml:"logger_name" json:"logger_name"`
+	// Environment 仅在SentryConfig.Environment与之相同时生效
+	Environment string `toml:"environment" yaml:"environment" json:"environment"`
+	// Field、Value 匹配字段值，如 Field: "module"、Value: "api"，Value为空时只要求字段存在
+	Field  string             `toml:"field" yaml:"field" json:"field"`
+	Value  string             `toml:"value" yaml:"value" json:"value"`
+	Config SentryLoggerConfig `toml:"sentry_config" yaml:"sentry_config" json:"sentry_config"`
+}
+
+func (r SentryRoute) match(ent zapcore.Entry, fields map[string]interface{}, env string) bool {
+	if r.Environment != "" && r.Environment != env {
+		return false
+	}
+	if r.LoggerName != "" {
+		if ok, _ := path.Match(r.LoggerName, ent.LoggerName); !ok {
+			return false
+		}
+	}
+	if r.Field != "" {
+		v, ok := fields[r.Field]
+		if !ok || (r.Value != "" && fmt.Sprint(v) != r.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// sentryRouter 按SentryRoutes将日志分发到对应的sentry core，按顺序取第一个匹配的路由，
+// 都不匹配时交给fallback(即SentryConfig对应的core，可以为nil)
+type sentryRouter struct {
+	routes   []SentryRoute
+	cores    []zapcore.Core
+	fallback zapcore.Core
+	env      string
+	fields   map[string]interface{}
+}
+
+func (r *sentryRouter) Enabled(lvl zapcore.Level) bool {
+	if r.fallback != nil && r.fallback.Enabled(lvl) {
+		return true
+	}
+	for _, c := range r.cores {
+		if c.Enabled(lvl) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *sentryRouter) With(fs []zapcore.Field) zapcore.Core {
+	clone := &sentryRouter{
+		routes: r.routes,
+		cores:  make([]zapcore.Core, len(r.cores)),
+		env:    r.env,
+		fields: r.merge(fs),
+	}
+	for i, c := range r.cores {
+		clone.cores[i] = c.With(fs)
+	}
+	if r.fallback != nil {
+		clone.fallback = r.fallback.With(fs)
+	}
+	return clone
+}
+
+func (r *sentryRouter) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if r.Enabled(ent.Level) {
+		return ce.AddCore(ent, r)
+	}
+	return ce
+}
+
+func (r *sentryRouter) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	fields := r.merge(fs)
+	target := r.fallback
+	for i, route := range r.routes {
+		if route.match(ent, fields, r.env) {
+			target = r.cores[i]
+			break
+		}
+	}
+	if target == nil || !target.Enabled(ent.Level) {
+		return nil
+	}
+	return target.Write(ent, fs)
+}
+
+func (r *sentryRouter) Sync() error {
+	if r.fallback != nil {
+		_ = r.fallback.Sync()
+	}
+	for _, c := range r.cores {
+		_ = c.Sync()
+	}
+	return nil
+}
+
+// merge 返回已有字段与fs合并后的结果，用于匹配路由
+func (r *sentryRouter) merge(fs []zapcore.Field) map[string]interface{} {
+	if len(fs) == 0 {
+		return r.fields
+	}
+	return mergeFields(r.fields, fs)
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// 配置格式，用于NewFromBytes和NewFromReader，另见FormatHCL、FormatINI
+const (
+	FormatToml = "toml"
+	FormatYaml = "yaml"
+	FormatJson = "json"
+)
+
+// NewFromBytes 按format解析配置，支持profile(见ProfileEnv)及环境变量(见ApplyEnv)，
+// 用于go:embed、HTTP或数据库中的配置，不需要先写入临时文件
+func NewFromBytes(b []byte, format string) (*LogOptions, error) {
+	c, err := decodeConfig(b, format, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewFromReader 读取r中的全部内容，按format解析配置，见NewFromBytes
+func NewFromReader(r io.Reader, format string) (*LogOptions, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromBytes(b, format)
+}
+
+// nativeFormat 统一format的写法，并把HCL、INI配置转换为yaml
+func nativeFormat(b []byte, format string) ([]byte, string, error) {
+	switch format = strings.ToLower(format); format {
+	case FormatToml, FormatYaml, FormatJson:
+		return b, format, nil
+	case "yml":
+		return b, FormatYaml, nil
+	case FormatHCL:
+		y, err := hclToYaml(b)
+		return y, FormatYaml, err
+	case FormatINI:
+		y, err := iniToYaml(b)
+		return y, FormatYaml, err
+	}
+	return nil, "", fmt.Errorf("logger: unknown config format %q", format)
+}
+
+// decodeConfig 按format解析配置并覆盖profile，不应用环境变量
+func decodeConfig(b []byte, format, profile string) (*LogOptions, error) {
+	b, format, err := nativeFormat(b, format)
+	if err != nil {
+		return nil, err
+	}
+	c := &LogOptions{}
+	switch format {
+	case FormatToml:
+		_, err = toml.Decode(string(b), c)
+	case FormatYaml:
+		err = yaml.Unmarshal(b, c)
+	case FormatJson:
+		err = json.Unmarshal(b, c)
+	}
+	if err != nil {
+		return c, err
+	}
+	return c, c.applyProfile(b, format, profile)
+}
@@ -0,0 +1,339 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const _spoolSuffix = ".req"
+
+// SpoolConfig 网络输出不可用时将请求暂存到本地磁盘，恢复后按顺序重放。
+// 暂存文件中不保存地址和认证头，重放时使用当前配置中的地址和token；被拒绝(4xx)的请求计入丢弃
+type SpoolConfig struct {
+	// Dir 暂存目录，每个输出使用其中的一个子目录，为空时不暂存
+	Dir string `toml:"dir" yaml:"dir" json:"dir"`
+	// MaxSize 每个输出暂存的最大大小(MB)，超过时丢弃最早的请求，默认100
+	MaxSize int `toml:"max_size" yaml:"max_size" json:"max_size"`
+	// ReplayRate 恢复后每秒最多重放的请求数，默认10
+	ReplayRate int `toml:"replay_rate" yaml:"replay_rate" json:"replay_rate"`
+}
+
+// spoolAuth 返回请求的地址和认证头，由输出按当前配置提供。暂存时不保存地址和这些头，
+// webhook地址中的token、access token等凭据不会以明文落盘，重放时重新设置
+type spoolAuth func() (url string, header http.Header)
+
+// spoolRecord 暂存的http请求，URL为空时重放使用spoolAuth返回的地址
+type spoolRecord struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url,omitempty"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// deliver 发送请求，网络错误、429及5xx返回retry为true，可以稍后重试
+func deliver(client *http.Client, req *http.Request) (retry bool, err error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, errors.New(resp.Status)
+	}
+	if resp.StatusCode >= 300 {
+		return false, errors.New(resp.Status)
+	}
+	return false, nil
+}
+
+// spool 按顺序保存发送失败的请求，由单独的goroutine在输出恢复后重放
+type spool struct {
+	name     string
+	dir      string
+	maxSize  int64
+	interval time.Duration
+
+	mu sync.Mutex
+	// send、auth、metrics Reload时换成新的输出，见newSpool
+	send    func(*http.Request) (bool, error)
+	auth    spoolAuth
+	metrics *metrics
+	seq     uint64
+	count   int
+	size    int64
+	wake    chan struct{}
+	done    chan struct{}
+	stopped bool
+}
+
+// newSpool 未配置Spool时返回nil。Reload前已有同一目录的暂存时继续使用，只替换发送函数，
+// 同一目录只有一个goroutine重放，请求不会重复发送
+func (c *LogOptions) newSpool(name string, send func(*http.Request) (bool, error), auth spoolAuth) *spool {
+	if c.Spool.Dir == "" {
+		return nil
+	}
+	dir := filepath.Join(c.Spool.Dir, name)
+	if c.prevOutputs != nil {
+		if s := c.prevOutputs.spools[name]; s != nil && s.dir == dir {
+			s.mu.Lock()
+			s.send, s.auth, s.metrics = send, auth, c.metrics
+			s.mu.Unlock()
+			c.outputs.spools[name] = s
+			return s
+		}
+	}
+	maxSize := c.Spool.MaxSize
+	if maxSize <= 0 {
+		maxSize = 100
+	}
+	rate := c.Spool.ReplayRate
+	if rate <= 0 {
+		rate = 10
+	}
+	s := &spool{
+		name:     name,
+		dir:      dir,
+		maxSize:  int64(maxSize) * 1024 * 1024,
+		interval: time.Second / time.Duration(rate),
+		send:     send,
+		auth:     auth,
+		metrics:  c.metrics,
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	dirMode, _ := c.dirMode()
+	if err := os.MkdirAll(s.dir, dirMode); err != nil {
+		handleError(fmt.Errorf("logger: create spool directory %s: %v", s.dir, err))
+		return nil
+	}
+	// 继续重放上次退出前未发送的请求
+	files, err := s.files()
+	if err != nil {
+		handleError(fmt.Errorf("logger: read spool directory %s: %v", s.dir, err))
+		return nil
+	}
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			s.size += info.Size()
+		}
+		s.count++
+		seq, _ := strconv.ParseUint(strings.TrimSuffix(filepath.Base(f), _spoolSuffix), 10, 64)
+		if seq > s.seq {
+			s.seq = seq
+		}
+	}
+	if c.outputs != nil {
+		c.outputs.spools[name] = s
+	}
+	go s.replay()
+	return s
+}
+
+// stop Reload后不再使用时停止重放，未发送的请求留在目录中，下次启动时继续重放
+func (s *spool) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.done)
+	}
+}
+
+// sender 返回当前的发送函数、认证和指标
+func (s *spool) sender() (func(*http.Request) (bool, error), spoolAuth, *metrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.send, s.auth, s.metrics
+}
+
+// sleep 等待d，停止时返回false
+func (s *spool) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// files 按保存顺序返回暂存的请求文件
+func (s *spool) files() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), _spoolSuffix) {
+			files = append(files, filepath.Join(s.dir, e.Name()))
+		}
+	}
+	return files, nil
+}
+
+func (s *spool) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// pending 是否有尚未重放的请求，有时新的请求也需要进入暂存以保证顺序
+func (s *spool) pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count > 0
+}
+
+// _credentialHeaders 任何情况下都不写入暂存文件的请求头
+var _credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+func (s *spool) push(req *http.Request) {
+	_, auth, metrics := s.sender()
+	rec := spoolRecord{Method: req.Method, Header: req.Header.Clone()}
+	for _, key := range _credentialHeaders {
+		rec.Header.Del(key)
+	}
+	if auth != nil {
+		_, header := auth()
+		for key := range header {
+			rec.Header.Del(key)
+		}
+	} else {
+		rec.URL = req.URL.String()
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err == nil {
+			rec.Body, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d%s", s.seq, _spoolSuffix))
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		metrics.drop(s.name)
+		handleError(fmt.Errorf("logger: spool %s request: %v", s.name, err))
+		return
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		metrics.drop(s.name)
+		return
+	}
+	s.count++
+	s.size += int64(len(b))
+
+	// 超过大小限制时丢弃最早的请求
+	if s.size > s.maxSize {
+		files, _ := s.files()
+		for _, f := range files {
+			if s.size <= s.maxSize || f == name {
+				break
+			}
+			if info, err := os.Stat(f); err == nil && os.Remove(f) == nil {
+				s.size -= info.Size()
+				s.count--
+				metrics.drop(s.name)
+			}
+		}
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// request 由暂存的记录生成请求，地址和认证头取自当前配置
+func (s *spool) request(rec spoolRecord, auth spoolAuth) (*http.Request, error) {
+	url := rec.URL
+	var header http.Header
+	if auth != nil {
+		url, header = auth()
+	}
+	req, err := http.NewRequest(rec.Method, url, bytes.NewReader(rec.Body))
+	if err != nil {
+		return nil, err
+	}
+	if rec.Header != nil {
+		req.Header = rec.Header
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	return req, nil
+}
+
+func (s *spool) replay() {
+	backoff := time.Second
+	for {
+		files, err := s.files()
+		if err != nil || len(files) == 0 {
+			select {
+			case <-s.wake:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		send, auth, metrics := s.sender()
+		f := files[0]
+		b, err := os.ReadFile(f)
+		var rec spoolRecord
+		if err == nil {
+			err = json.Unmarshal(b, &rec)
+		}
+		var req *http.Request
+		if err == nil {
+			req, err = s.request(rec, auth)
+		}
+		if err == nil {
+			var retry bool
+			if retry, err = send(req); retry {
+				if !s.sleep(backoff) {
+					return
+				}
+				if backoff *= 2; backoff > time.Minute {
+					backoff = time.Minute
+				}
+				continue
+			}
+		}
+		backoff = time.Second
+		if err != nil {
+			// 无法读取或被拒绝(4xx)的请求不再重试
+			metrics.drop(s.name)
+			handleError(fmt.Errorf("logger: spooled %s request %s dropped: %v", s.name, filepath.Base(f), err))
+		}
+
+		s.mu.Lock()
+		if os.Remove(f) == nil {
+			s.count--
+			s.size -= int64(len(b))
+		}
+		s.mu.Unlock()
+		if !s.sleep(s.interval) {
+			return
+		}
+	}
+}
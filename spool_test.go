@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSpoolDoesNotPersistCredentials(t *testing.T) {
+	errs := captureErrors(t)
+	c := New()
+	c.Spool.Dir = t.TempDir()
+	c.Spool.ReplayRate = 1000
+
+	gate := make(chan struct{})
+	var mu sync.Mutex
+	var sent []*http.Request
+	send := func(req *http.Request) (bool, error) {
+		<-gate
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, req)
+		if len(sent) == 2 {
+			return false, errors.New("400 Bad Request")
+		}
+		return false, nil
+	}
+	// 重放时使用当前配置中的地址和token
+	auth := func() (string, http.Header) {
+		return "https://hooks.example.com/send?token=live", http.Header{"X-Api-Key": {"live-key"}}
+	}
+	s := c.newSpool("test", send, auth)
+	defer s.stop()
+
+	for _, body := range []string{"first", "second"} {
+		req, err := http.NewRequest(http.MethodPost, "https://hooks.example.com/send?token=old", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer old")
+		req.Header.Set("X-Api-Key", "old-key")
+		req.Header.Set("Content-Type", "text/plain")
+		s.push(req)
+	}
+
+	files, err := s.files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("spooled %d requests, want 2", len(files))
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{"token=old", "Bearer old", "old-key"} {
+			if bytes.Contains(b, []byte(secret)) {
+				t.Errorf("%s contains %q: %s", f, secret, b)
+			}
+		}
+	}
+
+	close(gate)
+	waitFor(t, func() bool { return s.len() == 0 })
+	mu.Lock()
+	defer mu.Unlock()
+	for i, req := range sent {
+		if req.URL.String() != "https://hooks.example.com/send?token=live" || req.Header.Get("X-Api-Key") != "live-key" {
+			t.Errorf("request %d sent to %s with key %q", i, req.URL, req.Header.Get("X-Api-Key"))
+		}
+		if req.Header.Get("Content-Type") != "text/plain" {
+			t.Errorf("request %d lost Content-Type", i)
+		}
+	}
+	body, _ := io.ReadAll(sent[0].Body)
+	if string(body) != "first" {
+		t.Errorf("first replayed body = %q", body)
+	}
+	// 被拒绝的请求不再重试，并报告给错误处理函数
+	if got := errs(); len(got) != 1 || !strings.Contains(got[0].Error(), "400 Bad Request") {
+		t.Fatalf("errors = %v", got)
+	}
+}
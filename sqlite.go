@@ -0,0 +1,253 @@
+package logger
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// sinkSQLite SQLite输出的指标名称
+const sinkSQLite = "sqlite"
+
+// _sqliteTimeLayout 时间列的格式，UTC时间，可以按字符串排序并直接用于SQLite的日期函数
+const _sqliteTimeLayout = "2006-01-02 15:04:05.000"
+
+// SQLiteConfig 将日志写入本地SQLite数据库，便于在没有日志系统的设备上用SQL查询日志。
+// 本包不依赖具体的驱动，需要在程序中导入，如 import _ "modernc.org/sqlite"(驱动名 "sqlite")
+// 或 import _ "github.com/mattn/go-sqlite3"(驱动名 "sqlite3")
+type SQLiteConfig struct {
+	// Path 数据库文件路径，为空时不启用
+	Path string `toml:"path" yaml:"path" json:"path"`
+	// Driver database/sql的驱动名，默认 "sqlite"
+	Driver string `toml:"driver" yaml:"driver" json:"driver"`
+	// Table 表名，默认 "logs"，不存在时自动创建，并在time和level、time上建立索引
+	Table string `toml:"table" yaml:"table" json:"table"`
+	// Level 写入的最低级别，默认与Level相同
+	Level string `toml:"level" yaml:"level" json:"level"`
+	// MaxRows 最多保留的条数，超过时删除最旧的日志，0不限制
+	MaxRows int `toml:"max_rows" yaml:"max_rows" json:"max_rows"`
+	// MaxSize 数据占用的最大大小(MB)，超过时删除最旧的日志，0不限制。
+	// 删除释放的空间由之后写入的日志复用，数据库文件本身不会缩小
+	MaxSize int `toml:"max_size" yaml:"max_size" json:"max_size"`
+	// Batch 合并写入的条件，每批在一个事务中写入，默认每100条或每1秒写入一次
+	Batch BatchConfig `toml:"batch" yaml:"batch" json:"batch"`
+}
+
+func (cfg SQLiteConfig) withDefaults() SQLiteConfig {
+	if cfg.Driver == "" {
+		cfg.Driver = "sqlite"
+	}
+	if cfg.Table == "" {
+		cfg.Table = "logs"
+	}
+	if cfg.Batch.MaxEntries <= 0 {
+		cfg.Batch.MaxEntries = 100
+	}
+	if cfg.Batch.MaxAge <= 0 {
+		cfg.Batch.MaxAge = 1
+	}
+	return cfg
+}
+
+// sqliteDB 打开的数据库，Reload时Path、Driver和Table不变则继续使用
+type sqliteDB struct {
+	cfg    SQLiteConfig
+	db     *sql.DB
+	insert string
+}
+
+// sqliteSink 按当前配置写入和清理sqliteDB，每次初始化或Reload时创建
+type sqliteSink struct {
+	*sqliteDB
+	maxRows int
+	maxSize int64
+	metrics *metrics
+}
+
+func openSQLite(cfg SQLiteConfig) (*sqliteDB, error) {
+	if !_sqlIdentifier.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("logger: invalid sqlite table name %q", cfg.Table)
+	}
+	db, err := sql.Open(cfg.Driver, cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("logger: open sqlite %s: %v", cfg.Path, err)
+	}
+	// SQLite同一时间只允许一个写入，单个连接避免 database is locked
+	db.SetMaxOpenConns(1)
+	s := &sqliteDB{
+		cfg: cfg,
+		db:  db,
+		insert: fmt.Sprintf("INSERT INTO %s (time, level, logger, caller, msg, stacktrace, fields) "+
+			"VALUES (?, ?, ?, ?, ?, ?, ?)", cfg.Table),
+	}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("logger: open sqlite %s: %v", cfg.Path, err)
+	}
+	return s, nil
+}
+
+// close Reload后不再使用时关闭数据库
+func (s *sqliteDB) close() {
+	if err := s.db.Close(); err != nil {
+		handleError(fmt.Errorf("logger: close sqlite %s: %v", s.cfg.Path, err))
+	}
+}
+
+func (s *sqliteDB) migrate() error {
+	t := s.cfg.Table
+	for _, stmt := range []string{
+		// WAL模式下写入不阻塞其他进程查询日志
+		"PRAGMA journal_mode=WAL",
+		"PRAGMA busy_timeout=5000",
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	time TEXT NOT NULL,
+	level TEXT NOT NULL,
+	logger TEXT NOT NULL DEFAULT '',
+	caller TEXT NOT NULL DEFAULT '',
+	msg TEXT NOT NULL DEFAULT '',
+	stacktrace TEXT NOT NULL DEFAULT '',
+	fields TEXT NOT NULL DEFAULT '{}'
+)`, t),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_time ON %s (time)", t, t),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_level_time ON %s (level, time)", t, t),
+	} {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send 在batcher的goroutine中执行，在一个事务中写入一批日志并按配置清理，写日志不等待磁盘。
+// 写入失败时这批日志被丢弃
+func (s *sqliteSink) send(rows []dbRow) {
+	start := time.Now()
+	err := s.write(rows)
+	s.metrics.observe(sinkSQLite, time.Since(start))
+	if err != nil {
+		s.metrics.writeError(sinkSQLite, err)
+		for range rows {
+			s.metrics.drop(sinkSQLite)
+		}
+		handleError(fmt.Errorf("logger: write sqlite %s failed: %v", s.cfg.Path, err))
+		return
+	}
+	size := 0
+	for _, r := range rows {
+		size += r.size()
+	}
+	s.metrics.written(sinkSQLite, size)
+	if err := s.prune(); err != nil {
+		handleError(fmt.Errorf("logger: prune sqlite %s failed: %v", s.cfg.Path, err))
+	}
+}
+
+func (s *sqliteDB) write(rows []dbRow) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(s.insert)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, r := range rows {
+		_, err := stmt.Exec(r.time.UTC().Format(_sqliteTimeLayout), r.level, r.logger, r.caller, r.message, r.stacktrace, r.fields)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// prune 按MaxRows和MaxSize删除最旧的日志，id自增，越小越旧
+func (s *sqliteSink) prune() error {
+	t := s.cfg.Table
+	if s.maxRows > 0 {
+		_, err := s.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id <= (SELECT MAX(id) FROM %s) - ?", t, t), s.maxRows)
+		if err != nil {
+			return err
+		}
+	}
+	if s.maxSize <= 0 {
+		return nil
+	}
+	used, err := s.usedBytes()
+	if err != nil {
+		return err
+	}
+	if used <= s.maxSize {
+		return nil
+	}
+	var count int64
+	if err := s.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", t)).Scan(&count); err != nil {
+		return err
+	}
+	// 按超出的比例估算需要删除的条数，多删除10%，避免之后每批写入都要删除
+	n := count*(used-s.maxSize)/used + count/10 + 1
+	_, err = s.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id IN (SELECT id FROM %s ORDER BY id LIMIT ?)", t, t), n)
+	return err
+}
+
+// usedBytes 数据库中已使用的页占用的字节数，不包括空闲页
+func (s *sqliteDB) usedBytes() (int64, error) {
+	var pages, free, pageSize int64
+	for _, q := range []struct {
+		pragma string
+		v      *int64
+	}{
+		{"page_count", &pages},
+		{"freelist_count", &free},
+		{"page_size", &pageSize},
+	} {
+		if err := s.db.QueryRow("PRAGMA " + q.pragma).Scan(q.v); err != nil {
+			return 0, err
+		}
+	}
+	return (pages - free) * pageSize, nil
+}
+
+// sqliteCore 根据SQLite配置生成core，prev为Reload前使用的数据库
+func (c *LogOptions) sqliteCore(level int8, encoderConfig zapcore.EncoderConfig, prev *sqliteDB) (zapcore.Core, *sqliteDB) {
+	if c.SQLite.Path == "" {
+		return nil, nil
+	}
+	cfg := c.SQLite.withDefaults()
+	enabler, err := dbLevel(cfg.Level, level)
+	if err != nil {
+		handleError(fmt.Errorf("logger: sqlite level: %v", err))
+		return nil, nil
+	}
+
+	db := prev
+	if db == nil || db.cfg.Path != cfg.Path || db.cfg.Driver != cfg.Driver || db.cfg.Table != cfg.Table {
+		// 预先按FileMode创建数据库文件，SQLite把空文件当作新的数据库
+		err = c.prepareLogFile(cfg.Path, true)
+		if err == nil {
+			db, err = openSQLite(cfg)
+		}
+		if err != nil {
+			handleError(err)
+			return nil, nil
+		}
+	}
+	sink := &sqliteSink{
+		sqliteDB: db,
+		maxRows:  cfg.MaxRows,
+		maxSize:  int64(cfg.MaxSize) * 1024 * 1024,
+		metrics:  c.metrics,
+	}
+
+	batch := newBatcher(sinkSQLite, cfg.Batch, sink.send)
+	batch.metrics = c.metrics
+	c.metrics.queue(sinkSQLite, batch.len)
+	c.closeOnReload(batch.close)
+	return &dbCore{LevelEnabler: enabler, enc: newFieldsEncoder(encoderConfig), batch: batch}, db
+}
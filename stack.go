@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// stackMarker WithStack字段的标记
+type stackMarker struct{}
+
+// WithStack 为本条日志输出调用栈，不受StacktraceLevel限制
+func WithStack() zapcore.Field {
+	return zapcore.Field{Key: "stacktrace", Type: zapcore.SkipType, Interface: stackMarker{}}
+}
+
+func isStackMarker(f zapcore.Field) bool {
+	_, ok := f.Interface.(stackMarker)
+	return ok && f.Type == zapcore.SkipType
+}
+
+// stackTrimmer 按配置裁剪日志的调用栈，并处理WithStack字段
+type stackTrimmer struct {
+	skip int
+	max  int
+}
+
+func (s stackTrimmer) entry(ent *zapcore.Entry, fs []zapcore.Field) []zapcore.Field {
+	for i, f := range fs {
+		if !isStackMarker(f) {
+			continue
+		}
+		fs = append(fs[:i:i], fs[i+1:]...)
+		if ent.Stack == "" {
+			ent.Stack = callerStack()
+		}
+		break
+	}
+	if ent.Stack != "" && (s.skip > 0 || s.max > 0) {
+		ent.Stack = trimStack(ent.Stack, s.skip, s.max)
+	}
+	return fs
+}
+
+// callerStack 返回调用方的调用栈，格式与zap相同，跳过栈顶zap和本包的帧
+func callerStack() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	var b strings.Builder
+	leading := true
+	for {
+		frame, more := frames.Next()
+		if leading && (strings.HasPrefix(frame.Function, "go.uber.org/zap") ||
+			strings.HasPrefix(frame.Function, "github.com/mae-pax/logger.")) {
+			if !more {
+				break
+			}
+			continue
+		}
+		leading = false
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// trimStack 跳过前skip帧并最多保留max帧，每帧占函数名和文件位置两行
+func trimStack(stack string, skip, max int) string {
+	lines := strings.Split(stack, "\n")
+	frames := len(lines) / 2
+	if skip > frames {
+		skip = frames
+	}
+	end := frames
+	if max > 0 && skip+max < frames {
+		end = skip + max
+	}
+	trimmed := strings.Join(lines[skip*2:end*2], "\n")
+	if end < frames {
+		trimmed += fmt.Sprintf("\n...(%d more frames)", frames-end)
+	}
+	return trimmed
+}
+
+// stacktraceLevel 返回StacktraceLevel对应的级别，默认warn
+func (c *LogOptions) stacktraceLevel() zapcore.Level {
+	level := zapcore.WarnLevel
+	if c.StacktraceLevel != "" {
+		if err := unmarshalLevel(&level, c.StacktraceLevel); err != nil {
+			fmt.Println(err)
+			return zapcore.WarnLevel
+		}
+	}
+	return level
+}
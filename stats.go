@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// _latencyBuckets 第i个桶统计耗时在[2^(i-1), 2^i)微秒之间的写入，最后一个桶包含所有更长的耗时
+const _latencyBuckets = 32
+
+// latencyHistogram 以2的幂次分桶统计写入耗时，记录只有原子加，百分位取所在桶的上界
+type latencyHistogram struct {
+	counts [_latencyBuckets]uint64
+	max    int64
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	i := bits.Len64(uint64(d / time.Microsecond))
+	if i >= _latencyBuckets {
+		i = _latencyBuckets - 1
+	}
+	atomic.AddUint64(&h.counts[i], 1)
+	for {
+		max := atomic.LoadInt64(&h.max)
+		if int64(d) <= max || atomic.CompareAndSwapInt64(&h.max, max, int64(d)) {
+			return
+		}
+	}
+}
+
+// snapshot 返回写入次数和p50、p90、p99
+func (h *latencyHistogram) snapshot() (n uint64, p50, p90, p99 time.Duration) {
+	var counts [_latencyBuckets]uint64
+	for i := range counts {
+		counts[i] = atomic.LoadUint64(&h.counts[i])
+		n += counts[i]
+	}
+	if n == 0 {
+		return 0, 0, 0, 0
+	}
+	percentile := func(p uint64) time.Duration {
+		// 第rank次写入所在的桶，rank从1开始
+		rank := (n*p + 99) / 100
+		var seen uint64
+		for i, c := range counts {
+			seen += c
+			if seen >= rank {
+				return time.Duration(uint64(1)<<uint(i)) * time.Microsecond
+			}
+		}
+		return 0
+	}
+	return n, percentile(50), percentile(90), percentile(99)
+}
+
+// SinkStats 单个输出自启动以来的写入统计，Latency*为近似值，取所在2的幂次区间的上界(微秒)
+type SinkStats struct {
+	Name       string        `json:"name"`
+	Writes     uint64        `json:"writes"`
+	Errors     uint64        `json:"errors"`
+	Dropped    uint64        `json:"dropped"`
+	QueueDepth int           `json:"queue_depth"`
+	LatencyP50 time.Duration `json:"latency_p50"`
+	LatencyP90 time.Duration `json:"latency_p90"`
+	LatencyP99 time.Duration `json:"latency_p99"`
+	LatencyMax time.Duration `json:"latency_max"`
+}
+
+// Stats 日志管道的统计，用于评估异步输出的容量
+type Stats struct {
+	Sinks []SinkStats `json:"sinks"`
+	// CompressionPending 等待及正在压缩的切割文件数
+	CompressionPending int `json:"compression_pending"`
+}
+
+// observe 记录一次写入的耗时，成功和失败的写入都会记录
+func (m *metrics) observe(sink string, d time.Duration) {
+	if m != nil {
+		m.sink(sink).latency.observe(d)
+	}
+}
+
+func (m *metrics) stats() []SinkStats {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	names := append([]string(nil), m.order...)
+	m.mu.Unlock()
+
+	var stats []SinkStats
+	for _, name := range names {
+		st := m.sink(name)
+		s := SinkStats{Name: name}
+		s.Writes, s.LatencyP50, s.LatencyP90, s.LatencyP99 = st.latency.snapshot()
+		s.LatencyMax = time.Duration(atomic.LoadInt64(&st.latency.max))
+		st.mu.Lock()
+		s.Errors = st.errors
+		s.Dropped = st.dropped
+		queues := st.queues
+		st.mu.Unlock()
+		for _, depth := range queues {
+			s.QueueDepth += depth()
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// Stats 返回各输出的写入耗时百分位、队列长度和丢弃条数
+func (log *Log) Stats() Stats {
+	stats := Stats{Sinks: log.metrics.stats()}
+	if log.rotateHooks != nil && log.rotateHooks.compressor != nil {
+		stats.CompressionPending = log.rotateHooks.compressor.pending()
+	}
+	return stats
+}
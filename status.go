@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthPath RegisterHealthHandler使用的默认路径
+const HealthPath = "/logz/health"
+
+// SinkStatus 单个输出的状态
+type SinkStatus struct {
+	Name          string    `json:"name"`
+	Healthy       bool      `json:"healthy"`
+	LastWrite     time.Time `json:"last_write"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorTime time.Time `json:"last_error_time"`
+	Errors        uint64    `json:"errors"`
+	Dropped       uint64    `json:"dropped"`
+	QueueDepth    int       `json:"queue_depth"`
+}
+
+// Status 日志管道的状态，任一输出不健康时Healthy为false
+type Status struct {
+	Healthy bool         `json:"healthy"`
+	Sinks   []SinkStatus `json:"sinks"`
+}
+
+type sinkState struct {
+	latency       latencyHistogram // 放在首位以保证原子操作的64位对齐
+	mu            sync.Mutex
+	lastWrite     time.Time
+	lastError     string
+	lastErrorTime time.Time
+	errors        uint64
+	dropped       uint64
+	queues        []func() int
+}
+
+// sink 返回输出的状态，不存在时创建
+func (m *metrics) sink(name string) *sinkState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.sinks[name]
+	if !ok {
+		st = &sinkState{}
+		m.sinks[name] = st
+		m.order = append(m.order, name)
+	}
+	return st
+}
+
+func (m *metrics) status() Status {
+	status := Status{Healthy: true}
+	if m == nil {
+		return status
+	}
+	m.mu.Lock()
+	names := append([]string(nil), m.order...)
+	m.mu.Unlock()
+
+	for _, name := range names {
+		st := m.sink(name)
+		st.mu.Lock()
+		s := SinkStatus{
+			Name:          name,
+			LastWrite:     st.lastWrite,
+			LastError:     st.lastError,
+			LastErrorTime: st.lastErrorTime,
+			Errors:        st.errors,
+			Dropped:       st.dropped,
+		}
+		queues := st.queues
+		st.mu.Unlock()
+		for _, depth := range queues {
+			s.QueueDepth += depth()
+		}
+		// 最近一次写入失败且之后没有成功写入时认为不健康
+		s.Healthy = s.LastErrorTime.IsZero() || s.LastWrite.After(s.LastErrorTime)
+		if !s.Healthy {
+			status.Healthy = false
+		}
+		status.Sinks = append(status.Sinks, s)
+	}
+	return status
+}
+
+// Status 返回各输出的状态，可用于就绪探针检测日志管道是否卡住
+func (log *Log) Status() Status {
+	return log.metrics.status()
+}
+
+// HealthHandler 以json返回Status，不健康时状态码为503
+func (log *Log) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := log.Status()
+		w.Header().Set("Content-Type", "application/json")
+		if !status.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(status)
+	})
+}
+
+// RegisterHealthHandler 在mux的 /logz/health 上注册HealthHandler
+func (log *Log) RegisterHealthHandler(mux *http.ServeMux) {
+	mux.Handle(HealthPath, log.HealthHandler())
+}
@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// 链路追踪使用的header
+const (
+	TraceparentHeader = "traceparent"
+	B3Header          = "b3"
+	B3TraceIDHeader   = "X-B3-TraceId"
+	B3SpanIDHeader    = "X-B3-SpanId"
+	B3SampledHeader   = "X-B3-Sampled"
+)
+
+// TraceContext 从请求header中解析出的链路信息，TraceID、SpanID为小写十六进制
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+	Sampled bool
+}
+
+type traceKey struct{}
+
+// WithTrace 在ctx中保存链路信息，通过Log.Ctx输出的日志会带上trace_id、span_id字段
+func WithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey{}, tc)
+}
+
+// TraceFrom 返回ctx中的链路信息
+func TraceFrom(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceKey{}).(TraceContext)
+	return tc, ok
+}
+
+// TraceFromRequest 依次从W3C traceparent、B3单header及B3多header中解析链路信息，不依赖OpenTelemetry
+func TraceFromRequest(r *http.Request) (TraceContext, bool) {
+	if tc, ok := ParseTraceparent(r.Header.Get(TraceparentHeader)); ok {
+		return tc, true
+	}
+	return ParseB3(r.Header)
+}
+
+// ParseTraceparent 解析W3C traceparent，如 "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func ParseTraceparent(h string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(h), "-")
+	// 未来版本可能在后面追加字段，00版本必须正好4段
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return TraceContext{}, false
+	}
+	traceID, spanID, flags := parts[1], parts[2], parts[3]
+	if !validTraceID(traceID, 32) || !validTraceID(spanID, 16) || len(flags) != 2 || !isHex(flags) {
+		return TraceContext{}, false
+	}
+	return TraceContext{TraceID: traceID, SpanID: spanID, Sampled: hexValue(flags[1])&1 == 1}, true
+}
+
+// ParseB3 解析B3单header "{TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}" 或 X-B3-TraceId 等多header，
+// 64位的TraceID左侧补零为128位
+func ParseB3(h http.Header) (TraceContext, bool) {
+	var traceID, spanID, sampled string
+	if b3 := h.Get(B3Header); b3 != "" {
+		parts := strings.Split(b3, "-")
+		// 只有采样标志时没有链路信息
+		if len(parts) < 2 {
+			return TraceContext{}, false
+		}
+		traceID, spanID = parts[0], parts[1]
+		if len(parts) > 2 {
+			sampled = parts[2]
+		}
+	} else {
+		traceID, spanID, sampled = h.Get(B3TraceIDHeader), h.Get(B3SpanIDHeader), h.Get(B3SampledHeader)
+	}
+	traceID, spanID = strings.ToLower(traceID), strings.ToLower(spanID)
+	if len(traceID) == 16 {
+		traceID = strings.Repeat("0", 16) + traceID
+	}
+	if !validTraceID(traceID, 32) || !validTraceID(spanID, 16) {
+		return TraceContext{}, false
+	}
+	return TraceContext{TraceID: traceID, SpanID: spanID, Sampled: sampled == "1" || sampled == "d" || sampled == "true"}, true
+}
+
+// TraceMiddleware 从请求header中解析链路信息并保存在请求的context中，HTTPMiddleware已包含该功能
+func TraceMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, withTrace(r))
+		})
+	}
+}
+
+func withTrace(r *http.Request) *http.Request {
+	if tc, ok := TraceFromRequest(r); ok {
+		return r.WithContext(WithTrace(r.Context(), tc))
+	}
+	return r
+}
+
+// validTraceID 检查id为n位小写十六进制且不全为0
+func validTraceID(id string, n int) bool {
+	return len(id) == n && isHex(id) && strings.Trim(id, "0") != ""
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func hexValue(c byte) byte {
+	if c >= 'a' {
+		return c - 'a' + 10
+	}
+	return c - '0'
+}
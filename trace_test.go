@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	_testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	_testSpanID  = "00f067aa0ba902b7"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		header string
+		want   TraceContext
+		ok     bool
+	}{
+		{"00-" + _testTraceID + "-" + _testSpanID + "-01", TraceContext{_testTraceID, _testSpanID, true}, true},
+		{"00-" + _testTraceID + "-" + _testSpanID + "-00", TraceContext{_testTraceID, _testSpanID, false}, true},
+		{" 00-" + _testTraceID + "-" + _testSpanID + "-01 ", TraceContext{_testTraceID, _testSpanID, true}, true},
+		// 其他flag位不影响采样
+		{"00-" + _testTraceID + "-" + _testSpanID + "-03", TraceContext{_testTraceID, _testSpanID, true}, true},
+		// 未来版本可以追加字段
+		{"01-" + _testTraceID + "-" + _testSpanID + "-01-extra", TraceContext{_testTraceID, _testSpanID, true}, true},
+		{"00-" + _testTraceID + "-" + _testSpanID + "-01-extra", TraceContext{}, false},
+		{"ff-" + _testTraceID + "-" + _testSpanID + "-01", TraceContext{}, false},
+		{"00-00000000000000000000000000000000-" + _testSpanID + "-01", TraceContext{}, false},
+		{"00-" + _testTraceID + "-0000000000000000-01", TraceContext{}, false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-" + _testSpanID + "-01", TraceContext{}, false},
+		{"00-" + _testTraceID[:31] + "-" + _testSpanID + "-01", TraceContext{}, false},
+		{"00-" + _testTraceID + "-" + _testSpanID + "-1", TraceContext{}, false},
+		{"00-" + _testTraceID + "-" + _testSpanID, TraceContext{}, false},
+		{"", TraceContext{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseTraceparent(tt.header)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseTraceparent(%q) = %+v, %v, want %+v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseB3(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   TraceContext
+		ok     bool
+	}{
+		{"single", map[string]string{B3Header: _testTraceID + "-" + _testSpanID + "-1"}, TraceContext{_testTraceID, _testSpanID, true}, true},
+		{"single with parent", map[string]string{B3Header: _testTraceID + "-" + _testSpanID + "-0-05e3ac9a4f6e3b90"}, TraceContext{_testTraceID, _testSpanID, false}, true},
+		{"single debug", map[string]string{B3Header: _testTraceID + "-" + _testSpanID + "-d"}, TraceContext{_testTraceID, _testSpanID, true}, true},
+		{"single no sampling", map[string]string{B3Header: _testTraceID + "-" + _testSpanID}, TraceContext{_testTraceID, _testSpanID, false}, true},
+		// 64位TraceID左侧补零
+		{"single 64 bit", map[string]string{B3Header: "a3ce929d0e0e4736-" + _testSpanID + "-1"}, TraceContext{"0000000000000000a3ce929d0e0e4736", _testSpanID, true}, true},
+		{"single sampling only", map[string]string{B3Header: "0"}, TraceContext{}, false},
+		{"multi", map[string]string{B3TraceIDHeader: _testTraceID, B3SpanIDHeader: _testSpanID, B3SampledHeader: "1"}, TraceContext{_testTraceID, _testSpanID, true}, true},
+		{"multi true", map[string]string{B3TraceIDHeader: _testTraceID, B3SpanIDHeader: _testSpanID, B3SampledHeader: "true"}, TraceContext{_testTraceID, _testSpanID, true}, true},
+		{"multi upper case", map[string]string{B3TraceIDHeader: "4BF92F3577B34DA6A3CE929D0E0E4736", B3SpanIDHeader: "00F067AA0BA902B7"}, TraceContext{_testTraceID, _testSpanID, false}, true},
+		{"multi missing span", map[string]string{B3TraceIDHeader: _testTraceID}, TraceContext{}, false},
+		{"invalid trace id", map[string]string{B3TraceIDHeader: "xyz", B3SpanIDHeader: _testSpanID}, TraceContext{}, false},
+		// 单header优先
+		{"single before multi", map[string]string{B3Header: _testTraceID + "-" + _testSpanID, B3TraceIDHeader: "0000000000000000a3ce929d0e0e4736", B3SpanIDHeader: "05e3ac9a4f6e3b90"},
+			TraceContext{_testTraceID, _testSpanID, false}, true},
+		{"none", nil, TraceContext{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.header {
+				h.Set(k, v)
+			}
+			got, ok := ParseB3(h)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("ParseB3() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	traceparent := "00-" + _testTraceID + "-" + _testSpanID + "-01"
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   TraceContext
+		ok     bool
+	}{
+		{"traceparent", map[string]string{TraceparentHeader: traceparent}, TraceContext{_testTraceID, _testSpanID, true}, true},
+		// traceparent优先于B3
+		{"traceparent before b3", map[string]string{TraceparentHeader: traceparent, B3Header: "0000000000000000a3ce929d0e0e4736-05e3ac9a4f6e3b90-0"},
+			TraceContext{_testTraceID, _testSpanID, true}, true},
+		// traceparent无效时使用B3
+		{"invalid traceparent", map[string]string{TraceparentHeader: "garbage", B3Header: _testTraceID + "-" + _testSpanID + "-0"},
+			TraceContext{_testTraceID, _testSpanID, false}, true},
+		{"none", nil, TraceContext{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got TraceContext
+			var ok bool
+			h := TraceMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, ok = TraceFrom(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("TraceFrom() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
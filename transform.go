@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// _entryPool 复用传给entry的*zapcore.Entry，避免ent逃逸到堆上
+var _entryPool = sync.Pool{New: func() interface{} { return new(zapcore.Entry) }}
+
+// transformCore 在日志交给内部core之前修改日志内容，用于脱敏、截断等处理。
+// 内部core由Check决定哪些需要写入，Write中重新Check以保证各core的级别过滤仍然生效
+type transformCore struct {
+	zapcore.Core
+	// entry 写入时处理日志消息，可以根据需要增加字段
+	entry func(ent *zapcore.Entry, fs []zapcore.Field) []zapcore.Field
+	// fields 处理With和写入时的字段
+	fields func(fs []zapcore.Field) []zapcore.Field
+}
+
+func newTransformCore(core zapcore.Core, entry func(*zapcore.Entry, []zapcore.Field) []zapcore.Field, fields func([]zapcore.Field) []zapcore.Field) zapcore.Core {
+	return &transformCore{Core: core, entry: entry, fields: fields}
+}
+
+func (c *transformCore) With(fs []zapcore.Field) zapcore.Core {
+	if c.fields != nil {
+		fs = c.fields(fs)
+	}
+	return &transformCore{Core: c.Core.With(fs), entry: c.entry, fields: c.fields}
+}
+
+func (c *transformCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *transformCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	if c.fields != nil {
+		fs = c.fields(fs)
+	}
+	if c.entry != nil {
+		e := _entryPool.Get().(*zapcore.Entry)
+		*e = ent
+		fs = c.entry(e, fs)
+		ent = *e
+		*e = zapcore.Entry{}
+		_entryPool.Put(e)
+	}
+	// fatal、panic的退出由外层CheckedEntry处理，这里只负责写入
+	inner := c.Core.Check(ent, nil)
+	if inner == nil {
+		return nil
+	}
+	inner.ErrorOutput = errorOutput{}
+	inner.Write(fs...)
+	return nil
+}
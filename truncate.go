@@ -0,0 +1,168 @@
+package logger
+
+import (
+	"encoding/json"
+	"sort"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	_ellipsis       = "..."
+	_truncatedField = "truncated"
+)
+
+// truncator 限制日志消息、单个字段和整条日志的大小(字节)，0表示不限制
+type truncator struct {
+	message int
+	field   int
+	entry   int
+}
+
+// truncateString 截断到不超过max字节并加上省略号，不会截断在多字节字符中间
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	n := max - len(_ellipsis)
+	if n <= 0 {
+		return _ellipsis[:max]
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + _ellipsis
+}
+
+// fieldText 返回字段的文本形式，对象、数组等按json编码
+func fieldText(f zapcore.Field) (string, bool) {
+	if s, ok := fieldString(f); ok {
+		return s, true
+	}
+	switch f.Type {
+	case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		b, err := json.Marshal(enc.Fields[f.Key])
+		if err != nil {
+			return "", false
+		}
+		return string(b), true
+	}
+	return "", false
+}
+
+// truncateFields 截断超过field大小的字段，返回截断后的fields及是否有字段被截断
+func (t truncator) truncateFields(fs []zapcore.Field, max int) ([]zapcore.Field, bool) {
+	var out []zapcore.Field
+	for i, f := range fs {
+		text, ok := fieldText(f)
+		if !ok || len(text) <= max {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, i, len(fs))
+			copy(out, fs[:i])
+		}
+		out = append(out, zap.String(f.Key, truncateString(text, max)))
+	}
+	if out == nil {
+		return fs, false
+	}
+	return out, true
+}
+
+func (t truncator) fields(fs []zapcore.Field) []zapcore.Field {
+	if t.field <= 0 {
+		return fs
+	}
+	out, truncated := t.truncateFields(fs, t.field)
+	if truncated {
+		out = append(out, zap.Bool(_truncatedField, true))
+	}
+	return out
+}
+
+func (t truncator) truncateEntry(ent *zapcore.Entry, fs []zapcore.Field) []zapcore.Field {
+	truncated := false
+	for _, f := range fs {
+		if f.Key == _truncatedField && f.Type == zapcore.BoolType {
+			truncated = true
+		}
+	}
+	if t.message > 0 && len(ent.Message) > t.message {
+		ent.Message = truncateString(ent.Message, t.message)
+		truncated = true
+	}
+
+	if t.entry > 0 {
+		sizes := make([]int, len(fs))
+		total := len(ent.Message)
+		for i, f := range fs {
+			text, _ := fieldText(f)
+			sizes[i] = len(f.Key) + len(text)
+			total += sizes[i]
+		}
+		if excess := total - t.entry; excess > 0 {
+			fs = append([]zapcore.Field(nil), fs...)
+			// 从最大的字段开始截断，直到总大小不超过限制
+			order := make([]int, len(fs))
+			for i := range order {
+				order[i] = i
+			}
+			sort.Slice(order, func(a, b int) bool { return sizes[order[a]] > sizes[order[b]] })
+			for _, i := range order {
+				if excess <= 0 {
+					break
+				}
+				text, ok := fieldText(fs[i])
+				if !ok || len(text) <= len(_ellipsis) {
+					continue
+				}
+				keep := len(text) - excess
+				if keep < len(_ellipsis) {
+					keep = len(_ellipsis)
+				}
+				short := truncateString(text, keep)
+				excess -= len(text) - len(short)
+				fs[i] = zap.String(fs[i].Key, short)
+				truncated = true
+			}
+			if excess > 0 {
+				keep := len(ent.Message) - excess
+				if keep < len(_ellipsis) {
+					keep = len(_ellipsis)
+				}
+				ent.Message = truncateString(ent.Message, keep)
+				truncated = true
+			}
+		}
+	}
+
+	if truncated && !hasField(fs, _truncatedField) {
+		fs = append(fs, zap.Bool(_truncatedField, true))
+	}
+	return fs
+}
+
+func hasField(fs []zapcore.Field, key string) bool {
+	for _, f := range fs {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// truncator MaxMessageSize、MaxFieldSize、MaxEntrySize都未配置时返回nil
+func (c *LogOptions) truncator() *truncator {
+	if c.MaxMessageSize <= 0 && c.MaxFieldSize <= 0 && c.MaxEntrySize <= 0 {
+		return nil
+	}
+	return &truncator{message: c.MaxMessageSize, field: c.MaxFieldSize, entry: c.MaxEntrySize}
+}
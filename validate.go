@@ -0,0 +1,226 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldError 单个配置项的错误，Field为配置文件中的路径，如 "sentry_config.level"
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + strings.TrimPrefix(e.Err.Error(), "logger: ")
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors Validate发现的所有错误
+type ValidationErrors []*FieldError
+
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return "logger: invalid config: " + strings.Join(msgs, "; ")
+}
+
+// validator 收集配置错误
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) add(field string, err error) {
+	if err != nil {
+		v.errs = append(v.errs, &FieldError{Field: field, Err: err})
+	}
+}
+
+func (v *validator) addf(field, format string, args ...interface{}) {
+	v.add(field, fmt.Errorf(format, args...))
+}
+
+func (v *validator) nonNegative(field string, n int) {
+	if n < 0 {
+		v.addf(field, "must not be negative, got %d", n)
+	}
+}
+
+func (v *validator) level(field, text string) {
+	if text != "" {
+		var l zapcore.Level
+		v.add(field, unmarshalLevel(&l, text))
+	}
+}
+
+// dir 检查filename所在目录可以使用：已存在时必须是目录，不存在时最近的已存在上级必须是目录
+func (v *validator) dir(field, filename string) {
+	if filename == "" {
+		return
+	}
+	dir := filepath.Dir(filename)
+	for {
+		fi, err := os.Stat(dir)
+		if err == nil {
+			if !fi.IsDir() {
+				v.addf(field, "%s is not a directory", dir)
+			}
+			return
+		}
+		if !os.IsNotExist(err) {
+			v.add(field, err)
+			return
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			v.add(field, err)
+			return
+		}
+		dir = parent
+	}
+}
+
+// Validate 检查配置，返回包含所有错误及其配置路径的ValidationErrors，配置合法时返回nil
+func (c *LogOptions) Validate() error {
+	v := &validator{}
+
+	if c.Encoding != "" {
+		if _, ok := _encoderNameToConstructor[c.Encoding]; !ok {
+			v.addf("encoding", "unknown encoding %q", c.Encoding)
+		}
+	}
+	switch c.Division {
+	case "", TimeDivision, SizeDivision, HybridDivision:
+	default:
+		v.addf("division", "unknown division %q, want %q, %q or %q", c.Division, TimeDivision, SizeDivision, HybridDivision)
+	}
+	switch c.TimeUnit {
+	case "", Minute, Hour, Day, Month, Year:
+	default:
+		v.addf("time_unit", "unknown time unit %q, want %q, %q, %q, %q or %q", c.TimeUnit, Minute, Hour, Day, Month, Year)
+	}
+	if c.DayDirectory && (c.Division == "" || c.Division == SizeDivision) {
+		v.addf("day_directory", "requires %q or %q division", TimeDivision, HybridDivision)
+	}
+	if c.LevelSeparate && c.ErrorFilename == "" {
+		v.addf("error_filename", "required when level_separate is enabled")
+	}
+	if c.ErrorFilename != "" && c.InfoFilename == "" {
+		v.addf("info_filename", "required when error_filename is set")
+	}
+	if c.Level > int8(zapcore.FatalLevel) {
+		v.addf("level", "unknown level %d", c.Level)
+	}
+
+	v.nonNegative("max_size", c.MaxSize)
+	v.nonNegative("max_backups", c.MaxBackups)
+	v.nonNegative("max_age", c.MaxAge)
+	v.nonNegative("max_total_size", c.MaxTotalSize)
+	v.nonNegative("max_message_size", c.MaxMessageSize)
+	v.nonNegative("max_field_size", c.MaxFieldSize)
+	v.nonNegative("max_entry_size", c.MaxEntrySize)
+	v.nonNegative("stacktrace_skip_frames", c.StacktraceSkipFrames)
+	v.nonNegative("stacktrace_max_frames", c.StacktraceMaxFrames)
+	switch strings.ToLower(c.Mode) {
+	case "", ModeProduction, ModeDevelopment:
+	default:
+		v.addf("mode", "unknown mode %q", c.Mode)
+	}
+	v.nonNegative("crash_context_size", c.CrashContextSize)
+	v.nonNegative("compression_workers", c.CompressionWorkers)
+	v.nonNegative("file_buffer_size", c.FileBufferSize)
+	v.nonNegative("file_flush_interval", c.FileFlushInterval)
+	v.nonNegative("heartbeat.interval", c.Heartbeat.Interval)
+	v.dir("access_log.filename", c.AccessLog.Filename)
+	v.dir("sqlite.path", c.SQLite.Path)
+	v.level("sqlite.level", c.SQLite.Level)
+	if c.SQLite.Table != "" && !_sqlIdentifier.MatchString(c.SQLite.Table) {
+		v.addf("sqlite.table", "invalid table name %q", c.SQLite.Table)
+	}
+	v.nonNegative("sqlite.max_rows", c.SQLite.MaxRows)
+	v.nonNegative("sqlite.max_size", c.SQLite.MaxSize)
+	for i, db := range c.Databases {
+		field := fmt.Sprintf("databases[%d]", i)
+		if _, err := newDialect(db); err != nil {
+			v.add(field+".type", err)
+		}
+		if db.DSN == "" {
+			v.addf(field+".dsn", "required")
+		}
+		if db.Table != "" && !_sqlTableName.MatchString(db.Table) {
+			v.addf(field+".table", "invalid table name %q", db.Table)
+		}
+		v.level(field+".level", db.Level)
+		v.nonNegative(field+".queue_size", db.QueueSize)
+	}
+	for i, w := range c.Maintenance {
+		if _, err := newMaintenanceWindow(w, time.Local); err != nil {
+			v.add(fmt.Sprintf("maintenance[%d]", i), err)
+		}
+	}
+	v.nonNegative("sampling.initial", c.Sampling.Initial)
+	v.nonNegative("sampling.thereafter", c.Sampling.Thereafter)
+	for i, rule := range c.Sampling.Rules {
+		if _, err := parsePredicate(rule.Match); err != nil {
+			v.add(fmt.Sprintf("sampling.rules[%d].match", i), err)
+		}
+		v.nonNegative(fmt.Sprintf("sampling.rules[%d].rate", i), rule.Rate)
+	}
+	switch strings.ToLower(c.AccessLog.Format) {
+	case "", AccessFormatCombined, AccessFormatCommon, AccessFormatJson:
+	default:
+		v.addf("access_log.format", "unknown access log format %q", c.AccessLog.Format)
+	}
+
+	v.dir("info_filename", c.InfoFilename)
+	v.dir("error_filename", c.ErrorFilename)
+	v.dir("audit.filename", c.Audit.Filename)
+
+	switch c.Compression {
+	case "", CompressionGzip, CompressionZstd, CompressionNone:
+	default:
+		v.addf("compression", "unknown compression %q", c.Compression)
+	}
+	if c.TimeZone != "" {
+		_, err := time.LoadLocation(c.TimeZone)
+		v.add("time_zone", err)
+	}
+	if c.RotationCron != "" {
+		_, err := cron.ParseStandard(c.RotationCron)
+		v.add("rotation_cron", err)
+	}
+	if _, err := c.dirMode(); err != nil {
+		v.add("dir_mode", err)
+	}
+	if _, err := c.fileMode(); err != nil {
+		v.add("file_mode", err)
+	}
+	if len(c.LevelRules) > 0 {
+		_, err := newLevelRules(c.LevelRules, zapcore.Level(c.Level), newCallerFinder(nil))
+		v.add("level_rules", err)
+	}
+	v.level("stacktrace_level", c.StacktraceLevel)
+	v.level("sentry_config.level", c.SentryConfig.Level)
+	v.level("sentry_config.breadcrumb_level", c.SentryConfig.BreadcrumbLevel)
+	if _, err := newSentryFilter(c.SentryConfig.Filter, nil); err != nil {
+		v.add("sentry_config.filter", err)
+	}
+	v.nonNegative("runtime_stats.interval", c.RuntimeStats.Interval)
+	v.level("runtime_stats.level", c.RuntimeStats.Level)
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		set   func(c *LogOptions)
+		field string
+	}{
+		{"encoding", func(c *LogOptions) { c.Encoding = "xml" }, "encoding"},
+		{"division", func(c *LogOptions) { c.Division = "weekly" }, "division"},
+		{"time unit", func(c *LogOptions) { c.TimeUnit = "week" }, "time_unit"},
+		{"day directory", func(c *LogOptions) { c.DayDirectory = true; c.Division = SizeDivision }, "day_directory"},
+		{"level separate", func(c *LogOptions) { c.LevelSeparate = true; c.InfoFilename = "app.log" }, "error_filename"},
+		{"error without info", func(c *LogOptions) { c.ErrorFilename = "error.log" }, "info_filename"},
+		{"level", func(c *LogOptions) { c.Level = 6 }, "level"},
+		{"max size", func(c *LogOptions) { c.MaxSize = -1 }, "max_size"},
+		{"max age", func(c *LogOptions) { c.MaxAge = -1 }, "max_age"},
+		{"mode", func(c *LogOptions) { c.Mode = "staging" }, "mode"},
+		// 上级是文件，无法创建目录
+		{"info dir", func(c *LogOptions) { c.InfoFilename = filepath.Join(file, "app.log") }, "info_filename"},
+		{"compression", func(c *LogOptions) { c.Compression = "bzip2" }, "compression"},
+		{"time zone", func(c *LogOptions) { c.TimeZone = "Mars/Olympus" }, "time_zone"},
+		{"rotation cron", func(c *LogOptions) { c.RotationCron = "every day" }, "rotation_cron"},
+		{"dir mode", func(c *LogOptions) { c.DirMode = "rwx" }, "dir_mode"},
+		{"file mode", func(c *LogOptions) { c.FileMode = "0999" }, "file_mode"},
+		{"level rules", func(c *LogOptions) { c.LevelRules = []string{"app/db"} }, "level_rules"},
+		{"stacktrace level", func(c *LogOptions) { c.StacktraceLevel = "loud" }, "stacktrace_level"},
+		{"sentry level", func(c *LogOptions) { c.SentryConfig.Level = "loud" }, "sentry_config.level"},
+		{"maintenance", func(c *LogOptions) { c.Maintenance = []MaintenanceWindow{{Schedule: "0 2 * * 6"}} }, "maintenance[0]"},
+		{"sampling", func(c *LogOptions) { c.Sampling.Initial = -1 }, "sampling.initial"},
+		{"sampling rule", func(c *LogOptions) {
+			c.Sampling.Rules = []SamplingRule{{Match: `msg == "a"`}, {Match: `status >= 500 &&`}}
+		}, "sampling.rules[1].match"},
+		{"database dsn", func(c *LogOptions) { c.Databases = []DatabaseConfig{{Type: "postgres"}} }, "databases[0].dsn"},
+		{"access log format", func(c *LogOptions) { c.AccessLog.Format = "xml" }, "access_log.format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c LogOptions
+			tt.set(&c)
+			err := c.Validate()
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate() = %v, want ValidationErrors", err)
+			}
+			if len(errs) != 1 || errs[0].Field != tt.field {
+				t.Fatalf("Validate() = %v, want one error for %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestValidateCollectsAllErrors(t *testing.T) {
+	var c LogOptions
+	if err := c.Validate(); err != nil {
+		t.Fatalf("zero config: %v", err)
+	}
+	c.Encoding = "xml"
+	c.MaxBackups = -1
+	c.SentryConfig.BreadcrumbLevel = "loud"
+	err := c.Validate()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Validate() = %v, want ValidationErrors", err)
+	}
+	want := []string{"encoding", "max_backups", "sentry_config.breadcrumb_level"}
+	if len(errs) != len(want) {
+		t.Fatalf("Validate() = %v, want errors for %v", err, want)
+	}
+	for i, field := range want {
+		if errs[i].Field != field {
+			t.Errorf("errs[%d].Field = %s, want %s", i, errs[i].Field, field)
+		}
+	}
+}
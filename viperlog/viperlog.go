@@ -0,0 +1,39 @@
+// Package viperlog 从viper的配置树中读取 logger.LogOptions，已经使用viper的应用不需要再单独维护日志配置文件
+//
+//	c, err := viperlog.NewFromViper(v, "log")
+//	log := c.InitLoggerWith(logger.EncoderOptions{})
+//
+// viper会把所有key转换为小写，fields等需要区分大小写的配置应使用小写的key
+package viperlog
+
+import (
+	"fmt"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mae-pax/logger"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// NewFromViper 读取v中key下的配置，key为空时读取全部配置，同样支持profile及环境变量，见logger.NewFromBytes
+func NewFromViper(v *viper.Viper, key string) (*logger.LogOptions, error) {
+	if key != "" {
+		if v = v.Sub(key); v == nil {
+			return nil, fmt.Errorf("logger: viper key %q not found", key)
+		}
+	}
+	b, err := yaml.Marshal(v.AllSettings())
+	if err != nil {
+		return nil, err
+	}
+	return logger.NewFromBytes(b, logger.FormatYaml)
+}
+
+// Watch 监听viper的配置文件，变化后重新读取key下的配置并调用fn，fn中通常重建Log并替换旧的Log，
+// 会覆盖v中已有的OnConfigChange回调
+func Watch(v *viper.Viper, key string, fn func(*logger.LogOptions, error)) {
+	v.OnConfigChange(func(fsnotify.Event) {
+		fn(NewFromViper(v, key))
+	})
+	v.WatchConfig()
+}
@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 以下类型化的字段函数不经过反射，热点路径上应优先使用，With仅用于类型不确定的值
+
+func WithString(k string, v string) zap.Field {
+	return zap.String(k, v)
+}
+
+func WithInt(k string, v int) zap.Field {
+	return zap.Int(k, v)
+}
+
+func WithInt64(k string, v int64) zap.Field {
+	return zap.Int64(k, v)
+}
+
+func WithUint64(k string, v uint64) zap.Field {
+	return zap.Uint64(k, v)
+}
+
+func WithFloat(k string, v float64) zap.Field {
+	return zap.Float64(k, v)
+}
+
+func WithBool(k string, v bool) zap.Field {
+	return zap.Bool(k, v)
+}
+
+func WithDuration(k string, v time.Duration) zap.Field {
+	return zap.Duration(k, v)
+}
+
+func WithTime(k string, v time.Time) zap.Field {
+	return zap.Time(k, v)
+}
+
+func WithStrings(k string, v []string) zap.Field {
+	return zap.Strings(k, v)
+}
+
+func WithInts(k string, v []int) zap.Field {
+	return zap.Ints(k, v)
+}
+
+// WithBytes 以base64输出二进制数据
+func WithBytes(k string, v []byte) zap.Field {
+	return zap.Binary(k, v)
+}
+
+// WithJSON 原样嵌入一段JSON，JSON编码时不会被转义为字符串，raw必须是合法的JSON
+func WithJSON(k string, raw []byte) zap.Field {
+	return zap.Reflect(k, json.RawMessage(raw))
+}
+
+// WithStringer 在输出时才调用v.String()，日志级别未启用时没有开销
+func WithStringer(k string, v fmt.Stringer) zap.Field {
+	return zap.Stringer(k, v)
+}
+
+// WithFields 把map或结构体转换为字段，便于从logrus.WithFields迁移或处理动态字段：
+// map的key按字典序输出；结构体使用导出字段，字段名取log标签，其次json标签，
+// 标签为 "-" 的字段忽略，带omitempty的零值字段忽略，匿名嵌入的结构体展开
+func WithFields(v interface{}) []zap.Field {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		fields := make([]zap.Field, 0, len(keys))
+		for _, k := range keys {
+			fields = append(fields, zap.Any(k, rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface()))
+		}
+		return fields
+	case reflect.Struct:
+		return structFields(nil, rv)
+	}
+	return []zap.Field{zap.Any("fields", v)}
+}
+
+func structFields(fields []zap.Field, rv reflect.Value) []zap.Field {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		name, opts := sf.Tag.Get("log"), ""
+		if name == "" {
+			name = sf.Tag.Get("json")
+		}
+		if j := strings.IndexByte(name, ','); j >= 0 {
+			name, opts = name[:j], name[j+1:]
+		}
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if sf.Anonymous && name == "" {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				fields = structFields(fields, fv)
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, zap.Any(name, fv.Interface()))
+	}
+	return fields
+}
+
+// Namespace 之后的字段都嵌套在key下，同一个Log上多次调用会逐层嵌套
+func Namespace(key string) zap.Field {
+	return zap.Namespace(key)
+}
+
+// WithObject 以嵌套对象输出实现了zapcore.ObjectMarshaler的值，不经过反射
+func WithObject[T zapcore.ObjectMarshaler](k string, v T) zap.Field {
+	return zap.Object(k, v)
+}
+
+// WithObjects 以对象数组输出多个实现了zapcore.ObjectMarshaler的值
+func WithObjects[T zapcore.ObjectMarshaler](k string, vs []T) zap.Field {
+	return zap.Array(k, zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, v := range vs {
+			if err := enc.AppendObject(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// WithObjectFunc 用函数输出嵌套对象，适合临时组织字段而不需要定义类型
+func WithObjectFunc(k string, fn func(enc zapcore.ObjectEncoder) error) zap.Field {
+	return zap.Object(k, zapcore.ObjectMarshalerFunc(fn))
+}
+
+// WithArray 以数组输出实现了zapcore.ArrayMarshaler的值
+func WithArray(k string, v zapcore.ArrayMarshaler) zap.Field {
+	return zap.Array(k, v)
+}